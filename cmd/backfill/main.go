@@ -0,0 +1,76 @@
+// Command backfill fills in data for photos uploaded before a feature
+// existed. It uses the same .env configuration as the API server.
+//
+// Usage:
+//
+//	go run ./cmd/backfill variants
+//	go run ./cmd/backfill placeholders
+//	go run ./cmd/backfill sizes
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mini-project-alterra/configs"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/repositories"
+	"mini-project-alterra/usecases"
+	"os"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 100, "number of rows loaded per query")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: backfill [-batch n] variants|placeholders|sizes")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := configs.ConnectDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = configs.MigrateDB(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mediaStore, err := helpers.NewMediaStore(configs.EnvStorageBackend())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.ImageLimitsFromEnv())
+	backfillUsecase := usecases.NewBackfillUsecase(repositories.NewPhotoRepository(db), mediaUpload)
+
+	switch flag.Arg(0) {
+	case "variants":
+		updated, err := backfillUsecase.BackfillVariants(*batchSize)
+		log.Printf("generated variants for %d photo items", updated)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "placeholders":
+		updated, err := backfillUsecase.BackfillPlaceholders(*batchSize)
+		log.Printf("computed placeholders for %d photo items", updated)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "sizes":
+		updated, err := backfillUsecase.BackfillSizes(*batchSize)
+		log.Printf("looked up the size of %d photo items and variants", updated)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,44 @@
+// Command sweep removes stored media that no photo refers to. It uses the
+// same .env configuration as the API server, which also runs the sweeper
+// every MEDIA_SWEEP_INTERVAL.
+//
+// Usage:
+//
+//	go run ./cmd/sweep [-dry-run] [-grace 24h]
+package main
+
+import (
+	"flag"
+	"log"
+	"mini-project-alterra/configs"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/repositories"
+	"mini-project-alterra/usecases"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only list the orphaned objects")
+	grace := flag.Duration("grace", configs.EnvMediaSweepGrace(), "minimum age of an object before it is deleted")
+	flag.Parse()
+
+	db, err := configs.ConnectDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mediaStore, err := helpers.NewMediaStore(configs.EnvStorageBackend())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mediaSweeper := usecases.NewMediaSweeper(mediaStore, repositories.NewPhotoRepository(db), *grace)
+
+	result, err := mediaSweeper.Sweep(*dryRun)
+	for _, key := range result.Orphaned {
+		log.Printf("orphaned: %s", key)
+	}
+	log.Printf("checked %d objects, %d orphaned, %d deleted", result.Checked, len(result.Orphaned), result.Deleted)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -37,9 +37,41 @@ func ConnectDB() (*gorm.DB, error) {
 }
 
 func MigrateDB(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		models.User{}, models.Photo{}, models.Comment{}, models.SocialMedia{},
+		models.Album{}, models.AlbumPhoto{}, models.Tag{}, models.PhotoItem{},
+		models.PhotoVariant{}, models.PhotoMetadata{}, models.Follow{},
+		models.Block{}, models.UserTag{}, models.PhotoRevision{}, models.Upload{},
+		models.PresignedUpload{}, models.PhotoViewCount{}, models.PhotoDailyViewer{},
+		models.ModerationCase{}, models.Report{}, models.ModerationNote{}, models.ModerationAction{},
+		models.Story{}, models.StoryView{}, models.StoryHighlight{}, models.Repost{},
 	)
+	if err != nil {
+		return err
+	}
+
+	return migratePhotoItems(db)
+}
+
+// migratePhotoItems turns photos from before carousel posts into posts with
+// a single item and moves their variants to that item. It only touches rows
+// that were not migrated yet, so it is safe to run on every start. The items
+// are created without a size; `go run ./cmd/backfill sizes` looks it up in
+// the media store.
+func migratePhotoItems(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO photo_items (photo_id, position, url, storage_key, alt_text, width, height, created_at)
+			SELECT photos.id, 0, photos.photo_url, photos.storage_key, '', 0, 0, photos.created_at FROM photos
+			WHERE NOT EXISTS (SELECT 1 FROM photo_items WHERE photo_items.photo_id = photos.id)`).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`UPDATE photo_variants
+			JOIN photo_items ON photo_items.photo_id = photo_variants.photo_id AND photo_items.position = 0
+			SET photo_variants.photo_item_id = photo_items.id
+			WHERE photo_variants.photo_item_id IS NULL`).Error
+	})
 }
 
 func InitDBTest() {
@@ -0,0 +1,8 @@
+package configs
+
+// EnvReportHideThreshold returns after how many reports content is hidden
+// until a moderator reviews it. Reported users are never hidden. Zero
+// disables hiding.
+func EnvReportHideThreshold() int64 {
+	return envInt64("REPORT_HIDE_THRESHOLD", 5)
+}
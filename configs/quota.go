@@ -0,0 +1,18 @@
+package configs
+
+import "mini-project-alterra/models"
+
+// EnvStorageQuotas returns the storage quota of every tier. A limit of zero
+// disables it.
+func EnvStorageQuotas() map[string]models.StorageQuota {
+	return map[string]models.StorageQuota{
+		models.UserQuotaStandard: {
+			MaxBytes:  envInt64("QUOTA_STANDARD_BYTES", 1<<30),
+			MaxPhotos: envInt64("QUOTA_STANDARD_PHOTOS", 1000),
+		},
+		models.UserQuotaExtended: {
+			MaxBytes:  envInt64("QUOTA_EXTENDED_BYTES", 10<<30),
+			MaxPhotos: envInt64("QUOTA_EXTENDED_PHOTOS", 10000),
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package configs
+
+import "time"
+
+// EnvPhotoScheduleInterval returns how often scheduled posts are checked
+// for publication. Zero disables the scheduler.
+func EnvPhotoScheduleInterval() time.Duration {
+	return envDuration("PHOTO_SCHEDULE_INTERVAL", time.Minute)
+}
+
+// EnvPhotoViewWindow returns how long repeated views of a photo by the same
+// user count as one.
+func EnvPhotoViewWindow() time.Duration {
+	return envDuration("PHOTO_VIEW_WINDOW", 30*time.Minute)
+}
+
+// EnvPhotoViewFlushInterval returns how often counted views are written to
+// the database.
+func EnvPhotoViewFlushInterval() time.Duration {
+	return envDuration("PHOTO_VIEW_FLUSH_INTERVAL", time.Minute)
+}
+
+// EnvStoryExpireInterval returns how often expired stories are removed. Zero
+// disables the expirer.
+func EnvStoryExpireInterval() time.Duration {
+	return envDuration("STORY_EXPIRE_INTERVAL", 10*time.Minute)
+}
+
+// EnvStoryArchiveRetention returns how long expired stories are kept in the
+// archive of their author, to be saved into a highlight, before their media
+// is removed.
+func EnvStoryArchiveRetention() time.Duration {
+	return envDuration("STORY_ARCHIVE_RETENTION", 7*24*time.Hour)
+}
+
+// EnvAccountPurgeInterval returns how often the photos and stories of
+// deleted accounts are removed. Zero disables the purger.
+func EnvAccountPurgeInterval() time.Duration {
+	return envDuration("ACCOUNT_PURGE_INTERVAL", time.Hour)
+}
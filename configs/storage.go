@@ -0,0 +1,127 @@
+package configs
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func envOrDefault(key, fallback string) string {
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	return getEnv(key, fallback)
+}
+
+// EnvStorageBackend returns the media storage backend: "cloudinary", "local"
+// or "s3".
+func EnvStorageBackend() string {
+	return envOrDefault("STORAGE_BACKEND", "cloudinary")
+}
+
+func EnvStorageLocalDir() string {
+	return envOrDefault("STORAGE_LOCAL_DIR", "uploads")
+}
+
+func EnvStorageLocalBaseURL() string {
+	return envOrDefault("STORAGE_LOCAL_BASE_URL", "/media")
+}
+
+func EnvStorageSigningKey() string {
+	return envOrDefault("STORAGE_SIGNING_KEY", os.Getenv("SECRET_JWT"))
+}
+
+// EnvStorageURLExpiry returns how long the media URLs in responses stay
+// valid on the local backend, which only serves signed URLs. A URL is
+// valid for at least this long and at most twice as long.
+func EnvStorageURLExpiry() time.Duration {
+	return envDuration("STORAGE_URL_EXPIRY", time.Hour)
+}
+
+func EnvS3Endpoint() string {
+	return envOrDefault("S3_ENDPOINT", "http://127.0.0.1:9000")
+}
+
+func EnvS3Region() string {
+	return envOrDefault("S3_REGION", "us-east-1")
+}
+
+func EnvS3Bucket() string {
+	return envOrDefault("S3_BUCKET", "pixelfeed")
+}
+
+func EnvS3AccessKey() string {
+	return envOrDefault("S3_ACCESS_KEY", "")
+}
+
+func EnvS3SecretKey() string {
+	return envOrDefault("S3_SECRET_KEY", "")
+}
+
+// EnvS3PublicURL returns the base URL objects are served from. When empty
+// the bucket URL on S3_ENDPOINT is used.
+func EnvS3PublicURL() string {
+	return envOrDefault("S3_PUBLIC_URL", "")
+}
+
+func envInt64(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(envOrDefault(key, ""), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func EnvImageMaxBytes() int64 {
+	return envInt64("IMAGE_MAX_BYTES", 10<<20)
+}
+
+func EnvImageMaxWidth() int {
+	return int(envInt64("IMAGE_MAX_WIDTH", 8000))
+}
+
+func EnvImageMaxHeight() int {
+	return int(envInt64("IMAGE_MAX_HEIGHT", 8000))
+}
+
+func EnvImageMaxPixels() int64 {
+	return envInt64("IMAGE_MAX_PIXELS", 40000000)
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(envOrDefault(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(envOrDefault(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// EnvMediaSweepInterval returns how often orphaned media is swept. Zero
+// disables the sweeper.
+func EnvMediaSweepInterval() time.Duration {
+	return envDuration("MEDIA_SWEEP_INTERVAL", 24*time.Hour)
+}
+
+// EnvMediaSweepGrace returns how old an unreferenced object must be before
+// the sweeper deletes it, so uploads still being saved are left alone.
+func EnvMediaSweepGrace() time.Duration {
+	return envDuration("MEDIA_SWEEP_GRACE", 24*time.Hour)
+}
+
+// EnvMediaSweepDryRun reports whether the sweeper only logs what it would
+// delete.
+func EnvMediaSweepDryRun() bool {
+	return envBool("MEDIA_SWEEP_DRY_RUN", false)
+}
@@ -0,0 +1,27 @@
+package configs
+
+import "time"
+
+// EnvUploadStagingDir returns where resumable uploads are kept until they
+// are complete. It must not be below STORAGE_LOCAL_DIR, which is served
+// publicly.
+func EnvUploadStagingDir() string {
+	return envOrDefault("UPLOAD_STAGING_DIR", "staging")
+}
+
+// EnvUploadExpiry returns how long a resumable upload is kept after its
+// last chunk.
+func EnvUploadExpiry() time.Duration {
+	return envDuration("UPLOAD_EXPIRY", 24*time.Hour)
+}
+
+// EnvUploadExpireInterval returns how often expired uploads are removed.
+// Zero disables the expirer.
+func EnvUploadExpireInterval() time.Duration {
+	return envDuration("UPLOAD_EXPIRE_INTERVAL", time.Hour)
+}
+
+// EnvUploadPresignExpiry returns how long a direct upload form stays valid.
+func EnvUploadPresignExpiry() time.Duration {
+	return envDuration("UPLOAD_PRESIGN_EXPIRY", 15*time.Minute)
+}
@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AlbumController struct {
+	userUsecase  usecases.UserUsecase
+	albumUsecase usecases.AlbumUsecase
+}
+
+func NewAlbumController(userUsecase usecases.UserUsecase, albumUsecase usecases.AlbumUsecase) AlbumController {
+	return AlbumController{userUsecase, albumUsecase}
+}
+
+func (ac *AlbumController) CreateAlbum(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var albumInput models.AlbumInput
+
+	err = c.Bind(&albumInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Failed to create album",
+			})
+	}
+
+	album, err := ac.albumUsecase.CreateAlbum(albumInput, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully created album",
+			"data":    models.ParseAlbumToResponse(album),
+		})
+}
+
+func (ac *AlbumController) GetAlbum(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albumID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	album, err := ac.albumUsecase.GetAlbum(albumID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved album",
+			"data":    models.ParseAlbumToResponse(album),
+		})
+}
+
+func (ac *AlbumController) GetUserAlbums(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albums, err := ac.albumUsecase.GetUserAlbums(c.Param("username"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved albums",
+			"data":    models.ParseAlbumToResponseArray(albums),
+		})
+}
+
+func (ac *AlbumController) UpdateAlbum(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albumID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var albumInput models.AlbumInput
+
+	err = c.Bind(&albumInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	album, validID, err := ac.albumUsecase.UpdateAlbum(albumInput, albumID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	if validID != userID {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Invalid User ID",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully updated album",
+			"data":    models.ParseAlbumToResponse(album),
+		})
+}
+
+func (ac *AlbumController) DeleteAlbum(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albumID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	validID, err := ac.albumUsecase.DeleteAlbum(albumID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Album ID cannot be found",
+			})
+	}
+
+	if validID != userID {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Invalid User ID",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully deleted album",
+		})
+}
+
+func (ac *AlbumController) AddAlbumPhoto(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albumID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var input models.AlbumPhotoInput
+
+	err = c.Bind(&input)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	album, validID, err := ac.albumUsecase.AddPhoto(input, albumID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	if validID != userID {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Invalid User ID",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully added photo to album",
+			"data":    models.ParseAlbumToResponse(album),
+		})
+}
+
+func (ac *AlbumController) RemoveAlbumPhoto(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albumID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	album, validID, err := ac.albumUsecase.RemovePhoto(albumID, photoID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	if validID != userID {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Invalid User ID",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully removed photo from album",
+			"data":    models.ParseAlbumToResponse(album),
+		})
+}
+
+func (ac *AlbumController) ReorderAlbumPhotos(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := ac.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	albumID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var input models.AlbumOrderInput
+
+	err = c.Bind(&input)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	album, validID, err := ac.albumUsecase.ReorderPhotos(input, albumID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	if validID != userID {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Invalid User ID",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully reordered album",
+			"data":    models.ParseAlbumToResponse(album),
+		})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// ownedAlbumUsecase has one album, with ID 1, owned by ownerID.
+type ownedAlbumUsecase struct {
+	usecases.AlbumUsecase
+	ownerID int
+}
+
+func (u ownedAlbumUsecase) GetAlbum(albumID, viewerID int) (models.Album, error) {
+	if albumID != 1 {
+		return models.Album{}, errors.New("Album not found")
+	}
+	return models.Album{Model: gorm.Model{ID: 1}, Title: "Liburan"}, nil
+}
+
+func (u ownedAlbumUsecase) UpdateAlbum(input models.AlbumInput, albumID, userID int) (models.Album, int, error) {
+	return models.Album{Model: gorm.Model{ID: uint(albumID)}, Title: input.Title}, u.ownerID, nil
+}
+
+func TestGetAlbum(t *testing.T) {
+	albumController := NewAlbumController(credentialUsecase{}, ownedAlbumUsecase{ownerID: 1})
+
+	var testCases = []struct {
+		name       string
+		id         string
+		expectCode int
+	}{
+		{
+			name:       "get album",
+			id:         "1",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "album not found",
+			id:         "2",
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "invalid ID",
+			id:         "liburan",
+			expectCode: http.StatusBadRequest,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		req := newTokenRequest(t, 1, http.MethodGet, "/albums/"+testCase.id, "")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testCase.id)
+
+		if assert.NoError(t, albumController.GetAlbum(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+}
+
+func TestUpdateAlbumOfAnotherUser(t *testing.T) {
+	e := echo.New()
+	for ownerID, expectCode := range map[int]int{1: http.StatusOK, 2: http.StatusBadRequest} {
+		albumController := NewAlbumController(credentialUsecase{}, ownedAlbumUsecase{ownerID: ownerID})
+
+		req := newTokenRequest(t, 1, http.MethodPatch, "/albums/1", `{"title":"Liburan 2023"}`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		if assert.NoError(t, albumController.UpdateAlbum(c)) {
+			assert.Equal(t, expectCode, rec.Code)
+		}
+	}
+}
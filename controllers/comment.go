@@ -144,7 +144,7 @@ func (cc *CommentController) GetAllComments(c echo.Context) error {
 			"message": err.Error(),
 		})
 	}
-	comments := cc.commentUsecase.GetComments()
+	comments := cc.commentUsecase.GetComments(userId)
 
 	return c.JSON(
 		http.StatusOK, echo.Map{
@@ -43,13 +43,15 @@ func TestGetAllMyCommenByID(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
+	followRepository := repositories.NewFollowRepository(configs.DB)
 
 	commentRepository := repositories.NewCommentRepository(configs.DB)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := NewCommentController(userService, commentUsecase)
 
 	// setup echo
@@ -111,13 +113,15 @@ func TestGetAllMyComment(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
+	followRepository := repositories.NewFollowRepository(configs.DB)
 
 	commentRepository := repositories.NewCommentRepository(configs.DB)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := NewCommentController(userService, commentUsecase)
 
 	// setup echo
@@ -179,13 +183,15 @@ func TestGetAllComments(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
+	followRepository := repositories.NewFollowRepository(configs.DB)
 
 	commentRepository := repositories.NewCommentRepository(configs.DB)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := NewCommentController(userService, commentUsecase)
 
 	// setup echo
@@ -238,13 +244,15 @@ func TestCreateComment(t *testing.T) {
 		},
 	}
 
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
+	followRepository := repositories.NewFollowRepository(configs.DB)
 
 	commentRepository := repositories.NewCommentRepository(configs.DB)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := NewCommentController(userService, commentUsecase)
 
 	e := InitEchoTestAPI()
@@ -270,13 +278,15 @@ func TestCreateComment(t *testing.T) {
 }
 
 func TestUpdateComment(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
+	followRepository := repositories.NewFollowRepository(configs.DB)
 
 	commentRepository := repositories.NewCommentRepository(configs.DB)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := NewCommentController(userService, commentUsecase)
 
 	// Create a new Echo request context
@@ -297,13 +307,15 @@ func TestUpdateComment(t *testing.T) {
 }
 
 func TestDeleteComment(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
+	followRepository := repositories.NewFollowRepository(configs.DB)
 
 	commentRepository := repositories.NewCommentRepository(configs.DB)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := NewCommentController(userService, commentUsecase)
 
 	e := InitEchoTestAPI()
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/usecases"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FollowController struct {
+	userUsecase   usecases.UserUsecase
+	followUsecase usecases.FollowUsecase
+}
+
+func NewFollowController(userUsecase usecases.UserUsecase, followUsecase usecases.FollowUsecase) FollowController {
+	return FollowController{userUsecase, followUsecase}
+}
+
+func (fc *FollowController) FollowUser(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := fc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	err = fc.followUsecase.FollowUser(c.Param("username"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully followed user",
+		})
+}
+
+func (fc *FollowController) UnfollowUser(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := fc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	err = fc.followUsecase.UnfollowUser(c.Param("username"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully unfollowed user",
+		})
+}
+
+func (fc *FollowController) BlockUser(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := fc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	err = fc.followUsecase.BlockUser(c.Param("username"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully blocked user",
+		})
+}
+
+func (fc *FollowController) UnblockUser(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := fc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	err = fc.followUsecase.UnblockUser(c.Param("username"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully unblocked user",
+		})
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingFollowUsecase remembers who followed whom.
+type recordingFollowUsecase struct {
+	usecases.FollowUsecase
+	username string
+	userID   int
+}
+
+func (u *recordingFollowUsecase) FollowUser(username string, userID int) error {
+	if username == "" {
+		return errors.New("User not found")
+	}
+	u.username, u.userID = username, userID
+	return nil
+}
+
+func TestFollowUser(t *testing.T) {
+	followUsecase := &recordingFollowUsecase{}
+	followController := NewFollowController(credentialUsecase{}, followUsecase)
+
+	e := echo.New()
+	for username, expectCode := range map[string]int{"hanif": http.StatusOK, "": http.StatusBadRequest} {
+		req := newTokenRequest(t, 7, http.MethodPost, "/users/"+username+"/follow", "")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("username")
+		c.SetParamValues(username)
+
+		if assert.NoError(t, followController.FollowUser(c)) {
+			assert.Equal(t, expectCode, rec.Code)
+		}
+	}
+
+	assert.Equal(t, "hanif", followUsecase.username)
+	assert.Equal(t, 7, followUsecase.userID)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"mini-project-alterra/helpers"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// publicMediaPrefixes lists the key prefixes of media that may be served
+// without a signature. Photos and stories can become private at any time, so
+// none of the uploaded media is public by construction and the list is
+// empty; only add prefixes of media that can never be private.
+var publicMediaPrefixes = []string{}
+
+type MediaController struct {
+	store    *helpers.LocalMediaStore
+	maxBytes int64
+}
+
+func NewMediaController(store *helpers.LocalMediaStore, maxBytes int64) MediaController {
+	return MediaController{store, maxBytes}
+}
+
+// publicMedia reports whether key is below one of publicMediaPrefixes.
+func publicMedia(key string) bool {
+	for _, prefix := range publicMediaPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeMedia serves files of the local storage backend. Unless the key is
+// public, requests must carry a valid, unexpired signature, as produced by
+// SignedURL or SignMediaURL for the URLs in responses.
+func (mc *MediaController) ServeMedia(c echo.Context) error {
+	key := c.Param("*")
+
+	if !publicMedia(key) && !mc.store.VerifySignature(http.MethodGet, key, c.QueryParam("expires"), c.QueryParam("signature")) {
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"message": "Invalid or expired signature",
+		})
+	}
+
+	name, err := mc.store.Path(key)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Media not found",
+		})
+	}
+
+	info, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.IsDir()) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Media not found",
+		})
+	}
+
+	return c.File(name)
+}
+
+// UploadMedia stores the body of a PUT request to a URL signed by
+// PresignUpload. Existing files are never replaced, so a leaked URL cannot
+// overwrite a finalized photo.
+func (mc *MediaController) UploadMedia(c echo.Context) error {
+	key := c.Param("*")
+
+	if !mc.store.VerifySignature(http.MethodPut, key, c.QueryParam("expires"), c.QueryParam("signature")) {
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"message": "Invalid or expired signature",
+		})
+	}
+
+	ctx := c.Request().Context()
+	_, err := mc.store.Stat(ctx, key)
+	if err == nil {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"message": "Media already exists",
+		})
+	}
+	if !errors.Is(err, helpers.ErrMediaNotFound) {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Error uploading photo",
+		})
+	}
+
+	if c.Request().ContentLength > mc.maxBytes {
+		return c.JSON(http.StatusRequestEntityTooLarge, echo.Map{
+			"message": helpers.ErrImageTooLarge.Error(),
+		})
+	}
+
+	object, err := mc.store.Put(ctx, key, io.LimitReader(c.Request().Body, mc.maxBytes+1), -1, c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Error uploading photo",
+		})
+	}
+	if object.Size > mc.maxBytes {
+		mc.store.Delete(ctx, key)
+		return c.JSON(http.StatusRequestEntityTooLarge, echo.Map{
+			"message": helpers.ErrImageTooLarge.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"message": "Successfully uploaded media",
+	})
+}
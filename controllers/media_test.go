@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"mini-project-alterra/helpers"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadMedia(t *testing.T) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	mediaController := NewMediaController(store, 16)
+
+	form, err := store.PresignUpload(context.Background(), "photos/a.png", "image/png", 16, time.Minute)
+	assert.NoError(t, err)
+
+	var testCases = []struct {
+		name       string
+		url        string
+		body       string
+		expectCode int
+	}{
+		{
+			name:       "invalid signature",
+			url:        "/media/photos/a.png?expires=1&signature=abc",
+			body:       "png data",
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "too large",
+			url:        form.URL,
+			body:       strings.Repeat("x", 17),
+			expectCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "upload",
+			url:        form.URL,
+			body:       "png data",
+			expectCode: http.StatusCreated,
+		},
+		{
+			name:       "replace",
+			url:        form.URL,
+			body:       "other data",
+			expectCode: http.StatusConflict,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		req := httptest.NewRequest(http.MethodPut, testCase.url, strings.NewReader(testCase.body))
+		req.Header.Set(echo.HeaderContentType, "image/png")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("*")
+		c.SetParamValues("photos/a.png")
+
+		if assert.NoError(t, mediaController.UploadMedia(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+
+	object, err := store.Stat(context.Background(), "photos/a.png")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("png data")), object.Size)
+}
+
+func TestServeMedia(t *testing.T) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	_, err = store.Put(context.Background(), "photos/a.png", strings.NewReader("png data"), 8, "image/png")
+	assert.NoError(t, err)
+	mediaController := NewMediaController(store, 16)
+
+	var testCases = []struct {
+		name       string
+		url        string
+		expectCode int
+	}{
+		{
+			name:       "no signature",
+			url:        "/media/photos/a.png",
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "invalid signature",
+			url:        "/media/photos/a.png?expires=1&signature=abc",
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "signed",
+			url:        store.SignMediaURL("/media/photos/a.png", time.Hour),
+			expectCode: http.StatusOK,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		req := httptest.NewRequest(http.MethodGet, testCase.url, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("*")
+		c.SetParamValues("photos/a.png")
+
+		if assert.NoError(t, mediaController.ServeMedia(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+}
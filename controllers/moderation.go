@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"errors"
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ModerationController struct {
+	userUsecase       usecases.UserUsecase
+	moderationUsecase usecases.ModerationUsecase
+}
+
+func NewModerationController(userUsecase usecases.UserUsecase, moderationUsecase usecases.ModerationUsecase) ModerationController {
+	return ModerationController{userUsecase, moderationUsecase}
+}
+
+func moderationErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, usecases.ErrNotModerator):
+		status = http.StatusForbidden
+	case errors.Is(err, usecases.ErrCaseNotFound), errors.Is(err, usecases.ErrContentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, usecases.ErrCaseClaimed), errors.Is(err, usecases.ErrReportDuplicate):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, echo.Map{
+		"message": err.Error(),
+	})
+}
+
+func (mc *ModerationController) CreateReport(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := mc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var reportInput models.ReportInput
+
+	err = c.Bind(&reportInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	report, err := mc.moderationUsecase.Report(userID, reportInput)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully reported content",
+			"data":    report,
+		})
+}
+
+func (mc *ModerationController) GetCases(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := mc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	page, limit := parsePagination(c)
+
+	cases, err := mc.moderationUsecase.GetCases(userID, c.QueryParam("status"), page, limit)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved moderation cases",
+			"data":    cases,
+		})
+}
+
+func (mc *ModerationController) GetCase(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := mc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	caseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	moderationCase, err := mc.moderationUsecase.GetCase(caseID, userID)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved moderation case",
+			"data":    moderationCase,
+		})
+}
+
+func (mc *ModerationController) ClaimCase(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := mc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	caseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	moderationCase, err := mc.moderationUsecase.ClaimCase(caseID, userID)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully claimed moderation case",
+			"data":    moderationCase,
+		})
+}
+
+func (mc *ModerationController) AddNote(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := mc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	caseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var noteInput models.ModerationNoteInput
+
+	err = c.Bind(&noteInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	note, err := mc.moderationUsecase.AddNote(caseID, userID, noteInput)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully added note",
+			"data":    note,
+		})
+}
+
+func (mc *ModerationController) ResolveCase(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := mc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	caseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var resolveInput models.ModerationResolveInput
+
+	err = c.Bind(&resolveInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	moderationCase, err := mc.moderationUsecase.ResolveCase(caseID, userID, resolveInput)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully resolved moderation case",
+			"data":    moderationCase,
+		})
+}
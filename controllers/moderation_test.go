@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingModerationUsecase fails every report with err, if set.
+type failingModerationUsecase struct {
+	usecases.ModerationUsecase
+	err error
+}
+
+func (u failingModerationUsecase) Report(reporterID int, input models.ReportInput) (models.ReportResponse, error) {
+	return models.ReportResponse{ID: 1, TargetType: input.TargetType, TargetID: input.TargetID, Reason: input.Reason}, u.err
+}
+
+func TestCreateReport(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		err        error
+		expectCode int
+	}{
+		{
+			name:       "create report",
+			expectCode: http.StatusCreated,
+		},
+		{
+			name:       "duplicate report",
+			err:        usecases.ErrReportDuplicate,
+			expectCode: http.StatusConflict,
+		},
+		{
+			name:       "content not found",
+			err:        usecases.ErrContentNotFound,
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "invalid reason",
+			err:        usecases.ErrReportReason,
+			expectCode: http.StatusBadRequest,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		moderationController := NewModerationController(credentialUsecase{}, failingModerationUsecase{err: testCase.err})
+
+		body := `{"target_type":"photo","target_id":1,"reason":"spam"}`
+		req := newTokenRequest(t, 1, http.MethodPost, "/reports", body)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, moderationController.CreateReport(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+}
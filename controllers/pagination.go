@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination reads the page and limit query params, falling back to the
+// first page and the default limit when they are missing or invalid.
+func parsePagination(c echo.Context) (int, int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return page, limit
+}
@@ -1,23 +1,66 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"mini-project-alterra/helpers"
 	"mini-project-alterra/middlewares"
 	"mini-project-alterra/models"
 	"mini-project-alterra/usecases"
 	"net/http"
-	"regexp"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
 
+// defaultNearbyRadiusKm is the search radius of /photos/nearby when none is
+// given.
+const defaultNearbyRadiusKm = 5.0
+
 type PhotoController struct {
-	userUsecase  usecases.UserUsecase
-	photoUsecase usecases.PhotoUsecase
+	userUsecase   usecases.UserUsecase
+	photoUsecase  usecases.PhotoUsecase
+	mediaUpload   usecases.MediaUpload
+	uploadUsecase usecases.UploadUsecase
+	quotaUsecase  usecases.QuotaUsecase
+	viewUsecase   usecases.PhotoViewUsecase
+	repostUsecase usecases.RepostUsecase
+}
+
+func NewPhotoController(userUsecase usecases.UserUsecase, photoUsecase usecases.PhotoUsecase, mediaUpload usecases.MediaUpload, uploadUsecase usecases.UploadUsecase, quotaUsecase usecases.QuotaUsecase, viewUsecase usecases.PhotoViewUsecase, repostUsecase usecases.RepostUsecase) PhotoController {
+	return PhotoController{userUsecase, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase}
 }
 
-func NewPhotoController(userUsecase usecases.UserUsecase, photoUsecase usecases.PhotoUsecase) PhotoController {
-	return PhotoController{userUsecase, photoUsecase}
+// mediaErrorResponse reports a failed upload with the status matching the
+// reason: 413 for oversized files, 415 for formats that are not images, 403
+// when the storage quota is used up and 400 for broken or oversized
+// images, unreachable URLs and resumable or direct uploads that cannot be
+// attached.
+func mediaErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, helpers.ErrImageTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, helpers.ErrImageUnsupported):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, usecases.ErrQuotaExceeded):
+		status = http.StatusForbidden
+	case errors.Is(err, helpers.ErrImageInvalid), errors.Is(err, helpers.ErrImageDimensions), errors.Is(err, usecases.ErrMediaFetch),
+		errors.Is(err, helpers.ErrRemoteFetch), errors.Is(err, helpers.ErrRemoteBlocked),
+		errors.Is(err, usecases.ErrUploadNotFound), errors.Is(err, usecases.ErrUploadExpired), errors.Is(err, usecases.ErrUploadIncomplete),
+		errors.Is(err, usecases.ErrUploadLocked), errors.Is(err, usecases.ErrUploadMissing), errors.Is(err, usecases.ErrUploadMismatch):
+		status = http.StatusBadRequest
+	}
+
+	if status == http.StatusInternalServerError {
+		return c.JSON(status, echo.Map{
+			"message": "Error uploading photo",
+		})
+	}
+
+	return c.JSON(status, echo.Map{
+		"message": err.Error(),
+	})
 }
 
 func (pc *PhotoController) CreatePhoto(c echo.Context) error {
@@ -49,82 +92,128 @@ func (pc *PhotoController) CreatePhoto(c echo.Context) error {
 	}
 
 	photoInput.UserID = userID
+	if photoInput.Visibility == "" {
+		photoInput.Visibility = checkUser.DefaultVisibility
+	}
 
-	if photoInput.PhotoURL == "" {
-		formHeader, err := c.FormFile("file")
-		if err != nil {
-			if err != nil {
-				return c.JSON(
-					http.StatusInternalServerError,
-					echo.Map{
-						"message": "Error uploading photo",
-					})
-			}
-		}
+	var objects []models.MediaObject
 
-		//get file from header
-		formFile, err := formHeader.Open()
-		if err != nil {
+	if len(photoInput.UploadIDs) > 0 {
+		if len(photoInput.UploadIDs) > models.MaxPhotoItems {
 			return c.JSON(
-				http.StatusInternalServerError,
+				http.StatusBadRequest,
 				echo.Map{
-					"message": err.Error(),
+					"message": fmt.Sprintf("A post can hold at most %d images", models.MaxPhotoItems),
 				})
 		}
 
-		var re = regexp.MustCompile(`.png|.jpeg|.jpg`)
-
-		if !re.MatchString(formHeader.Filename) {
+		objects, err = pc.uploadUsecase.StoreUploads(photoInput.UploadIDs, userID, checkUser.KeepPhotoLocation)
+		if err != nil {
+			return mediaErrorResponse(c, err)
+		}
+	} else if photoInput.PhotoURL == "" {
+		form, err := c.MultipartForm()
+		if err != nil || len(form.File["file"]) == 0 {
 			return c.JSON(
-				http.StatusBadRequest, echo.Map{
-					"message": "The provided file format is not allowed. Please upload a JPEG or PNG image",
+				http.StatusBadRequest,
+				echo.Map{
+					"message": "Please upload a photo file or provide a photo URL",
 				})
 		}
 
-		uploadUrl, err := usecases.NewMediaUpload().FileUpload(models.File{File: formFile})
-
-		if err != nil {
+		formHeaders := form.File["file"]
+		if len(formHeaders) > models.MaxPhotoItems {
 			return c.JSON(
-				http.StatusInternalServerError,
+				http.StatusBadRequest,
 				echo.Map{
-					"message": err.Error(),
+					"message": fmt.Sprintf("A post can hold at most %d images", models.MaxPhotoItems),
 				})
 		}
-		photoInput.PhotoURL = uploadUrl
-	}
 
-	var url models.Url
+		var total int64
+		for _, formHeader := range formHeaders {
+			total += formHeader.Size
+		}
+		err = pc.quotaUsecase.CheckQuota(userID, total, 1)
+		if err != nil {
+			return mediaErrorResponse(c, err)
+		}
 
-	url.Url = photoInput.PhotoURL
+		//get files from header
+		files := make([]models.File, 0, len(formHeaders))
+		for _, formHeader := range formHeaders {
+			formFile, err := formHeader.Open()
+			if err != nil {
+				return c.JSON(
+					http.StatusInternalServerError,
+					echo.Map{
+						"message": err.Error(),
+					})
+			}
+			defer formFile.Close()
 
-	var re = regexp.MustCompile(`.png|.jpeg|.jpg`)
+			files = append(files, models.File{File: formFile, KeepLocation: checkUser.KeepPhotoLocation})
+		}
 
-	if !re.MatchString(photoInput.PhotoURL) {
-		return c.JSON(
-			http.StatusBadRequest, echo.Map{
-				"message": "The provided file format is not allowed. Please upload a JPEG or PNG image",
-			})
+		objects, err = pc.mediaUpload.FileUploads(files)
+		if err != nil {
+			return mediaErrorResponse(c, err)
+		}
+	} else {
+		// The size is only known once the photo is fetched, so check that at
+		// least a byte is left and limit the download to the rest.
+		err = pc.quotaUsecase.CheckQuota(userID, 1, 1)
+		if err != nil {
+			return mediaErrorResponse(c, err)
+		}
+		remaining, err := pc.quotaUsecase.RemainingBytes(userID)
+		if err != nil {
+			return mediaErrorResponse(c, err)
+		}
+
+		object, err := pc.mediaUpload.RemoteUpload(models.Url{Url: photoInput.PhotoURL, KeepLocation: checkUser.KeepPhotoLocation, MaxBytes: remaining})
+		if err != nil {
+			return mediaErrorResponse(c, err)
+		}
+		objects = []models.MediaObject{object}
 	}
 
-	uploadUrl, err := usecases.NewMediaUpload().RemoteUpload(url)
+	return pc.createPhoto(c, photoInput, objects)
+}
 
-	if uploadUrl == "" || err != nil {
-		return c.JSON(
-			http.StatusInternalServerError,
-			echo.Map{
-				"message": "Error uploading photo",
-			})
+// createPhoto saves a post made of the stored objects, with the alt texts
+// given in the request.
+func (pc *PhotoController) createPhoto(c echo.Context, photoInput models.PhotoInput, objects []models.MediaObject) error {
+	altTexts := []string{c.FormValue("alt_text")}
+	if form, err := c.MultipartForm(); err == nil {
+		altTexts = form.Value["alt_text"]
+	}
+
+	// The variants are only known once stored, so check the quota again
+	// with them. Media left behind is removed by the media sweeper.
+	err := pc.quotaUsecase.CheckStored(photoInput.UserID, objects, 1)
+	if err != nil {
+		var keys []string
+		for _, object := range objects {
+			keys = append(keys, object.StorageKeys()...)
+		}
+		pc.mediaUpload.DeleteMedia(keys)
+		return mediaErrorResponse(c, err)
 	}
 
-	photoInput.PhotoURL = uploadUrl
+	photoInput.Items = models.ParseMediaToPhotoItems(objects, altTexts)
+	photoInput.PhotoURL = objects[0].URL
+	photoInput.StorageKey = objects[0].Key
+	photoInput.Metadata = objects[0].Metadata
 
 	photo, err := pc.photoUsecase.CreatePhoto(photoInput)
 	if err != nil {
 		return c.JSON(
 			http.StatusBadRequest, echo.Map{
-				"message": "Failed to upload photo",
+				"message": err.Error(),
 			})
 	}
+	pc.uploadUsecase.FinishUploads(photoInput.UploadIDs)
 
 	return c.JSON(
 		http.StatusCreated, echo.Map{
@@ -133,6 +222,48 @@ func (pc *PhotoController) CreatePhoto(c echo.Context) error {
 		})
 }
 
+func (pc *PhotoController) FinalizeUpload(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var photoInput models.PhotoInput
+
+	err = c.Bind(&photoInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Failed to upload photo",
+			})
+	}
+
+	photoInput.UserID = userID
+	photoInput.UploadIDs = nil
+	if photoInput.Visibility == "" {
+		photoInput.Visibility = checkUser.DefaultVisibility
+	}
+
+	object, err := pc.uploadUsecase.FinalizeUpload(c.Param("id"), userID, checkUser.KeepPhotoLocation)
+	if err != nil {
+		return mediaErrorResponse(c, err)
+	}
+
+	return pc.createPhoto(c, photoInput, []models.MediaObject{object})
+}
+
 func (pc *PhotoController) DeletePhoto(c echo.Context) error {
 	tokenString := middlewares.GetTokenFromHeader(c.Request())
 
@@ -215,6 +346,49 @@ func (pc *PhotoController) GetMyPhotoByID(c echo.Context) error {
 		})
 }
 
+func (pc *PhotoController) GetPhoto(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	photo, err := pc.photoUsecase.GetPhoto(photoID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	pc.viewUsecase.RecordView(photo, userID)
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved photo",
+			"data":    models.ParsePhotoToResponse(photo),
+		})
+}
+
 func (pc *PhotoController) GetMyPhoto(c echo.Context) error {
 	tokenString := middlewares.GetTokenFromHeader(c.Request())
 
@@ -232,7 +406,19 @@ func (pc *PhotoController) GetMyPhoto(c echo.Context) error {
 			"message": err.Error(),
 		})
 	}
-	photos := pc.photoUsecase.GetMyPhoto(userId)
+
+	archived := false
+	if c.QueryParam("archived") != "" {
+		archived, err = strconv.ParseBool(c.QueryParam("archived"))
+		if err != nil {
+			return c.JSON(
+				http.StatusBadRequest, echo.Map{
+					"message": "archived must be true or false",
+				})
+		}
+	}
+
+	photos := pc.photoUsecase.GetMyPhoto(userId, archived)
 
 	return c.JSON(
 		http.StatusOK, echo.Map{
@@ -258,11 +444,380 @@ func (pc *PhotoController) GetPhotos(c echo.Context) error {
 			"message": err.Error(),
 		})
 	}
-	photos := pc.photoUsecase.GetPhotos()
+	page, limit := parsePagination(c)
+	photos := pc.photoUsecase.GetPhotos(userId, page, limit)
+
+	reposts, err := pc.repostUsecase.GetFeedReposts(userId, page, limit)
+	if err != nil {
+		return c.JSON(
+			http.StatusInternalServerError, echo.Map{
+				"message": err.Error(),
+			})
+	}
 
 	return c.JSON(
 		http.StatusOK, echo.Map{
 			"message": "Successfully retrieved all photos",
+			"data":    models.ParseFeedToResponse(photos, reposts, page, limit),
+		})
+}
+
+func (pc *PhotoController) GetNearbyPhotos(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userId, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userId)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameters lat and lng must be numbers",
+			})
+	}
+
+	radius := defaultNearbyRadiusKm
+	if c.QueryParam("radius") != "" {
+		radius, err = strconv.ParseFloat(c.QueryParam("radius"), 64)
+		if err != nil {
+			return c.JSON(
+				http.StatusBadRequest, echo.Map{
+					"message": "Parameter radius must be a number",
+				})
+		}
+	}
+
+	page, limit := parsePagination(c)
+
+	photos, err := pc.photoUsecase.GetNearbyPhotos(lat, lng, radius, userId, page, limit)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved nearby photos",
+			"data":    models.ParseNearbyPhotosToResponse(photos),
+		})
+}
+
+func (pc *PhotoController) GetRevisions(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	revisions, err := pc.photoUsecase.GetRevisions(photoID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved photo revisions",
+			"data":    models.ParsePhotoRevisionsToResponse(revisions),
+		})
+}
+
+func (pc *PhotoController) GetInsights(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	days := 0
+	if c.QueryParam("days") != "" {
+		days, err = strconv.Atoi(c.QueryParam("days"))
+		if err != nil || days < 1 {
+			return c.JSON(
+				http.StatusBadRequest, echo.Map{
+					"message": "days must be a positive number",
+				})
+		}
+	}
+
+	insights, err := pc.viewUsecase.GetInsights(photoID, userID, days)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved photo insights",
+			"data":    insights,
+		})
+}
+
+func (pc *PhotoController) RevertPhoto(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	revisionID, err := strconv.Atoi(c.Param("revisionId"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	photo, err := pc.photoUsecase.RevertPhoto(photoID, revisionID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully reverted photo",
+			"data":    models.ParsePhotoToResponse(photo),
+		})
+}
+
+func (pc *PhotoController) ArchivePhoto(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	photo, err := pc.photoUsecase.ArchivePhoto(photoID, userID, true)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully archived photo",
+			"data":    models.ParsePhotoToResponse(photo),
+		})
+}
+
+func (pc *PhotoController) UnarchivePhoto(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	photo, err := pc.photoUsecase.ArchivePhoto(photoID, userID, false)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully unarchived photo",
+			"data":    models.ParsePhotoToResponse(photo),
+		})
+}
+
+func (pc *PhotoController) SetContentWarning(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var input models.ContentWarningInput
+
+	err = c.Bind(&input)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	photo, err := pc.photoUsecase.SetContentWarning(photoID, checkUser, input)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrNotModerator) {
+			status = http.StatusForbidden
+		}
+		return c.JSON(
+			status, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully updated content warning",
+			"data":    models.ParsePhotoToResponse(photo),
+		})
+}
+
+func (pc *PhotoController) GetDrafts(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userId, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := pc.userUsecase.GetCredential(userId)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+	photos := pc.photoUsecase.GetDrafts(userId)
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved all my drafts",
 			"data":    models.ParsePhotoToResponseArray(photos),
 		})
 }
@@ -314,9 +869,14 @@ func (pc *PhotoController) UpdatePhoto(c echo.Context) error {
 
 	photo, validID, err := pc.photoUsecase.UpdatePhoto(photoInput, photoID, userID)
 	if err != nil {
+		message := "Photo ID cannot be found"
+		if validID == userID {
+			// The photo exists, so the items of the request were rejected.
+			message = err.Error()
+		}
 		return c.JSON(
 			http.StatusBadRequest, echo.Map{
-				"message": "Photo ID cannot be found",
+				"message": message,
 			})
 	}
 
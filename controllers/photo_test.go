@@ -3,6 +3,7 @@ package controllers
 import (
 	"encoding/json"
 	"mini-project-alterra/configs"
+	"mini-project-alterra/helpers"
 	"mini-project-alterra/models"
 	"mini-project-alterra/repositories"
 	"mini-project-alterra/usecases"
@@ -10,6 +11,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -43,12 +45,20 @@ func TestGetMyPhotoByID(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := NewPhotoController(userService, photoUsecase)
+	tagRepository := repositories.NewTagRepository(configs.DB)
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, nil)
+	viewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(configs.DB), photoRepository, time.Minute)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(configs.DB), photoRepository, repositories.NewBlockRepository(configs.DB))
+	uploadUsecase := usecases.NewUploadUsecase(repositories.NewUploadRepository(configs.DB), mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	photoController := NewPhotoController(userService, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase)
 
 	// setup echo
 	e := InitEchoTestAPI()
@@ -109,12 +119,20 @@ func TestGetMyPhoto(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := NewPhotoController(userService, photoUsecase)
+	tagRepository := repositories.NewTagRepository(configs.DB)
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, nil)
+	viewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(configs.DB), photoRepository, time.Minute)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(configs.DB), photoRepository, repositories.NewBlockRepository(configs.DB))
+	uploadUsecase := usecases.NewUploadUsecase(repositories.NewUploadRepository(configs.DB), mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	photoController := NewPhotoController(userService, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase)
 
 	// setup echo
 	e := InitEchoTestAPI()
@@ -175,12 +193,20 @@ func TestGetPhotos(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := NewPhotoController(userService, photoUsecase)
+	tagRepository := repositories.NewTagRepository(configs.DB)
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, nil)
+	viewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(configs.DB), photoRepository, time.Minute)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(configs.DB), photoRepository, repositories.NewBlockRepository(configs.DB))
+	uploadUsecase := usecases.NewUploadUsecase(repositories.NewUploadRepository(configs.DB), mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	photoController := NewPhotoController(userService, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase)
 
 	// setup echo
 	e := InitEchoTestAPI()
@@ -233,12 +259,20 @@ func TestCreatePhoto(t *testing.T) {
 		},
 	}
 
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := NewPhotoController(userService, photoUsecase)
+	tagRepository := repositories.NewTagRepository(configs.DB)
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, nil)
+	viewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(configs.DB), photoRepository, time.Minute)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(configs.DB), photoRepository, repositories.NewBlockRepository(configs.DB))
+	uploadUsecase := usecases.NewUploadUsecase(repositories.NewUploadRepository(configs.DB), mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	photoController := NewPhotoController(userService, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase)
 
 	e := InitEchoTestAPI()
 	for _, testCase := range testCases {
@@ -263,12 +297,20 @@ func TestCreatePhoto(t *testing.T) {
 }
 
 func TestUpdatePhoto(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := NewPhotoController(userService, photoUsecase)
+	tagRepository := repositories.NewTagRepository(configs.DB)
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, nil)
+	viewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(configs.DB), photoRepository, time.Minute)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(configs.DB), photoRepository, repositories.NewBlockRepository(configs.DB))
+	uploadUsecase := usecases.NewUploadUsecase(repositories.NewUploadRepository(configs.DB), mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	photoController := NewPhotoController(userService, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase)
 
 	// Create a new Echo request context
 	e := echo.New()
@@ -288,12 +330,20 @@ func TestUpdatePhoto(t *testing.T) {
 }
 
 func TestDeletePhoto(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	photoRepository := repositories.NewPhotoRepository(configs.DB)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := NewPhotoController(userService, photoUsecase)
+	tagRepository := repositories.NewTagRepository(configs.DB)
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, nil)
+	viewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(configs.DB), photoRepository, time.Minute)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(configs.DB), photoRepository, repositories.NewBlockRepository(configs.DB))
+	uploadUsecase := usecases.NewUploadUsecase(repositories.NewUploadRepository(configs.DB), mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	photoController := NewPhotoController(userService, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, viewUsecase, repostUsecase)
 
 	e := InitEchoTestAPI()
 
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/usecases"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type QuotaController struct {
+	userUsecase  usecases.UserUsecase
+	quotaUsecase usecases.QuotaUsecase
+}
+
+func NewQuotaController(userUsecase usecases.UserUsecase, quotaUsecase usecases.QuotaUsecase) QuotaController {
+	return QuotaController{userUsecase, quotaUsecase}
+}
+
+func (qc *QuotaController) GetUsage(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := qc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	usage, err := qc.quotaUsecase.GetUsage(userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusInternalServerError, echo.Map{
+				"message": "Error retrieving storage usage",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved storage usage",
+			"data":    usage,
+		})
+}
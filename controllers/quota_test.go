@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// usageQuotaUsecase reports the usage of user 1 and fails for anyone else.
+type usageQuotaUsecase struct {
+	usecases.QuotaUsecase
+}
+
+func (usageQuotaUsecase) GetUsage(userID int) (models.StorageUsageResponse, error) {
+	if userID != 1 {
+		return models.StorageUsageResponse{}, errors.New("connection refused")
+	}
+	return models.StorageUsageResponse{Tier: "free", BytesUsed: 2048, BytesLimit: 1 << 30, PhotosUsed: 3, PhotosLimit: 500}, nil
+}
+
+func TestGetUsage(t *testing.T) {
+	quotaController := NewQuotaController(credentialUsecase{}, usageQuotaUsecase{})
+
+	e := echo.New()
+	req := newTokenRequest(t, 1, http.MethodGet, "/users/usage", "")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, quotaController.GetUsage(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Data models.StorageUsageResponse `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, int64(2048), body.Data.BytesUsed)
+		assert.Equal(t, int64(3), body.Data.PhotosUsed)
+	}
+
+	// Errors retrieving the usage are not shown to the user.
+	req = newTokenRequest(t, 2, http.MethodGet, "/users/usage", "")
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+
+	if assert.NoError(t, quotaController.GetUsage(c)) {
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "connection refused")
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RepostController struct {
+	userUsecase   usecases.UserUsecase
+	repostUsecase usecases.RepostUsecase
+}
+
+func NewRepostController(userUsecase usecases.UserUsecase, repostUsecase usecases.RepostUsecase) RepostController {
+	return RepostController{userUsecase, repostUsecase}
+}
+
+func (rc *RepostController) CreateRepost(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := rc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var repostInput models.RepostInput
+
+	err = c.Bind(&repostInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	repost, err := rc.repostUsecase.CreateRepost(photoID, userID, repostInput)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, usecases.ErrRepostNotAllowed):
+			status = http.StatusForbidden
+		case errors.Is(err, usecases.ErrRepostDuplicate):
+			status = http.StatusConflict
+		case errors.Is(err, usecases.ErrRepostPhotoNotFound):
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully reposted photo",
+			"data":    models.ParseRepostToResponse(repost),
+		})
+}
+
+func (rc *RepostController) GetPhotoReposts(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := rc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	photoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	reposts, err := rc.repostUsecase.GetPhotoReposts(photoID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved reposts",
+			"data":    models.ParseRepostsToResponse(reposts),
+		})
+}
+
+func (rc *RepostController) DeleteRepost(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := rc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	repostID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	err = rc.repostUsecase.DeleteRepost(repostID, userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully deleted repost",
+		})
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingRepostUsecase fails every repost with err, if set.
+type failingRepostUsecase struct {
+	usecases.RepostUsecase
+	err error
+}
+
+func (u failingRepostUsecase) CreateRepost(photoID, userID int, input models.RepostInput) (models.Repost, error) {
+	return models.Repost{ID: 1, UserID: userID, PhotoID: uint(photoID), Caption: input.Caption}, u.err
+}
+
+func TestCreateRepost(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		id         string
+		err        error
+		expectCode int
+	}{
+		{
+			name:       "create repost",
+			id:         "1",
+			expectCode: http.StatusCreated,
+		},
+		{
+			name:       "reposts not allowed",
+			id:         "1",
+			err:        usecases.ErrRepostNotAllowed,
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "already reposted",
+			id:         "1",
+			err:        usecases.ErrRepostDuplicate,
+			expectCode: http.StatusConflict,
+		},
+		{
+			name:       "photo not found",
+			id:         "1",
+			err:        usecases.ErrRepostPhotoNotFound,
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "invalid ID",
+			id:         "bali",
+			expectCode: http.StatusBadRequest,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		repostController := NewRepostController(credentialUsecase{}, failingRepostUsecase{err: testCase.err})
+
+		req := newTokenRequest(t, 1, http.MethodPost, "/photos/"+testCase.id+"/reposts", `{"caption":"Pengen ke sini juga"}`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testCase.id)
+
+		if assert.NoError(t, repostController.CreateRepost(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+}
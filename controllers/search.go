@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SearchController struct {
+	userUsecase   usecases.UserUsecase
+	searchUsecase usecases.SearchUsecase
+}
+
+func NewSearchController(userUsecase usecases.UserUsecase, searchUsecase usecases.SearchUsecase) SearchController {
+	return SearchController{userUsecase, searchUsecase}
+}
+
+func (sc *SearchController) Search(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var types []string
+	for _, docType := range strings.Split(c.QueryParam("type"), ",") {
+		if docType = strings.TrimSpace(docType); docType != "" {
+			types = append(types, docType)
+		}
+	}
+
+	page, limit := parsePagination(c)
+
+	results, err := sc.searchUsecase.Search(c.QueryParam("q"), types, userID, page, limit)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully searched",
+			"data":    models.ParseSearchResultToResponseArray(results),
+			"page":    page,
+			"limit":   limit,
+		})
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSearchUsecase remembers the last search and finds nothing.
+type recordingSearchUsecase struct {
+	usecases.SearchUsecase
+	query       string
+	types       []string
+	viewerID    int
+	page, limit int
+}
+
+func (u *recordingSearchUsecase) Search(query string, types []string, viewerID, page, limit int) ([]models.SearchResult, error) {
+	u.query, u.types, u.viewerID, u.page, u.limit = query, types, viewerID, page, limit
+	return nil, nil
+}
+
+func TestSearch(t *testing.T) {
+	searchUsecase := &recordingSearchUsecase{}
+	searchController := NewSearchController(credentialUsecase{}, searchUsecase)
+
+	e := echo.New()
+	req := newTokenRequest(t, 3, http.MethodGet, "/search?q=bali&type=photo,%20user,&page=2&limit=10", "")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, searchController.Search(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "bali", searchUsecase.query)
+		assert.Equal(t, []string{"photo", "user"}, searchUsecase.types)
+		assert.Equal(t, 3, searchUsecase.viewerID)
+		assert.Equal(t, 2, searchUsecase.page)
+		assert.Equal(t, 10, searchUsecase.limit)
+	}
+}
@@ -43,8 +43,9 @@ func TestGetMySocialMediaByID(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	socialMediaRepository := repositories.NewSocialMediaRepository(configs.DB)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
@@ -109,8 +110,9 @@ func TestGetMySocialMedia(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	socialMediaRepository := repositories.NewSocialMediaRepository(configs.DB)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
@@ -175,8 +177,9 @@ func TestGetSocialMedias(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	socialMediaRepository := repositories.NewSocialMediaRepository(configs.DB)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
@@ -232,8 +235,9 @@ func TestCreateSocialMedia(t *testing.T) {
 		},
 	}
 
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	socialMediaRepository := repositories.NewSocialMediaRepository(configs.DB)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
@@ -263,8 +267,9 @@ func TestCreateSocialMedia(t *testing.T) {
 }
 
 func TestUpdateSocialMedia(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	socialMediaRepository := repositories.NewSocialMediaRepository(configs.DB)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
@@ -288,8 +293,9 @@ func TestUpdateSocialMedia(t *testing.T) {
 }
 
 func TestDeleteSocialMedia(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	socialMediaRepository := repositories.NewSocialMediaRepository(configs.DB)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
@@ -0,0 +1,472 @@
+package controllers
+
+import (
+	"errors"
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type StoryController struct {
+	userUsecase  usecases.UserUsecase
+	storyUsecase usecases.StoryUsecase
+	mediaUpload  usecases.MediaUpload
+	quotaUsecase usecases.QuotaUsecase
+}
+
+func NewStoryController(userUsecase usecases.UserUsecase, storyUsecase usecases.StoryUsecase, mediaUpload usecases.MediaUpload, quotaUsecase usecases.QuotaUsecase) StoryController {
+	return StoryController{userUsecase, storyUsecase, mediaUpload, quotaUsecase}
+}
+
+func storyErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, usecases.ErrStoriesFollowOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, usecases.ErrStoryNotFound), errors.Is(err, usecases.ErrHighlightNotFound):
+		status = http.StatusNotFound
+	}
+
+	return c.JSON(status, echo.Map{
+		"message": err.Error(),
+	})
+}
+
+func (sc *StoryController) CreateStory(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	formHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			echo.Map{
+				"message": "Please upload an image file",
+			})
+	}
+
+	// Stories count towards the storage quota, but not towards the photos.
+	err = sc.quotaUsecase.CheckQuota(userID, formHeader.Size, 0)
+	if err != nil {
+		return mediaErrorResponse(c, err)
+	}
+
+	formFile, err := formHeader.Open()
+	if err != nil {
+		return c.JSON(
+			http.StatusInternalServerError,
+			echo.Map{
+				"message": err.Error(),
+			})
+	}
+	defer formFile.Close()
+
+	// Stories keep no metadata, so the location is always stripped.
+	object, err := sc.mediaUpload.FileUpload(models.File{File: formFile})
+	if err != nil {
+		return mediaErrorResponse(c, err)
+	}
+	err = sc.quotaUsecase.CheckStored(userID, []models.MediaObject{object}, 0)
+	if err != nil {
+		sc.mediaUpload.DeleteMedia(object.StorageKeys())
+		return mediaErrorResponse(c, err)
+	}
+
+	story, err := sc.storyUsecase.CreateStory(userID, object)
+	if err != nil {
+		return c.JSON(
+			http.StatusInternalServerError, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully posted story",
+			"data":    models.ParseStoriesToResponse([]models.Story{story}, nil)[0],
+		})
+}
+
+func (sc *StoryController) GetTray(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tray, err := sc.storyUsecase.GetTray(userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusInternalServerError, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved story tray",
+			"data":    tray,
+		})
+}
+
+func (sc *StoryController) GetUserStories(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	stories, err := sc.storyUsecase.GetUserStories(c.Param("username"), userID)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved stories",
+			"data":    stories,
+		})
+}
+
+func (sc *StoryController) MarkSeen(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	storyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	err = sc.storyUsecase.MarkSeen(storyID, userID)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully marked story as seen",
+		})
+}
+
+func (sc *StoryController) GetViewers(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	storyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	views, err := sc.storyUsecase.GetViewers(storyID, userID)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved story viewers",
+			"data":    models.ParseStoryViewersToResponse(views),
+		})
+}
+
+func (sc *StoryController) GetArchive(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	stories, err := sc.storyUsecase.GetArchive(userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusInternalServerError, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved story archive",
+			"data":    models.ParseStoriesToResponse(stories, nil),
+		})
+}
+
+func (sc *StoryController) DeleteStory(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	storyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	err = sc.storyUsecase.DeleteStory(storyID, userID)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully deleted story",
+		})
+}
+
+func (sc *StoryController) CreateHighlight(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var highlightInput models.HighlightInput
+
+	err = c.Bind(&highlightInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	highlight, err := sc.storyUsecase.CreateHighlight(userID, highlightInput)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully created highlight",
+			"data":    models.ParseHighlightToResponse(highlight),
+		})
+}
+
+func (sc *StoryController) AddHighlightStory(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	highlightID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	var highlightStoryInput models.HighlightStoryInput
+
+	err = c.Bind(&highlightStoryInput)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	highlight, err := sc.storyUsecase.AddHighlightStory(highlightID, userID, highlightStoryInput)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully added story to highlight",
+			"data":    models.ParseHighlightToResponse(highlight),
+		})
+}
+
+func (sc *StoryController) GetHighlights(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	highlights, err := sc.storyUsecase.GetHighlights(c.Param("username"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved highlights",
+			"data":    models.ParseHighlightsToResponse(highlights),
+		})
+}
+
+func (sc *StoryController) DeleteHighlight(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := sc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	highlightID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Parameter must be a valid ID",
+			})
+	}
+
+	err = sc.storyUsecase.DeleteHighlight(highlightID, userID)
+	if err != nil {
+		return storyErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully deleted highlight",
+		})
+}
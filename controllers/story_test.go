@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStoryUsecase fails marking stories as seen with err, if set.
+type failingStoryUsecase struct {
+	usecases.StoryUsecase
+	err error
+}
+
+func (u failingStoryUsecase) MarkSeen(storyID, viewerID int) error {
+	return u.err
+}
+
+func TestMarkSeen(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		err        error
+		expectCode int
+	}{
+		{
+			name:       "mark story seen",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "story not found",
+			err:        usecases.ErrStoryNotFound,
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "not following the author",
+			err:        usecases.ErrStoriesFollowOnly,
+			expectCode: http.StatusForbidden,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		storyController := NewStoryController(credentialUsecase{}, failingStoryUsecase{err: testCase.err}, nil, nil)
+
+		req := newTokenRequest(t, 1, http.MethodPost, "/stories/1/seen", "")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		if assert.NoError(t, storyController.MarkSeen(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+}
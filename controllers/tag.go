@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TagController struct {
+	userUsecase usecases.UserUsecase
+	tagUsecase  usecases.TagUsecase
+}
+
+func NewTagController(userUsecase usecases.UserUsecase, tagUsecase usecases.TagUsecase) TagController {
+	return TagController{userUsecase, tagUsecase}
+}
+
+func (tc *TagController) GetTag(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := tc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tag, err := tc.tagUsecase.GetTag(c.Param("tag"), userID)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved tag",
+			"data":    tag,
+		})
+}
+
+func (tc *TagController) GetTagPhotos(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := tc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	page, limit := parsePagination(c)
+
+	photos, err := tc.tagUsecase.GetTagPhotos(c.Param("tag"), userID, page, limit)
+	if err != nil {
+		return c.JSON(
+			http.StatusNotFound, echo.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully retrieved tag photos",
+			"data":    models.ParsePhotoToResponseArray(photos),
+			"page":    page,
+			"limit":   limit,
+		})
+}
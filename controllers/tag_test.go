@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedTagUsecase returns a photo per page of the bali tag.
+type pagedTagUsecase struct {
+	usecases.TagUsecase
+}
+
+func (pagedTagUsecase) GetTagPhotos(name string, viewerID, page, limit int) ([]models.Photo, error) {
+	if name != "bali" {
+		return nil, errors.New("Tag not found")
+	}
+	return []models.Photo{{Title: "Bali", Caption: "Page " + strconv.Itoa(page)}}, nil
+}
+
+func TestGetTagPhotos(t *testing.T) {
+	tagController := NewTagController(credentialUsecase{}, pagedTagUsecase{})
+
+	e := echo.New()
+	req := newTokenRequest(t, 1, http.MethodGet, "/tags/bali/photos?page=2&limit=10", "")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("tag")
+	c.SetParamValues("bali")
+
+	if assert.NoError(t, tagController.GetTagPhotos(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Data  []models.PhotoResponse `json:"data"`
+			Page  int                    `json:"page"`
+			Limit int                    `json:"limit"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, 2, body.Page)
+		assert.Equal(t, 10, body.Limit)
+		if assert.Len(t, body.Data, 1) {
+			assert.Equal(t, "Page 2", body.Data[0].Caption)
+		}
+	}
+
+	req = newTokenRequest(t, 1, http.MethodGet, "/tags/jakarta/photos", "")
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("tag")
+	c.SetParamValues("jakarta")
+
+	if assert.NoError(t, tagController.GetTagPhotos(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
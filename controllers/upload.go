@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"errors"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/middlewares"
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration"
+
+	// tusChunkContentType is the content type of PATCH requests.
+	tusChunkContentType = "application/offset+octet-stream"
+)
+
+type UploadController struct {
+	userUsecase   usecases.UserUsecase
+	uploadUsecase usecases.UploadUsecase
+}
+
+func NewUploadController(userUsecase usecases.UserUsecase, uploadUsecase usecases.UploadUsecase) UploadController {
+	return UploadController{userUsecase, uploadUsecase}
+}
+
+// tusRequest sets the headers every tus response carries and reports whether
+// the client speaks a supported protocol version. If not, a 412 response has
+// been sent.
+func tusRequest(c echo.Context) (bool, error) {
+	header := c.Response().Header()
+	header.Set("Tus-Resumable", tusVersion)
+	header.Set("Cache-Control", "no-store")
+
+	if c.Request().Header.Get("Tus-Resumable") != tusVersion {
+		header.Set("Tus-Version", tusVersion)
+		return false, c.JSON(http.StatusPreconditionFailed, echo.Map{
+			"message": "Unsupported tus version, please use " + tusVersion,
+		})
+	}
+
+	return true, nil
+}
+
+// setUploadHeaders describes the state of upload in the tus headers.
+func setUploadHeaders(c echo.Context, upload models.Upload) {
+	header := c.Response().Header()
+	header.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	header.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// uploadErrorResponse reports a failed upload request with the status the
+// tus protocol uses for the reason, 400, 413 and 415 for invalid direct
+// uploads, or 403 when the storage quota is used up.
+func uploadErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecases.ErrUploadNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, usecases.ErrUploadExpired):
+		status = http.StatusGone
+	case errors.Is(err, usecases.ErrUploadOffset):
+		status = http.StatusConflict
+	case errors.Is(err, usecases.ErrUploadLocked):
+		status = http.StatusLocked
+	case errors.Is(err, helpers.ErrImageTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, helpers.ErrImageUnsupported):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, usecases.ErrPresignUnsupported):
+		status = http.StatusNotImplemented
+	case errors.Is(err, usecases.ErrQuotaExceeded):
+		status = http.StatusForbidden
+	case errors.Is(err, usecases.ErrUploadLength), errors.Is(err, usecases.ErrUploadLimit),
+		errors.Is(err, usecases.ErrPresignSize), errors.Is(err, usecases.ErrPresignChecksum):
+		status = http.StatusBadRequest
+	}
+
+	if status == http.StatusInternalServerError {
+		return c.JSON(status, echo.Map{
+			"message": "Error uploading photo",
+		})
+	}
+
+	return c.JSON(status, echo.Map{
+		"message": err.Error(),
+	})
+}
+
+// GetOptions tells tus clients which protocol version and extensions the
+// server supports. It needs no token.
+func (uc *UploadController) GetOptions(c echo.Context) error {
+	header := c.Response().Header()
+	header.Set("Tus-Resumable", tusVersion)
+	header.Set("Tus-Version", tusVersion)
+	header.Set("Tus-Extension", tusExtensions)
+	header.Set("Tus-Max-Size", strconv.FormatInt(uc.uploadUsecase.MaxSize(), 10))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (uc *UploadController) CreateUpload(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := uc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	ok, err := tusRequest(c)
+	if !ok {
+		return err
+	}
+
+	if c.Request().Header.Get("Upload-Defer-Length") != "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Deferred upload length is not supported",
+		})
+	}
+
+	length, err := strconv.ParseInt(c.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return uploadErrorResponse(c, usecases.ErrUploadLength)
+	}
+
+	upload, err := uc.uploadUsecase.CreateUpload(userID, length, c.Request().Header.Get("Upload-Metadata"))
+	if err != nil {
+		return uploadErrorResponse(c, err)
+	}
+
+	setUploadHeaders(c, upload)
+	c.Response().Header().Set(echo.HeaderLocation, c.Request().URL.Path+"/"+upload.ID)
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully created upload",
+			"data":    models.ParseUploadToResponse(upload),
+		})
+}
+
+func (uc *UploadController) GetUpload(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := uc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	ok, err := tusRequest(c)
+	if !ok {
+		return err
+	}
+
+	upload, err := uc.uploadUsecase.GetUpload(c.Param("id"), userID)
+	if err != nil {
+		return uploadErrorResponse(c, err)
+	}
+
+	setUploadHeaders(c, upload)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (uc *UploadController) WriteChunk(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := uc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	ok, err := tusRequest(c)
+	if !ok {
+		return err
+	}
+
+	if c.Request().Header.Get(echo.HeaderContentType) != tusChunkContentType {
+		return c.JSON(http.StatusUnsupportedMediaType, echo.Map{
+			"message": "Content-Type must be " + tusChunkContentType,
+		})
+	}
+
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Upload-Offset must be a valid offset",
+		})
+	}
+
+	upload, err := uc.uploadUsecase.WriteChunk(c.Param("id"), userID, offset, c.Request().Body)
+	if err != nil {
+		return uploadErrorResponse(c, err)
+	}
+
+	setUploadHeaders(c, upload)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (uc *UploadController) PresignUpload(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userID, err := middlewares.GetUserIdFromToken(tokenString)
+	checkUser, err := uc.userUsecase.GetCredential(userID)
+
+	if checkUser.ID == 0 || err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var input models.PresignUploadInput
+
+	err = c.Bind(&input)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Cannot input the data",
+			})
+	}
+
+	upload, form, err := uc.uploadUsecase.PresignUpload(userID, input)
+	if err != nil {
+		return uploadErrorResponse(c, err)
+	}
+
+	return c.JSON(
+		http.StatusCreated, echo.Map{
+			"message": "Successfully created upload URL",
+			"data": models.PresignedUploadResponse{
+				ID:        upload.ID,
+				Upload:    form,
+				ExpiresAt: upload.ExpiresAt,
+			},
+		})
+}
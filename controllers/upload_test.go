@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"io"
+	"mini-project-alterra/configs"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUploadController(t *testing.T) UploadController {
+	searchIndex := repositories.NewMemorySearchIndex()
+	userRepository := repositories.NewUserRepository(configs.DB)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
+
+	mediaStore, _ := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.DefaultImageLimits)
+	uploadRepository := repositories.NewUploadRepository(configs.DB)
+	quotaUsecase := usecases.NewQuotaUsecase(repositories.NewPhotoRepository(configs.DB), userRepository, nil)
+	uploadUsecase := usecases.NewUploadUsecase(uploadRepository, mediaUpload, quotaUsecase, t.TempDir(), helpers.DefaultImageLimits.MaxBytes, time.Hour, 15*time.Minute)
+	return NewUploadController(userService, uploadUsecase)
+}
+
+func TestUploadOptions(t *testing.T) {
+	uploadController := newTestUploadController(t)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodOptions, "/uploads", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, uploadController.GetOptions(c)) {
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "1.0.0", rec.Header().Get("Tus-Version"))
+		assert.Equal(t, "creation,expiration", rec.Header().Get("Tus-Extension"))
+		assert.Equal(t, "10485760", rec.Header().Get("Tus-Max-Size"))
+	}
+}
+
+// chunkUploadUsecase has one upload, with 5 of its 10 bytes written.
+type chunkUploadUsecase struct {
+	usecases.UploadUsecase
+}
+
+func (chunkUploadUsecase) WriteChunk(id string, userID int, offset int64, body io.Reader) (models.Upload, error) {
+	if id != "0123456789abcdef0123456789abcdef" {
+		return models.Upload{}, usecases.ErrUploadNotFound
+	}
+	if offset != 5 {
+		return models.Upload{}, usecases.ErrUploadOffset
+	}
+	chunk, err := io.ReadAll(body)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	return models.Upload{ID: id, UserID: userID, Length: 10, Offset: offset + int64(len(chunk))}, nil
+}
+
+func TestWriteChunk(t *testing.T) {
+	uploadController := NewUploadController(credentialUsecase{}, chunkUploadUsecase{})
+
+	var testCases = []struct {
+		name         string
+		id           string
+		tusResumable string
+		contentType  string
+		offset       string
+		expectCode   int
+	}{
+		{
+			name:         "write chunk",
+			id:           "0123456789abcdef0123456789abcdef",
+			tusResumable: "1.0.0",
+			contentType:  "application/offset+octet-stream",
+			offset:       "5",
+			expectCode:   http.StatusNoContent,
+		},
+		{
+			name:         "unsupported tus version",
+			id:           "0123456789abcdef0123456789abcdef",
+			tusResumable: "0.2.2",
+			contentType:  "application/offset+octet-stream",
+			offset:       "5",
+			expectCode:   http.StatusPreconditionFailed,
+		},
+		{
+			name:         "not a chunk",
+			id:           "0123456789abcdef0123456789abcdef",
+			tusResumable: "1.0.0",
+			contentType:  echo.MIMEApplicationJSON,
+			offset:       "5",
+			expectCode:   http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "invalid offset",
+			id:           "0123456789abcdef0123456789abcdef",
+			tusResumable: "1.0.0",
+			contentType:  "application/offset+octet-stream",
+			offset:       "-1",
+			expectCode:   http.StatusBadRequest,
+		},
+		{
+			name:         "wrong offset",
+			id:           "0123456789abcdef0123456789abcdef",
+			tusResumable: "1.0.0",
+			contentType:  "application/offset+octet-stream",
+			offset:       "0",
+			expectCode:   http.StatusConflict,
+		},
+		{
+			name:         "upload not found",
+			id:           "fedcba9876543210fedcba9876543210",
+			tusResumable: "1.0.0",
+			contentType:  "application/offset+octet-stream",
+			offset:       "5",
+			expectCode:   http.StatusNotFound,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		req := newTokenRequest(t, 1, http.MethodPatch, "/uploads/"+testCase.id, "chunk")
+		req.Header.Set("Tus-Resumable", testCase.tusResumable)
+		req.Header.Set(echo.HeaderContentType, testCase.contentType)
+		req.Header.Set("Upload-Offset", testCase.offset)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testCase.id)
+
+		if assert.NoError(t, uploadController.WriteChunk(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+			assert.Equal(t, "1.0.0", rec.Header().Get("Tus-Resumable"), testCase.name)
+			if rec.Code == http.StatusNoContent {
+				assert.Equal(t, "10", rec.Header().Get("Upload-Offset"), testCase.name)
+			}
+		}
+	}
+}
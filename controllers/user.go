@@ -145,6 +145,48 @@ func (controller *UserController) UpdateUser(c echo.Context) error {
 		})
 }
 
+func (controller *UserController) UpdateSettings(c echo.Context) error {
+	tokenString := middlewares.GetTokenFromHeader(c.Request())
+
+	if tokenString == "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "No token provided",
+		})
+	}
+
+	userId, err := middlewares.GetUserIdFromToken(tokenString)
+
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var input models.UserSettingsInput
+
+	err = c.Bind(&input)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Failed to update settings",
+			})
+	}
+
+	user, err := controller.userUsecase.UpdateSettings(userId, input)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest, echo.Map{
+				"message": "Failed to update settings",
+			})
+	}
+
+	return c.JSON(
+		http.StatusOK, echo.Map{
+			"message": "Successfully updated settings",
+			"data":    models.ParseUserToResponse(user),
+		})
+}
+
 func (controller *UserController) DeleteUser(c echo.Context) error {
 	tokenString := middlewares.GetTokenFromHeader(c.Request())
 
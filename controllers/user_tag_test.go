@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// ownedUserTagUsecase tags users in photos owned by ownerID.
+type ownedUserTagUsecase struct {
+	usecases.UserTagUsecase
+	ownerID int
+}
+
+func (u ownedUserTagUsecase) TagUser(input models.UserTagInput, photoID, userID int) (models.UserTag, int, error) {
+	tag := models.UserTag{ID: 1, PhotoID: uint(photoID), X: input.X, Y: input.Y, Status: "pending"}
+	return tag, u.ownerID, nil
+}
+
+func TestTagUser(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		ownerID    int
+		expectCode int
+	}{
+		{
+			name:       "tag user in own photo",
+			ownerID:    1,
+			expectCode: http.StatusCreated,
+		},
+		{
+			name:       "tag user in another user's photo",
+			ownerID:    2,
+			expectCode: http.StatusBadRequest,
+		},
+	}
+
+	e := echo.New()
+	for _, testCase := range testCases {
+		userTagController := NewUserTagController(credentialUsecase{}, ownedUserTagUsecase{ownerID: testCase.ownerID})
+
+		req := newTokenRequest(t, 1, http.MethodPost, "/photos/1/user-tags", `{"username":"hanif","x":0.5,"y":0.5}`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		if assert.NoError(t, userTagController.TagUser(c), testCase.name) {
+			assert.Equal(t, testCase.expectCode, rec.Code, testCase.name)
+		}
+	}
+}
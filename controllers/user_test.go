@@ -10,9 +10,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func InitEchoTestAPI() *echo.Echo {
@@ -21,6 +24,84 @@ func InitEchoTestAPI() *echo.Echo {
 	return e
 }
 
+// credentialUsecase stands in for the user usecase of controllers tested
+// without a database: every user a token names exists.
+type credentialUsecase struct {
+	usecases.UserUsecase
+}
+
+func (credentialUsecase) GetCredential(userId int) (models.User, error) {
+	return models.User{Model: gorm.Model{ID: uint(userId)}}, nil
+}
+
+// newTokenRequest returns a JSON request made with a token of the user.
+func newTokenRequest(t *testing.T, userID int, method, target, body string) *http.Request {
+	t.Setenv("SECRET_JWT", "secret")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": userID,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	return req
+}
+
+func TestHandlersWithoutToken(t *testing.T) {
+	albumController := NewAlbumController(credentialUsecase{}, nil)
+	followController := NewFollowController(credentialUsecase{}, nil)
+	moderationController := NewModerationController(credentialUsecase{}, nil)
+	quotaController := NewQuotaController(credentialUsecase{}, nil)
+	repostController := NewRepostController(credentialUsecase{}, nil)
+	searchController := NewSearchController(credentialUsecase{}, nil)
+	storyController := NewStoryController(credentialUsecase{}, nil, nil, nil)
+	tagController := NewTagController(credentialUsecase{}, nil)
+	uploadController := NewUploadController(credentialUsecase{}, nil)
+	userTagController := NewUserTagController(credentialUsecase{}, nil)
+
+	var testCases = map[string]echo.HandlerFunc{
+		"create album":      albumController.CreateAlbum,
+		"get album":         albumController.GetAlbum,
+		"update album":      albumController.UpdateAlbum,
+		"reorder album":     albumController.ReorderAlbumPhotos,
+		"follow user":       followController.FollowUser,
+		"block user":        followController.BlockUser,
+		"create report":     moderationController.CreateReport,
+		"resolve case":      moderationController.ResolveCase,
+		"get usage":         quotaController.GetUsage,
+		"create repost":     repostController.CreateRepost,
+		"search":            searchController.Search,
+		"create story":      storyController.CreateStory,
+		"get story tray":    storyController.GetTray,
+		"mark story seen":   storyController.MarkSeen,
+		"get tag photos":    tagController.GetTagPhotos,
+		"create upload":     uploadController.CreateUpload,
+		"write chunk":       uploadController.WriteChunk,
+		"presign upload":    uploadController.PresignUpload,
+		"tag user":          userTagController.TagUser,
+		"get pending tags":  userTagController.GetPendingTags,
+		"get tagged photos": userTagController.GetTaggedPhotos,
+	}
+
+	e := echo.New()
+	for name, handler := range testCases {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		if assert.NoError(t, handler(c), name) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, name)
+		}
+	}
+}
+
 func TestLoginUser(t *testing.T) {
 	var testCases = []struct {
 		name       string
@@ -39,9 +120,10 @@ func TestLoginUser(t *testing.T) {
 		},
 	}
 
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
 
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	userController := NewUserController(userService)
 
@@ -88,9 +170,10 @@ func TestRegisterUser(t *testing.T) {
 		},
 	}
 
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
 
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	userController := NewUserController(userService)
 
@@ -118,9 +201,10 @@ func TestRegisterUser(t *testing.T) {
 }
 func TestUpdateUser(t *testing.T) {
 
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
 
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	userController := NewUserController(userService)
 
@@ -168,8 +252,9 @@ func TestGetCredential(t *testing.T) {
 	}
 
 	// setup dependencies
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 	userController := NewUserController(userService)
 
 	// setup echo
@@ -203,9 +288,10 @@ func TestGetCredential(t *testing.T) {
 	}
 }
 func TestDeleteUser(t *testing.T) {
+	searchIndex := repositories.NewMemorySearchIndex()
 	userRepository := repositories.NewUserRepository(configs.DB)
 
-	userService := usecases.NewUserUsecase(userRepository)
+	userService := usecases.NewUserUsecase(userRepository, searchIndex)
 
 	userController := NewUserController(userService)
 
@@ -2,27 +2,191 @@ package helpers
 
 import (
 	"context"
-	"mini-project-alterra/configs"
+	"errors"
+	"io"
+	"mini-project-alterra/models"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/cloudinary/cloudinary-go"
+	"github.com/cloudinary/cloudinary-go/api"
+	"github.com/cloudinary/cloudinary-go/api/admin"
 	"github.com/cloudinary/cloudinary-go/api/uploader"
 )
 
-func ImageUploadHelper(input interface{}) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// CloudinaryMediaStore stores media as Cloudinary image assets. The public
+// ID of an asset is its key without the file extension, inside Folder.
+type CloudinaryMediaStore struct {
+	cld    *cloudinary.Cloudinary
+	Folder string
+}
+
+func NewCloudinaryMediaStore(cloudName, apiKey, apiSecret, folder string) (*CloudinaryMediaStore, error) {
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloudinaryMediaStore{cld: cld, Folder: strings.Trim(folder, "/")}, nil
+}
+
+func (s *CloudinaryMediaStore) publicID(key string) string {
+	id := strings.TrimSuffix(key, path.Ext(key))
+	if s.Folder == "" {
+		return id
+	}
+	return s.Folder + "/" + id
+}
+
+func (s *CloudinaryMediaStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (models.MediaObject, error) {
+	var object models.MediaObject
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	//create cloudinary instance
-	cld, err := cloudinary.NewFromParams(configs.EnvCloudName(), configs.EnvCloudAPIKey(), configs.EnvCloudAPISecret())
+	result, err := s.cld.Upload.Upload(ctx, body, uploader.UploadParams{PublicID: s.publicID(key), Overwrite: true})
 	if err != nil {
-		return "", err
+		return object, err
 	}
+	if result.Error.Message != "" {
+		return object, errors.New(result.Error.Message)
+	}
+
+	object.Key = key
+	object.URL = result.SecureURL
+	object.Size = int64(result.Bytes)
+	object.ContentType = contentType
+	object.UpdatedAt = result.CreatedAt
+
+	return object, nil
+}
+
+func (s *CloudinaryMediaStore) Delete(ctx context.Context, key string) error {
+	result, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: s.publicID(key), Invalidate: true})
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New(result.Error.Message)
+	}
+	return nil
+}
 
-	//upload file
-	uploadParam, err := cld.Upload.Upload(ctx, input, uploader.UploadParams{Folder: configs.EnvCloudUploadFolder()})
+func (s *CloudinaryMediaStore) Stat(ctx context.Context, key string) (models.MediaObject, error) {
+	var object models.MediaObject
+
+	result, err := s.cld.Admin.Asset(ctx, admin.AssetParams{PublicID: s.publicID(key)})
+	if err != nil {
+		return object, err
+	}
+	if result.Error.Message != "" {
+		if strings.Contains(strings.ToLower(result.Error.Message), "not found") {
+			return object, ErrMediaNotFound
+		}
+		return object, errors.New(result.Error.Message)
+	}
+
+	object.Key = key
+	object.URL = result.SecureURL
+	object.Size = int64(result.Bytes)
+	object.ContentType = "image/" + result.Format
+	object.UpdatedAt = result.CreatedAt
+
+	return object, nil
+}
+
+func (s *CloudinaryMediaStore) List(ctx context.Context, prefix string, fn func(models.MediaObject) error) error {
+	folder := ""
+	if s.Folder != "" {
+		folder = s.Folder + "/"
+	}
+
+	var cursor string
+	for {
+		result, err := s.cld.Admin.Assets(ctx, admin.AssetsParams{
+			AssetType:    api.Image,
+			DeliveryType: "upload",
+			Prefix:       folder + prefix,
+			MaxResults:   500,
+			NextCursor:   cursor,
+		})
+		if err != nil {
+			return err
+		}
+		if result.Error.Message != "" {
+			return errors.New(result.Error.Message)
+		}
+
+		for _, asset := range result.Assets {
+			key := strings.TrimPrefix(asset.PublicID, folder)
+			if asset.Format != "" {
+				key += "." + asset.Format
+			}
+
+			err = fn(models.MediaObject{
+				Key:         key,
+				URL:         asset.SecureURL,
+				Size:        int64(asset.Bytes),
+				ContentType: "image/" + asset.Format,
+				Width:       asset.Width,
+				Height:      asset.Height,
+				UpdatedAt:   asset.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if result.NextCursor == "" {
+			return nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// SignedURL returns a signed delivery URL. Cloudinary signatures do not
+// expire, so expiry is ignored.
+func (s *CloudinaryMediaStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	image, err := s.cld.Image(s.publicID(key))
 	if err != nil {
 		return "", err
 	}
-	return uploadParam.SecureURL, nil
+
+	image.Config.URL.Secure = true
+	image.Config.URL.SignURL = true
+
+	return image.String()
+}
+
+// PresignUpload returns a signed upload form for the asset of key.
+// Cloudinary rejects signatures older than an hour, so expiry is capped
+// there, and it cannot limit the size, which is checked when the upload is
+// finalized instead.
+func (s *CloudinaryMediaStore) PresignUpload(ctx context.Context, key, contentType string, maxSize int64, expiry time.Duration) (models.UploadForm, error) {
+	if expiry > time.Hour {
+		expiry = time.Hour
+	}
+
+	params := url.Values{}
+	params.Set("public_id", s.publicID(key))
+
+	signature, err := api.SignParameters(params, s.cld.Config.Cloud.APISecret)
+	if err != nil {
+		return models.UploadForm{}, err
+	}
+
+	return models.UploadForm{
+		Method: http.MethodPost,
+		URL:    "https://api.cloudinary.com/v1_1/" + s.cld.Config.Cloud.CloudName + "/image/upload",
+		Fields: map[string]string{
+			"public_id": params.Get("public_id"),
+			"timestamp": params.Get("timestamp"),
+			"api_key":   s.cld.Config.Cloud.APIKey,
+			"signature": signature,
+		},
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
 }
@@ -0,0 +1,750 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"mini-project-alterra/models"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoExif = errors.New("image has no EXIF data")
+	// ErrExifInvalid is returned for JPEGs whose metadata cannot be read,
+	// and so cannot be cleaned in place.
+	ErrExifInvalid = errors.New("image has unreadable metadata")
+)
+
+// JPEG markers of the segments handled by this file.
+const (
+	jpegMarkerAPP1  = 0xE1
+	jpegMarkerAPP13 = 0xED
+	jpegMarkerSOS   = 0xDA
+	jpegMarkerEOI   = 0xD9
+)
+
+// EXIF tags read or scrubbed by this file.
+const (
+	exifTagMake               = 0x010F
+	exifTagModel              = 0x0110
+	exifTagOrientation        = 0x0112
+	exifTagExifIFD            = 0x8769
+	exifTagGPSIFD             = 0x8825
+	exifTagExposureTime       = 0x829A
+	exifTagFNumber            = 0x829D
+	exifTagISO                = 0x8827
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagFocalLength        = 0x920A
+	exifTagMakerNote          = 0x927C
+	exifTagBodySerialNumber   = 0xA431
+	exifTagLensModel          = 0xA434
+	exifTagLensSerialNumber   = 0xA435
+	exifTagCameraSerialNumber = 0xC62F
+	exifTagGPSLatitudeRef     = 0x0001
+	exifTagGPSLatitude        = 0x0002
+	exifTagGPSLongitudeRef    = 0x0003
+	exifTagGPSLongitude       = 0x0004
+	exifTagStripOffsets       = 0x0111
+	exifTagStripByteCounts    = 0x0117
+	exifTagThumbnailOffset    = 0x0201
+	exifTagThumbnailLength    = 0x0202
+)
+
+// privateExifTags are blanked on upload. Maker notes are included because
+// most vendors store the body serial number in them.
+var privateExifTags = map[uint16]bool{
+	exifTagMakerNote:          true,
+	exifTagBodySerialNumber:   true,
+	exifTagLensSerialNumber:   true,
+	exifTagCameraSerialNumber: true,
+}
+
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// ExifData holds the EXIF fields Pixelfeed uses.
+type ExifData struct {
+	Make         string
+	Model        string
+	LensModel    string
+	ExposureTime string
+	FNumber      float64
+	ISO          int
+	FocalLength  float64
+	TakenAt      *time.Time
+	Orientation  int
+	Latitude     *float64
+	Longitude    *float64
+}
+
+type exifEntry struct {
+	tag    uint16
+	typ    uint16
+	count  uint32
+	offset int // offset of the 12 byte entry in the TIFF block
+}
+
+type exifReader struct {
+	tiff  []byte
+	order binary.ByteOrder
+}
+
+func (r *exifReader) uint16At(off int) (uint16, bool) {
+	if off < 0 || off+2 > len(r.tiff) {
+		return 0, false
+	}
+	return r.order.Uint16(r.tiff[off:]), true
+}
+
+func (r *exifReader) uint32At(off int) (uint32, bool) {
+	if off < 0 || off+4 > len(r.tiff) {
+		return 0, false
+	}
+	return r.order.Uint32(r.tiff[off:]), true
+}
+
+// ifd returns the entries of the IFD at off.
+func (r *exifReader) ifd(off int) ([]exifEntry, bool) {
+	count, ok := r.uint16At(off)
+	if !ok || off+2+int(count)*12 > len(r.tiff) {
+		return nil, false
+	}
+
+	entries := make([]exifEntry, 0, count)
+	for i := 0; i < int(count); i++ {
+		base := off + 2 + i*12
+		entries = append(entries, exifEntry{
+			tag:    r.order.Uint16(r.tiff[base:]),
+			typ:    r.order.Uint16(r.tiff[base+2:]),
+			count:  r.order.Uint32(r.tiff[base+4:]),
+			offset: base,
+		})
+	}
+	return entries, true
+}
+
+// value returns the raw bytes of an entry's value, which live inside the
+// entry when they fit in four bytes and elsewhere in the block otherwise.
+func (r *exifReader) value(e exifEntry) (int, int, bool) {
+	size, ok := exifTypeSizes[e.typ]
+	if !ok || e.count > uint32(len(r.tiff)) {
+		return 0, 0, false
+	}
+
+	length := size * int(e.count)
+	start := e.offset + 8
+	if length > 4 {
+		off, _ := r.uint32At(e.offset + 8)
+		start = int(off)
+	}
+	if start < 0 || start+length > len(r.tiff) {
+		return 0, 0, false
+	}
+	return start, start + length, true
+}
+
+func (r *exifReader) ascii(e exifEntry) string {
+	start, end, ok := r.value(e)
+	if !ok || e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(r.tiff[start:end]), "\x00"))
+}
+
+func (r *exifReader) uint(e exifEntry) int {
+	start, _, ok := r.value(e)
+	if !ok {
+		return 0
+	}
+	switch e.typ {
+	case 3:
+		return int(r.order.Uint16(r.tiff[start:]))
+	case 4:
+		return int(r.order.Uint32(r.tiff[start:]))
+	}
+	return 0
+}
+
+// uints returns the values of a SHORT or LONG entry.
+func (r *exifReader) uints(e exifEntry) ([]int, bool) {
+	start, _, ok := r.value(e)
+	if !ok || (e.typ != 3 && e.typ != 4) {
+		return nil, false
+	}
+
+	values := make([]int, 0, e.count)
+	for i := 0; i < int(e.count); i++ {
+		if e.typ == 3 {
+			values = append(values, int(r.order.Uint16(r.tiff[start+i*2:])))
+		} else {
+			values = append(values, int(r.order.Uint32(r.tiff[start+i*4:])))
+		}
+	}
+	return values, true
+}
+
+func (r *exifReader) rationals(e exifEntry) [][2]uint32 {
+	start, _, ok := r.value(e)
+	if !ok || e.typ != 5 {
+		return nil
+	}
+
+	values := make([][2]uint32, 0, e.count)
+	for i := 0; i < int(e.count); i++ {
+		values = append(values, [2]uint32{
+			r.order.Uint32(r.tiff[start+i*8:]),
+			r.order.Uint32(r.tiff[start+i*8+4:]),
+		})
+	}
+	return values
+}
+
+func rationalFloat(v [2]uint32) float64 {
+	if v[1] == 0 {
+		return 0
+	}
+	return float64(v[0]) / float64(v[1])
+}
+
+// gpsCoordinate converts degrees, minutes and seconds to a signed decimal
+// coordinate, negative for the south and west references.
+func (r *exifReader) gpsCoordinate(value, ref exifEntry) *float64 {
+	dms := r.rationals(value)
+	if len(dms) != 3 {
+		return nil
+	}
+
+	coordinate := rationalFloat(dms[0]) + rationalFloat(dms[1])/60 + rationalFloat(dms[2])/3600
+	if s := r.ascii(ref); s == "S" || s == "W" {
+		coordinate = -coordinate
+	}
+	return &coordinate
+}
+
+// jpegSegment is a metadata segment of a JPEG, from its marker to its end.
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+// exif reports whether the segment is an EXIF APP1 segment, whose TIFF
+// block starts 10 bytes in.
+func (s jpegSegment) exif() bool {
+	return s.marker == jpegMarkerAPP1 && bytes.HasPrefix(s.data[4:], []byte("Exif\x00\x00"))
+}
+
+// xmp reports whether the segment is an XMP APP1 segment, or the extension
+// of one.
+func (s jpegSegment) xmp() bool {
+	return s.marker == jpegMarkerAPP1 && (bytes.HasPrefix(s.data[4:], []byte("http://ns.adobe.com/xap/1.0/\x00")) ||
+		bytes.HasPrefix(s.data[4:], []byte("http://ns.adobe.com/xmp/extension/\x00")))
+}
+
+// jpegSegments returns the segments of a JPEG before its image data, and
+// where the image data starts.
+func jpegSegments(data []byte) ([]jpegSegment, int, error) {
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return nil, 0, ErrNoExif
+	}
+
+	var segments []jpegSegment
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil, 0, ErrExifInvalid
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte before a marker.
+			pos++
+			continue
+		}
+		if marker == jpegMarkerSOS || marker == jpegMarkerEOI {
+			return segments, pos, nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, ErrExifInvalid
+		}
+
+		segments = append(segments, jpegSegment{marker, data[pos:end]})
+		pos = end
+	}
+
+	return nil, 0, ErrExifInvalid
+}
+
+// findExif returns the TIFF block of the first EXIF APP1 segment of a JPEG.
+func findExif(data []byte) ([]byte, error) {
+	segments, _, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, segment := range segments {
+		if segment.exif() {
+			return segment.data[10:], nil
+		}
+	}
+
+	return nil, ErrNoExif
+}
+
+func newExifReader(tiff []byte) (*exifReader, int, error) {
+	if len(tiff) < 8 {
+		return nil, 0, ErrExifInvalid
+	}
+
+	r := &exifReader{tiff: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, 0, ErrExifInvalid
+	}
+
+	if magic, _ := r.uint16At(2); magic != 42 {
+		return nil, 0, ErrExifInvalid
+	}
+	ifd0, _ := r.uint32At(4)
+
+	return r, int(ifd0), nil
+}
+
+// ParseExif reads the first EXIF block of a JPEG image. It fails with
+// ErrNoExif if there is none and with ErrExifInvalid if the metadata cannot
+// be read.
+func ParseExif(data []byte) (ExifData, error) {
+	exif := ExifData{Orientation: 1}
+
+	tiff, err := findExif(data)
+	if err != nil {
+		return exif, err
+	}
+
+	r, ifd0, err := newExifReader(tiff)
+	if err != nil {
+		return exif, err
+	}
+
+	entries, ok := r.ifd(ifd0)
+	if !ok {
+		return exif, ErrExifInvalid
+	}
+
+	var subIFDs []exifEntry
+	for _, e := range entries {
+		switch e.tag {
+		case exifTagMake:
+			exif.Make = r.ascii(e)
+		case exifTagModel:
+			exif.Model = r.ascii(e)
+		case exifTagOrientation:
+			if o := r.uint(e); o >= 1 && o <= 8 {
+				exif.Orientation = o
+			}
+		case exifTagExifIFD, exifTagGPSIFD:
+			subIFDs = append(subIFDs, e)
+		}
+	}
+
+	for _, sub := range subIFDs {
+		subEntries, ok := r.ifd(r.uint(sub))
+		if !ok {
+			continue
+		}
+
+		if sub.tag == exifTagGPSIFD {
+			byTag := map[uint16]exifEntry{}
+			for _, e := range subEntries {
+				byTag[e.tag] = e
+			}
+			exif.Latitude = r.gpsCoordinate(byTag[exifTagGPSLatitude], byTag[exifTagGPSLatitudeRef])
+			exif.Longitude = r.gpsCoordinate(byTag[exifTagGPSLongitude], byTag[exifTagGPSLongitudeRef])
+			continue
+		}
+
+		for _, e := range subEntries {
+			switch e.tag {
+			case exifTagLensModel:
+				exif.LensModel = r.ascii(e)
+			case exifTagISO:
+				exif.ISO = r.uint(e)
+			case exifTagFNumber:
+				if v := r.rationals(e); len(v) == 1 {
+					exif.FNumber = rationalFloat(v[0])
+				}
+			case exifTagFocalLength:
+				if v := r.rationals(e); len(v) == 1 {
+					exif.FocalLength = rationalFloat(v[0])
+				}
+			case exifTagExposureTime:
+				if v := r.rationals(e); len(v) == 1 && v[0][0] > 0 && v[0][1] > 0 {
+					if v[0][0] < v[0][1] {
+						exif.ExposureTime = fmt.Sprintf("1/%d", (v[0][1]+v[0][0]/2)/v[0][0])
+					} else {
+						exif.ExposureTime = fmt.Sprintf("%g", rationalFloat(v[0]))
+					}
+				}
+			case exifTagDateTimeOriginal:
+				takenAt, err := time.Parse("2006:01:02 15:04:05", r.ascii(e))
+				if err == nil {
+					exif.TakenAt = &takenAt
+				}
+			}
+		}
+	}
+
+	return exif, nil
+}
+
+// jpegImageData returns the image data of a JPEG from scan, where its first
+// scan starts, up to and including the EOI marker. Anything after it, such
+// as the video of a motion photo, is left out, as are application and
+// comment segments between the scans of a progressive JPEG.
+func jpegImageData(data []byte, scan int) ([]byte, error) {
+	var scans []byte
+
+	start := scan
+	for pos := scan; pos+2 <= len(data); {
+		if data[pos] != 0xFF {
+			pos++
+			continue
+		}
+
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker.
+			pos++
+			continue
+		case marker == 0x00, marker >= 0xD0 && marker <= 0xD7:
+			// Stuffed byte or restart marker in the entropy-coded data.
+			pos += 2
+			continue
+		case marker == jpegMarkerEOI:
+			return append(scans, data[start:pos+2]...), nil
+		}
+
+		if pos+4 > len(data) {
+			break
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end < pos+4 || end > len(data) {
+			break
+		}
+		if (marker >= 0xE0 && marker <= 0xEF) || marker == 0xFE {
+			scans = append(scans, data[start:pos]...)
+			start = end
+		}
+		pos = end
+	}
+
+	return nil, ErrExifInvalid
+}
+
+// StripPrivateExif returns a copy of a JPEG without XMP and IPTC segments or
+// anything after the image, and with serial numbers and maker notes blanked
+// and the thumbnail removed in every EXIF segment. Unless keepLocation is
+// set, the GPS blocks are emptied. The EXIF layout is kept intact so the
+// other tags stay readable. EXIF segments that cannot be read, and so cannot
+// be cleaned, are dropped.
+func StripPrivateExif(data []byte, keepLocation bool) ([]byte, error) {
+	segments, scan, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	scans, err := jpegImageData(data, scan)
+	if err != nil {
+		return nil, err
+	}
+
+	clean := append([]byte(nil), data[:2]...)
+	for _, segment := range segments {
+		switch {
+		case segment.xmp(), segment.marker == jpegMarkerAPP13:
+		case segment.exif():
+			if scrubbed, ok := scrubExif(segment.data, keepLocation); ok {
+				clean = append(clean, scrubbed...)
+			}
+		default:
+			clean = append(clean, segment.data...)
+		}
+	}
+
+	return append(clean, scans...), nil
+}
+
+// scrubExif returns a copy of an EXIF segment with its private tags
+// blanked. It reports false if a tag that has to be blanked cannot be
+// found.
+func scrubExif(segment []byte, keepLocation bool) ([]byte, bool) {
+	clean := append([]byte(nil), segment...)
+
+	r, ifd0, err := newExifReader(clean[10:])
+	if err != nil {
+		return nil, false
+	}
+	entries, ok := r.ifd(ifd0)
+	if !ok {
+		return nil, false
+	}
+
+	blank := func(e exifEntry) bool {
+		start, end, ok := r.value(e)
+		if !ok {
+			return false
+		}
+		for i := start; i < end; i++ {
+			r.tiff[i] = 0
+		}
+		return true
+	}
+
+	for _, e := range entries {
+		if privateExifTags[e.tag] && !blank(e) {
+			return nil, false
+		}
+		if e.tag != exifTagExifIFD && e.tag != exifTagGPSIFD {
+			continue
+		}
+
+		subOffset := r.uint(e)
+		subEntries, ok := r.ifd(subOffset)
+		if !ok {
+			return nil, false
+		}
+
+		if e.tag == exifTagGPSIFD && !keepLocation {
+			for _, sub := range subEntries {
+				// Values that cannot be found are lost with the entry.
+				blank(sub)
+				for i := sub.offset; i < sub.offset+12; i++ {
+					r.tiff[i] = 0
+				}
+			}
+			r.order.PutUint16(r.tiff[subOffset:], 0)
+			continue
+		}
+
+		for _, sub := range subEntries {
+			if privateExifTags[sub.tag] && !blank(sub) {
+				return nil, false
+			}
+		}
+	}
+
+	if !r.dropThumbnail(ifd0, len(entries)) {
+		return nil, false
+	}
+
+	return clean, true
+}
+
+// dropThumbnail blanks the thumbnail in IFD1, which can show parts of the
+// image that were cropped out, together with the IFD itself, and unlinks it
+// from IFD0 with the given number of entries. It reports false if the
+// thumbnail cannot be found.
+func (r *exifReader) dropThumbnail(ifd0, entries int) bool {
+	link := ifd0 + 2 + 12*entries
+	ifd1, ok := r.uint32At(link)
+	if !ok {
+		return false
+	}
+	if ifd1 == 0 {
+		return true
+	}
+
+	thumbnail, ok := r.ifd(int(ifd1))
+	if !ok {
+		return false
+	}
+	byTag := map[uint16]exifEntry{}
+	for _, e := range thumbnail {
+		byTag[e.tag] = e
+	}
+
+	// A thumbnail is either a JPEG or the strips of an uncompressed image.
+	for _, tags := range [][2]uint16{{exifTagThumbnailOffset, exifTagThumbnailLength}, {exifTagStripOffsets, exifTagStripByteCounts}} {
+		offsetEntry, hasOffsets := byTag[tags[0]]
+		lengthEntry, hasLengths := byTag[tags[1]]
+		if !hasOffsets && !hasLengths {
+			continue
+		}
+
+		offsets, ok := r.uints(offsetEntry)
+		if !ok {
+			return false
+		}
+		lengths, ok := r.uints(lengthEntry)
+		if !ok || len(lengths) != len(offsets) {
+			return false
+		}
+		for i, start := range offsets {
+			if start+lengths[i] > len(r.tiff) {
+				return false
+			}
+			r.zero(start, start+lengths[i])
+		}
+	}
+
+	for _, e := range thumbnail {
+		// Values that cannot be found are lost with the entry.
+		if start, end, ok := r.value(e); ok {
+			r.zero(start, end)
+		}
+	}
+	r.zero(int(ifd1), int(ifd1)+2+12*len(thumbnail))
+	r.order.PutUint32(r.tiff[link:], 0)
+
+	return true
+}
+
+func (r *exifReader) zero(start, end int) {
+	for i := start; i < end; i++ {
+		r.tiff[i] = 0
+	}
+}
+
+// uprightExif marks a cleaned EXIF segment, which has no thumbnail left, as
+// upright.
+func uprightExif(segment []byte) []byte {
+	upright := append([]byte(nil), segment...)
+
+	r, ifd0, err := newExifReader(upright[10:])
+	if err != nil {
+		return upright
+	}
+	entries, ok := r.ifd(ifd0)
+	if !ok {
+		return upright
+	}
+
+	for _, e := range entries {
+		if e.tag == exifTagOrientation && e.typ == 3 {
+			r.order.PutUint16(r.tiff[e.offset+8:], 1)
+		}
+	}
+
+	return upright
+}
+
+// ApplyOrientation returns img transformed so that it displays upright for
+// the given EXIF orientation (1 to 8).
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+
+	return dst
+}
+
+// CleanJPEG prepares a JPEG for storage by stripping its private metadata.
+// Rotated images are re-encoded upright, and get their cleaned EXIF
+// segments back marked as upright.
+func CleanJPEG(data []byte, exif ExifData, keepLocation bool) ([]byte, error) {
+	clean, err := StripPrivateExif(data, keepLocation)
+	if err != nil || exif.Orientation < 2 {
+		return clean, err
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageInvalid, err)
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, ApplyOrientation(img, exif.Orientation), &jpeg.Options{Quality: 92})
+	if err != nil {
+		return nil, err
+	}
+	encoded := buf.Bytes()
+
+	segments, _, err := jpegSegments(clean)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated := append([]byte(nil), encoded[:2]...)
+	for _, segment := range segments {
+		if segment.exif() {
+			rotated = append(rotated, uprightExif(segment.data)...)
+		}
+	}
+
+	return append(rotated, encoded[2:]...), nil
+}
+
+// ReencodeJPEG decodes and encodes a JPEG again, which drops all of its
+// metadata. It is used for JPEGs whose metadata cannot be cleaned.
+func ReencodeJPEG(data []byte) ([]byte, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageInvalid, err)
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 92})
+
+	return buf.Bytes(), err
+}
+
+// PhotoMetadata returns the public EXIF fields. The location is only
+// included when keepLocation is set.
+func (exif ExifData) PhotoMetadata(keepLocation bool) *models.PhotoMetadata {
+	metadata := &models.PhotoMetadata{
+		CameraMake:   exif.Make,
+		CameraModel:  exif.Model,
+		LensModel:    exif.LensModel,
+		ExposureTime: exif.ExposureTime,
+		FNumber:      exif.FNumber,
+		ISO:          exif.ISO,
+		FocalLength:  exif.FocalLength,
+		TakenAt:      exif.TakenAt,
+	}
+
+	if keepLocation {
+		metadata.Latitude = exif.Latitude
+		metadata.Longitude = exif.Longitude
+	}
+
+	return metadata
+}
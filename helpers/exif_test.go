@@ -0,0 +1,326 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testExifEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func exifASCII(tag uint16, s string) testExifEntry {
+	return testExifEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func exifShort(tag uint16, v uint16) testExifEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint16(data, v)
+	return testExifEntry{tag, 3, 1, data}
+}
+
+func exifLong(tag uint16, v uint32) testExifEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, v)
+	return testExifEntry{tag, 4, 1, data}
+}
+
+func exifRationals(tag uint16, values ...[2]uint32) testExifEntry {
+	data := make([]byte, 0, len(values)*8)
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v[0])
+		data = binary.LittleEndian.AppendUint32(data, v[1])
+	}
+	return testExifEntry{tag, 5, uint32(len(values)), data}
+}
+
+func testIFDSize(entries []testExifEntry) int {
+	size := 2 + 12*len(entries) + 4
+	for _, e := range entries {
+		if len(e.data) > 4 {
+			size += len(e.data)
+		}
+	}
+	return size
+}
+
+// appendTestIFD writes entries as an IFD starting at len(tiff), with the
+// values that do not fit in an entry right after it.
+func appendTestIFD(tiff []byte, entries []testExifEntry) []byte {
+	start := len(tiff)
+	dataOffset := start + 2 + 12*len(entries) + 4
+
+	tiff = binary.LittleEndian.AppendUint16(tiff, uint16(len(entries)))
+	var data []byte
+	for _, e := range entries {
+		tiff = binary.LittleEndian.AppendUint16(tiff, e.tag)
+		tiff = binary.LittleEndian.AppendUint16(tiff, e.typ)
+		tiff = binary.LittleEndian.AppendUint32(tiff, e.count)
+		if len(e.data) > 4 {
+			tiff = binary.LittleEndian.AppendUint32(tiff, uint32(dataOffset+len(data)))
+			data = append(data, e.data...)
+		} else {
+			tiff = append(tiff, append(e.data, make([]byte, 4-len(e.data))...)...)
+		}
+	}
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+
+	return append(tiff, data...)
+}
+
+// testExifJPEG returns a width x height JPEG carrying camera, serial number
+// and GPS tags.
+func testExifJPEG(t *testing.T, width, height int, orientation uint16) []byte {
+	exifIFD := []testExifEntry{
+		exifRationals(exifTagExposureTime, [2]uint32{1, 125}),
+		exifRationals(exifTagFNumber, [2]uint32{28, 10}),
+		exifShort(exifTagISO, 200),
+		exifASCII(exifTagDateTimeOriginal, "2023:04:01 10:30:00"),
+		exifRationals(exifTagFocalLength, [2]uint32{50, 1}),
+		exifASCII(exifTagBodySerialNumber, "BODY-SN-999"),
+		exifASCII(exifTagLensModel, "EF50mm f/1.8"),
+	}
+	gpsIFD := []testExifEntry{
+		exifASCII(exifTagGPSLatitudeRef, "S"),
+		exifRationals(exifTagGPSLatitude, [2]uint32{6, 1}, [2]uint32{10, 1}, [2]uint32{30, 1}),
+		exifASCII(exifTagGPSLongitudeRef, "E"),
+		exifRationals(exifTagGPSLongitude, [2]uint32{106, 1}, [2]uint32{49, 1}, [2]uint32{0, 1}),
+	}
+
+	ifd0 := []testExifEntry{
+		exifASCII(exifTagMake, "Canon"),
+		exifASCII(exifTagModel, "EOS 80D"),
+		exifShort(exifTagOrientation, orientation),
+		exifLong(exifTagExifIFD, 0),
+		exifLong(exifTagGPSIFD, 0),
+		exifASCII(exifTagCameraSerialNumber, "CAM-SN-12345"),
+	}
+	exifOffset := 8 + testIFDSize(ifd0)
+	gpsOffset := exifOffset + testIFDSize(exifIFD)
+	ifd0[3] = exifLong(exifTagExifIFD, uint32(exifOffset))
+	ifd0[4] = exifLong(exifTagGPSIFD, uint32(gpsOffset))
+
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	tiff = appendTestIFD(tiff, ifd0)
+	tiff = appendTestIFD(tiff, exifIFD)
+	tiff = appendTestIFD(tiff, gpsIFD)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	encoded := buf.Bytes()
+
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+6+len(tiff)))
+	app1 = append(app1, "Exif\x00\x00"...)
+	app1 = append(app1, tiff...)
+
+	data := append([]byte{}, encoded[:2]...)
+	data = append(data, app1...)
+	return append(data, encoded[2:]...)
+}
+
+func TestParseExif(t *testing.T) {
+	exif, err := ParseExif(testExifJPEG(t, 8, 4, 1))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Canon", exif.Make)
+	assert.Equal(t, "EOS 80D", exif.Model)
+	assert.Equal(t, "EF50mm f/1.8", exif.LensModel)
+	assert.Equal(t, "1/125", exif.ExposureTime)
+	assert.Equal(t, 2.8, exif.FNumber)
+	assert.Equal(t, 200, exif.ISO)
+	assert.Equal(t, 50.0, exif.FocalLength)
+	assert.Equal(t, 1, exif.Orientation)
+	assert.Equal(t, time.Date(2023, 4, 1, 10, 30, 0, 0, time.UTC), *exif.TakenAt)
+	assert.InDelta(t, -6.175, *exif.Latitude, 1e-9)
+	assert.InDelta(t, 106.816667, *exif.Longitude, 1e-6)
+
+	_, err = ParseExif([]byte("\x89PNG\r\n\x1a\n"))
+	assert.ErrorIs(t, err, ErrNoExif)
+}
+
+func TestCleanJPEGStripsPrivateTags(t *testing.T) {
+	data := testExifJPEG(t, 8, 4, 1)
+	exif, err := ParseExif(data)
+	assert.NoError(t, err)
+
+	clean, err := CleanJPEG(data, exif, false)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(clean, []byte("BODY-SN-999")))
+	assert.False(t, bytes.Contains(clean, []byte("CAM-SN-12345")))
+
+	cleaned, err := ParseExif(clean)
+	assert.NoError(t, err)
+	assert.Nil(t, cleaned.Latitude)
+	assert.Nil(t, cleaned.Longitude)
+	assert.Equal(t, "Canon", cleaned.Make)
+	assert.Equal(t, 200, cleaned.ISO)
+
+	_, err = jpeg.Decode(bytes.NewReader(clean))
+	assert.NoError(t, err)
+
+	kept, err := CleanJPEG(data, exif, true)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(kept, []byte("CAM-SN-12345")))
+	keptExif, err := ParseExif(kept)
+	assert.NoError(t, err)
+	assert.InDelta(t, -6.175, *keptExif.Latitude, 1e-9)
+}
+
+func TestCleanJPEGRotates(t *testing.T) {
+	data := testExifJPEG(t, 8, 4, 6)
+	exif, err := ParseExif(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, exif.Orientation)
+
+	clean, err := CleanJPEG(data, exif, true)
+	assert.NoError(t, err)
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(clean))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Pt(4, 8), image.Pt(config.Width, config.Height))
+
+	cleaned, err := ParseExif(clean)
+	assert.NoError(t, err, "the kept fields are put back after rotating")
+	assert.Equal(t, 1, cleaned.Orientation)
+	assert.Equal(t, "Canon", cleaned.Make)
+	assert.InDelta(t, -6.175, *cleaned.Latitude, 1e-9)
+	assert.False(t, bytes.Contains(clean, []byte("CAM-SN-12345")))
+
+	clean, err = CleanJPEG(data, exif, false)
+	assert.NoError(t, err)
+	cleaned, err = ParseExif(clean)
+	assert.NoError(t, err)
+	assert.Nil(t, cleaned.Latitude)
+}
+
+// withSegment inserts a segment with the given marker and payload after the
+// start of a JPEG.
+func withSegment(data []byte, marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	segment = append(segment, payload...)
+
+	withSegment := append([]byte{}, data[:2]...)
+	withSegment = append(withSegment, segment...)
+	return append(withSegment, data[2:]...)
+}
+
+func TestStripPrivateExifSegments(t *testing.T) {
+	data := testExifJPEG(t, 8, 4, 1)
+	second := testExifJPEG(t, 8, 4, 1)
+	data = withSegment(data, 0xE1, second[6:6+int(binary.BigEndian.Uint16(second[4:]))-2])
+	data = withSegment(data, 0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>XMP-SECRET</x:xmpmeta>"))
+	data = withSegment(data, 0xED, []byte("Photoshop 3.0\x00IPTC-SECRET"))
+	assert.Equal(t, 2, bytes.Count(data, []byte("CAM-SN-12345")))
+
+	clean, err := StripPrivateExif(data, false)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(clean, []byte("CAM-SN-12345")), "every EXIF segment is cleaned")
+	assert.False(t, bytes.Contains(clean, []byte("XMP-SECRET")))
+	assert.False(t, bytes.Contains(clean, []byte("IPTC-SECRET")))
+	assert.Equal(t, 2, bytes.Count(clean, []byte("Canon")))
+
+	_, err = jpeg.Decode(bytes.NewReader(clean))
+	assert.NoError(t, err)
+}
+
+func TestStripPrivateExifDropsTrailer(t *testing.T) {
+	data := testExifJPEG(t, 8, 4, 1)
+	data = append(data, "MotionPhoto_Data\x00MOTION-SECRET"...)
+
+	clean, err := StripPrivateExif(data, false)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(clean, []byte("MOTION-SECRET")))
+	assert.True(t, bytes.HasSuffix(clean, []byte{0xFF, jpegMarkerEOI}))
+
+	_, err = jpeg.Decode(bytes.NewReader(clean))
+	assert.NoError(t, err)
+
+	// Without an end of image, what follows the image cannot be told apart.
+	_, err = StripPrivateExif(data[:bytes.LastIndex(data, []byte{0xFF, jpegMarkerEOI})], false)
+	assert.ErrorIs(t, err, ErrExifInvalid)
+}
+
+// testThumbnailJPEG returns a JPEG whose EXIF block has a thumbnail in IFD1.
+func testThumbnailJPEG(t *testing.T) []byte {
+	thumbnail := []byte("THUMBNAIL-SECRET")
+
+	ifd0 := []testExifEntry{exifASCII(exifTagMake, "Canon")}
+	ifd1 := []testExifEntry{exifLong(exifTagThumbnailOffset, 0), exifLong(exifTagThumbnailLength, uint32(len(thumbnail)))}
+	ifd1Offset := 8 + testIFDSize(ifd0)
+	ifd1[0] = exifLong(exifTagThumbnailOffset, uint32(ifd1Offset+testIFDSize(ifd1)))
+
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	tiff = appendTestIFD(tiff, ifd0)
+	binary.LittleEndian.PutUint32(tiff[8+2+12*len(ifd0):], uint32(ifd1Offset))
+	tiff = appendTestIFD(tiff, ifd1)
+	tiff = append(tiff, thumbnail...)
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+
+	return withSegment(buf.Bytes(), jpegMarkerAPP1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func TestStripPrivateExifDropsThumbnail(t *testing.T) {
+	data := testThumbnailJPEG(t)
+	assert.True(t, bytes.Contains(data, []byte("THUMBNAIL-SECRET")))
+
+	clean, err := StripPrivateExif(data, false)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(clean, []byte("THUMBNAIL-SECRET")))
+
+	tiff, err := findExif(clean)
+	assert.NoError(t, err)
+	r, ifd0, err := newExifReader(tiff)
+	assert.NoError(t, err)
+	entries, ok := r.ifd(ifd0)
+	assert.True(t, ok)
+	ifd1, _ := r.uint32At(ifd0 + 2 + 12*len(entries))
+	assert.Zero(t, ifd1, "IFD1 is unlinked")
+
+	exif, err := ParseExif(clean)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canon", exif.Make)
+}
+
+func TestApplyOrientation(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	marker := color.RGBA{255, 0, 0, 255}
+	src.Set(0, 0, marker)
+
+	var testCases = []struct {
+		orientation int
+		size        image.Point
+		marker      image.Point
+	}{
+		{1, image.Pt(3, 2), image.Pt(0, 0)},
+		{2, image.Pt(3, 2), image.Pt(2, 0)},
+		{3, image.Pt(3, 2), image.Pt(2, 1)},
+		{4, image.Pt(3, 2), image.Pt(0, 1)},
+		{5, image.Pt(2, 3), image.Pt(0, 0)},
+		{6, image.Pt(2, 3), image.Pt(1, 0)},
+		{7, image.Pt(2, 3), image.Pt(1, 2)},
+		{8, image.Pt(2, 3), image.Pt(0, 2)},
+	}
+
+	for _, testCase := range testCases {
+		dst := ApplyOrientation(src, testCase.orientation)
+		assert.Equal(t, testCase.size, dst.Bounds().Size(), testCase.orientation)
+		assert.Equal(t, marker, color.RGBAModel.Convert(dst.At(testCase.marker.X, testCase.marker.Y)), testCase.orientation)
+	}
+}
@@ -0,0 +1,69 @@
+package helpers
+
+import "math"
+
+// EarthRadiusKm is the mean radius of the Earth.
+const EarthRadiusKm = 6371.0
+
+// GeoBox is a latitude/longitude bounding box. When MinLng is greater than
+// MaxLng the box crosses the antimeridian and covers the longitudes outside
+// of [MaxLng, MinLng].
+type GeoBox struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+// ValidCoordinates reports whether lat and lng are a position on Earth.
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// HaversineKm returns the great-circle distance between two positions in
+// kilometres.
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox returns a box holding every position within radiusKm of lat,
+// lng. It is a cheap prefilter that an index can serve; the exact distance
+// still has to be checked with HaversineKm.
+func BoundingBox(lat, lng, radiusKm float64) GeoBox {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	box := GeoBox{MinLat: lat - dLat, MaxLat: lat + dLat, MinLng: -180, MaxLng: 180}
+
+	// Near a pole every longitude is within reach.
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	dLng := math.Asin(math.Sin(radiusKm/EarthRadiusKm)/math.Cos(lat*math.Pi/180)) * 180 / math.Pi
+	box.MinLng = lng - dLng
+	box.MaxLng = lng + dLng
+	if box.MinLng < -180 {
+		box.MinLng += 360
+	}
+	if box.MaxLng > 180 {
+		box.MaxLng -= 360
+	}
+
+	return box
+}
+
+// Contains reports whether lat, lng lies in the box.
+func (box GeoBox) Contains(lat, lng float64) bool {
+	if lat < box.MinLat || lat > box.MaxLat {
+		return false
+	}
+	if box.MinLng > box.MaxLng {
+		return lng >= box.MinLng || lng <= box.MaxLng
+	}
+	return lng >= box.MinLng && lng <= box.MaxLng
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// Jakarta to Bandung.
+	assert.InDelta(t, 116.5, HaversineKm(-6.2088, 106.8456, -6.9175, 107.6191), 1)
+	assert.Equal(t, 0.0, HaversineKm(-8.65, 115.2167, -8.65, 115.2167))
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := BoundingBox(-6.2088, 106.8456, 10)
+	assert.True(t, box.Contains(-6.2088, 106.8456))
+	assert.True(t, box.Contains(-6.25, 106.9))
+	assert.False(t, box.Contains(-6.9175, 107.6191))
+
+	// Every position within the radius has to be inside the box.
+	for _, bearing := range [][2]float64{{0.0899, 0}, {-0.0899, 0}, {0, 0.0904}, {0, -0.0904}} {
+		lat, lng := -6.2088+bearing[0], 106.8456+bearing[1]
+		if HaversineKm(-6.2088, 106.8456, lat, lng) <= 10 {
+			assert.True(t, box.Contains(lat, lng), "%v", bearing)
+		}
+	}
+
+	wrapped := BoundingBox(0, 179.95, 20)
+	assert.Greater(t, wrapped.MinLng, wrapped.MaxLng)
+	assert.True(t, wrapped.Contains(0, -179.95))
+	assert.False(t, wrapped.Contains(0, 0))
+
+	polar := BoundingBox(89.95, 0, 20)
+	assert.Equal(t, 90.0, polar.MaxLat)
+	assert.True(t, polar.Contains(89.9, 120))
+
+	assert.True(t, ValidCoordinates(-90, 180))
+	assert.False(t, ValidCoordinates(91, 0))
+	assert.False(t, ValidCoordinates(0, -181))
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxHashtagLength is the longest tag, in runes, that is kept. Longer
+// hashtags are ignored instead of being truncated into a different tag.
+const MaxHashtagLength = 64
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r)
+}
+
+// NormalizeHashtag returns the stored form of a tag: NFC normalised, lower
+// case and without a leading '#'. It returns an empty string when the tag is
+// not valid.
+func NormalizeHashtag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	tag = strings.ToLower(norm.NFC.String(tag))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return ""
+	}
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return ""
+		}
+	}
+
+	return tag
+}
+
+// ExtractHashtags returns the normalised, de-duplicated hashtags of text in
+// the order they first appear. A '#' only starts a tag at the beginning of the
+// text or after a rune that cannot be part of a tag, so URL fragments such as
+// "example.com/#top" and "abc#def" are skipped.
+func ExtractHashtags(text string) []string {
+	var (
+		tags = []string{}
+		seen = map[string]bool{}
+		prev rune
+	)
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r != '#' || (i > 0 && (isHashtagRune(prev) || prev == '/' || prev == '&' || prev == '#')) {
+			prev = r
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && isHashtagRune(runes[j]) {
+			j++
+		}
+
+		tag := NormalizeHashtag(string(runes[i+1 : j]))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+
+		prev = runes[j-1]
+		i = j - 1
+	}
+
+	return tags
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	var testCases = []struct {
+		name    string
+		caption string
+		expect  []string
+	}{
+		{
+			name:    "no tags",
+			caption: "Liburan ke bali",
+			expect:  []string{},
+		},
+		{
+			name:    "lower case and de-duplicated",
+			caption: "#Bali #bali #BALI sunset #beach",
+			expect:  []string{"bali", "beach"},
+		},
+		{
+			name:    "punctuation ends a tag",
+			caption: "Sunset at #kuta, with #friends!",
+			expect:  []string{"kuta", "friends"},
+		},
+		{
+			name:    "url fragments and mid-word hashes are skipped",
+			caption: "see https://example.com/#top and abc#def or ##double",
+			expect:  []string{},
+		},
+		{
+			name:    "unicode letters",
+			caption: "#Café #日本",
+			expect:  []string{"café", "日本"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expect, ExtractHashtags(testCase.caption), testCase.name)
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	assert.Equal(t, "bali", NormalizeHashtag("#Bali"))
+	assert.Equal(t, "", NormalizeHashtag("#"))
+	assert.Equal(t, "", NormalizeHashtag("not a tag"))
+}
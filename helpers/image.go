@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoding
+	_ "image/jpeg" // register JPEG decoding
+	_ "image/png"  // register PNG decoding
+	"mini-project-alterra/configs"
+
+	_ "golang.org/x/image/webp" // register WebP decoding
+)
+
+var (
+	ErrImageTooLarge    = errors.New("image file is too large")
+	ErrImageUnsupported = errors.New("unsupported image format, please upload a JPEG, PNG, GIF or WebP image")
+	ErrImageInvalid     = errors.New("file is not a valid image")
+	ErrImageDimensions  = errors.New("image dimensions are too large")
+)
+
+// ImageLimits bounds what an upload may contain. MaxPixels guards against
+// decompression bombs: small files that decode to huge bitmaps.
+type ImageLimits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+}
+
+var DefaultImageLimits = ImageLimits{
+	MaxBytes:  10 << 20,
+	MaxWidth:  8000,
+	MaxHeight: 8000,
+	MaxPixels: 40000000,
+}
+
+// ImageLimitsFromEnv returns the limits configured by the IMAGE_MAX_*
+// environment variables.
+func ImageLimitsFromEnv() ImageLimits {
+	return ImageLimits{
+		MaxBytes:  configs.EnvImageMaxBytes(),
+		MaxWidth:  configs.EnvImageMaxWidth(),
+		MaxHeight: configs.EnvImageMaxHeight(),
+		MaxPixels: configs.EnvImageMaxPixels(),
+	}
+}
+
+type ImageInfo struct {
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+var imageContentTypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+}
+
+// SniffImageFormat identifies an image by its magic bytes, returning "" for
+// anything that is not JPEG, PNG, GIF or WebP.
+func SniffImageFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		return "jpeg"
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "gif"
+	case len(header) >= 12 && bytes.Equal(header[:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return "webp"
+	}
+	return ""
+}
+
+// ValidateImage checks data against limits by its magic bytes and decoded
+// header. The pixels themselves are not decoded.
+func ValidateImage(data []byte, limits ImageLimits) (ImageInfo, error) {
+	var info ImageInfo
+
+	if limits.MaxBytes > 0 && int64(len(data)) > limits.MaxBytes {
+		return info, fmt.Errorf("%w: the limit is %d bytes", ErrImageTooLarge, limits.MaxBytes)
+	}
+
+	format := SniffImageFormat(data)
+	if format == "" {
+		return info, ErrImageUnsupported
+	}
+
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return info, fmt.Errorf("%w: cannot read %s header", ErrImageInvalid, format)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return info, fmt.Errorf("%w: %s has no pixels", ErrImageInvalid, format)
+	}
+
+	if (limits.MaxWidth > 0 && config.Width > limits.MaxWidth) || (limits.MaxHeight > 0 && config.Height > limits.MaxHeight) {
+		return info, fmt.Errorf("%w: %dx%d exceeds %dx%d", ErrImageDimensions, config.Width, config.Height, limits.MaxWidth, limits.MaxHeight)
+	}
+	if limits.MaxPixels > 0 && int64(config.Width)*int64(config.Height) > limits.MaxPixels {
+		return info, fmt.Errorf("%w: %d pixels exceeds %d", ErrImageDimensions, int64(config.Width)*int64(config.Height), limits.MaxPixels)
+	}
+
+	info.Format = format
+	info.ContentType = imageContentTypes[format]
+	info.Width = config.Width
+	info.Height = config.Height
+
+	return info, nil
+}
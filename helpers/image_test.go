@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeTestImage(t *testing.T, format string, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	assert.NoError(t, err)
+
+	return buf.Bytes()
+}
+
+// pngBomb returns a PNG header claiming a width x height image, without any
+// pixel data.
+func pngBomb(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 2 // truecolor
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	buf.WriteString("IHDR")
+	buf.Write(ihdr)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte("IHDR"), ihdr...)))
+
+	return buf.Bytes()
+}
+
+// webpLossless returns the header of a 2x3 lossless WebP image.
+func webpLossless() []byte {
+	return []byte("RIFF\x12\x00\x00\x00WEBPVP8L\x05\x00\x00\x00\x2f\x01\x80\x00\x00\x00")
+}
+
+func TestValidateImageFormats(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		data        []byte
+		contentType string
+		width       int
+		height      int
+	}{
+		{"jpeg", encodeTestImage(t, "jpeg", 4, 3), "image/jpeg", 4, 3},
+		{"png", encodeTestImage(t, "png", 5, 2), "image/png", 5, 2},
+		{"gif", encodeTestImage(t, "gif", 1, 1), "image/gif", 1, 1},
+		{"webp", webpLossless(), "image/webp", 2, 3},
+	}
+
+	for _, testCase := range testCases {
+		info, err := ValidateImage(testCase.data, DefaultImageLimits)
+		if assert.NoError(t, err, testCase.name) {
+			assert.Equal(t, testCase.contentType, info.ContentType, testCase.name)
+			assert.Equal(t, testCase.width, info.Width, testCase.name)
+			assert.Equal(t, testCase.height, info.Height, testCase.name)
+		}
+	}
+}
+
+func TestValidateImageErrors(t *testing.T) {
+	png := encodeTestImage(t, "png", 10, 10)
+
+	var testCases = []struct {
+		name   string
+		data   []byte
+		limits ImageLimits
+		expect error
+	}{
+		{"executable", []byte("MZ\x90\x00 this is not an image"), DefaultImageLimits, ErrImageUnsupported},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), DefaultImageLimits, ErrImageUnsupported},
+		{"truncated jpeg", []byte("\xff\xd8\xff\xe0"), DefaultImageLimits, ErrImageInvalid},
+		{"too many bytes", png, ImageLimits{MaxBytes: 10}, ErrImageTooLarge},
+		{"too wide", png, ImageLimits{MaxWidth: 5, MaxHeight: 100}, ErrImageDimensions},
+		{"decompression bomb", pngBomb(50000, 50000), DefaultImageLimits, ErrImageDimensions},
+		{"too many pixels", pngBomb(7000, 7000), DefaultImageLimits, ErrImageDimensions},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ValidateImage(testCase.data, testCase.limits)
+		assert.ErrorIs(t, err, testCase.expect, testCase.name)
+	}
+}
@@ -0,0 +1,254 @@
+package helpers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"mini-project-alterra/models"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalMediaStore keeps media on the local disk. The files are served by
+// Pixelfeed itself below BaseURL.
+type LocalMediaStore struct {
+	Root       string
+	BaseURL    string
+	signingKey []byte
+}
+
+func NewLocalMediaStore(root, baseURL, signingKey string) (*LocalMediaStore, error) {
+	if signingKey == "" {
+		return nil, errors.New("local storage needs STORAGE_SIGNING_KEY or SECRET_JWT")
+	}
+
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalMediaStore{Root: root, BaseURL: strings.TrimSuffix(baseURL, "/"), signingKey: []byte(signingKey)}, nil
+}
+
+// Path returns the file path of key, refusing keys that would escape Root.
+func (s *LocalMediaStore) Path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", errors.New("invalid media key")
+	}
+
+	return filepath.Join(s.Root, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalMediaStore) url(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+func (s *LocalMediaStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (models.MediaObject, error) {
+	var object models.MediaObject
+
+	name, err := s.Path(key)
+	if err != nil {
+		return object, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return object, err
+	}
+
+	// Write to a temporary file first so readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return object, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return object, err
+	}
+
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return object, err
+	}
+
+	object.Key = key
+	object.URL = s.url(key)
+	object.Size = written
+	object.ContentType = contentType
+	object.UpdatedAt = time.Now()
+
+	return object, nil
+}
+
+func (s *LocalMediaStore) Delete(ctx context.Context, key string) error {
+	name, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalMediaStore) Stat(ctx context.Context, key string) (models.MediaObject, error) {
+	var object models.MediaObject
+
+	name, err := s.Path(key)
+	if err != nil {
+		return object, err
+	}
+
+	info, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return object, ErrMediaNotFound
+	}
+	if err != nil {
+		return object, err
+	}
+
+	object.Key = key
+	object.URL = s.url(key)
+	object.Size = info.Size()
+	object.ContentType = mime.TypeByExtension(filepath.Ext(name))
+	object.UpdatedAt = info.ModTime()
+
+	return object, nil
+}
+
+func (s *LocalMediaStore) List(ctx context.Context, prefix string, fn func(models.MediaObject) error) error {
+	dir := filepath.Join(s.Root, filepath.FromSlash(path.Dir("/"+prefix+"x")))
+
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		return fn(models.MediaObject{
+			Key:         key,
+			URL:         s.url(key),
+			Size:        info.Size(),
+			ContentType: mime.TypeByExtension(filepath.Ext(name)),
+			UpdatedAt:   info.ModTime(),
+		})
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalMediaStore) sign(method, key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(method + "\n" + key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL returns a URL for key that stops working after expiry.
+func (s *LocalMediaStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	_, err := s.Path(key)
+	if err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(expiry).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign("GET", key, expires))
+
+	return s.url(key) + "?" + query.Encode(), nil
+}
+
+// SignMediaURL signs mediaURL if it points at a file of the store, so it can
+// be shown in a response. The signature expires at the end of the window
+// after the current one, so the URL of a file stays the same, and cacheable,
+// for a whole window. Other URLs are returned unchanged.
+func (s *LocalMediaStore) SignMediaURL(mediaURL string, window time.Duration) string {
+	key := strings.TrimPrefix(mediaURL, s.BaseURL+"/")
+	if key == mediaURL || strings.Contains(key, "?") {
+		return mediaURL
+	}
+	if _, err := s.Path(key); err != nil {
+		return mediaURL
+	}
+
+	expires := time.Now().Truncate(window).Add(2 * window).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign("GET", key, expires))
+
+	return s.url(key) + "?" + query.Encode()
+}
+
+// PresignUpload returns a form for a PUT request to the URL of key, which
+// Pixelfeed serves itself. The size limit is enforced by that handler.
+func (s *LocalMediaStore) PresignUpload(ctx context.Context, key, contentType string, maxSize int64, expiry time.Duration) (models.UploadForm, error) {
+	_, err := s.Path(key)
+	if err != nil {
+		return models.UploadForm{}, err
+	}
+
+	expires := time.Now().Add(expiry)
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", s.sign("PUT", key, expires.Unix()))
+
+	return models.UploadForm{
+		Method:    "PUT",
+		URL:       s.url(key) + "?" + query.Encode(),
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expires,
+	}, nil
+}
+
+// VerifySignature reports whether signature was issued by SignedURL or
+// PresignUpload for method and key and has not expired yet.
+func (s *LocalMediaStore) VerifySignature(method, key, expires, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(s.sign(method, key, expiresAt)))
+}
@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	"context"
+	"mini-project-alterra/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalMediaStore(t *testing.T) {
+	store, err := NewLocalMediaStore(t.TempDir(), "/media/", "secret")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	object, err := store.Put(ctx, "photos/a.jpg", strings.NewReader("jpeg data"), 9, "image/jpeg")
+	assert.NoError(t, err)
+	assert.Equal(t, "/media/photos/a.jpg", object.URL)
+	assert.Equal(t, int64(9), object.Size)
+
+	object, err = store.Stat(ctx, "photos/a.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), object.Size)
+	assert.Equal(t, "image/jpeg", object.ContentType)
+
+	assert.NoError(t, store.Delete(ctx, "photos/a.jpg"))
+	_, err = store.Stat(ctx, "photos/a.jpg")
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+	assert.NoError(t, store.Delete(ctx, "photos/a.jpg"))
+}
+
+func TestLocalMediaStoreList(t *testing.T) {
+	store, err := NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	for _, key := range []string{"photos/a.jpg", "photos/b_thumb.jpg", "avatars/c.png"} {
+		_, err := store.Put(ctx, key, strings.NewReader("data"), 4, "image/jpeg")
+		assert.NoError(t, err)
+	}
+
+	var keys []string
+	err = store.List(ctx, "photos/", func(object models.MediaObject) error {
+		keys = append(keys, object.Key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"photos/a.jpg", "photos/b_thumb.jpg"}, keys)
+
+	assert.NoError(t, store.List(ctx, "missing/", func(models.MediaObject) error {
+		t.Error("unexpected object")
+		return nil
+	}))
+}
+
+func TestLocalMediaStoreRejectsTraversal(t *testing.T) {
+	store, err := NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "../etc/passwd", "photos/../../x", "/abs", "photos//a.jpg"} {
+		_, err := store.Path(key)
+		assert.Error(t, err, key)
+	}
+}
+
+func TestLocalMediaStoreSignedURL(t *testing.T) {
+	store, err := NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+
+	signed, err := store.SignedURL(context.Background(), "photos/a.jpg", time.Minute)
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "/media/photos/a.jpg", parsed.Path)
+
+	expires := parsed.Query().Get("expires")
+	signature := parsed.Query().Get("signature")
+	assert.True(t, store.VerifySignature(http.MethodGet, "photos/a.jpg", expires, signature))
+	assert.False(t, store.VerifySignature(http.MethodGet, "photos/b.jpg", expires, signature))
+	assert.False(t, store.VerifySignature(http.MethodPut, "photos/a.jpg", expires, signature))
+	assert.False(t, store.VerifySignature(http.MethodGet, "photos/a.jpg", "1", signature))
+}
+
+func TestLocalMediaStorePresignUpload(t *testing.T) {
+	store, err := NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+
+	form, err := store.PresignUpload(context.Background(), "photos/a.png", "image/png", 1024, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, form.Method)
+	assert.Equal(t, "image/png", form.Headers["Content-Type"])
+
+	parsed, err := url.Parse(form.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/media/photos/a.png", parsed.Path)
+
+	expires := parsed.Query().Get("expires")
+	signature := parsed.Query().Get("signature")
+	assert.True(t, store.VerifySignature(http.MethodPut, "photos/a.png", expires, signature))
+	assert.False(t, store.VerifySignature(http.MethodGet, "photos/a.png", expires, signature), "upload URLs cannot be used to read")
+}
+
+func TestLocalMediaStoreSignMediaURL(t *testing.T) {
+	store, err := NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "https://cdn.example.com/a.jpg", store.SignMediaURL("https://cdn.example.com/a.jpg", time.Hour))
+	assert.Equal(t, "/media/../a.jpg", store.SignMediaURL("/media/../a.jpg", time.Hour))
+
+	signed := store.SignMediaURL("/media/photos/a.jpg", time.Hour)
+	assert.Equal(t, signed, store.SignMediaURL(signed, time.Hour), "signed URLs are not signed again")
+
+	parsed, err := url.Parse(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "/media/photos/a.jpg", parsed.Path)
+	assert.True(t, store.VerifySignature(http.MethodGet, "photos/a.jpg", parsed.Query().Get("expires"), parsed.Query().Get("signature")))
+}
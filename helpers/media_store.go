@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mini-project-alterra/configs"
+	"mini-project-alterra/models"
+	"time"
+)
+
+var ErrMediaNotFound = errors.New("media not found")
+
+// MediaStore stores uploaded media under a key chosen by the caller, such as
+// "photos/3f2a9c.jpg".
+type MediaStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (models.MediaObject, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	Stat(ctx context.Context, key string) (models.MediaObject, error)
+	// List calls fn for every object whose key starts with prefix, stopping
+	// at the first error fn returns.
+	List(ctx context.Context, prefix string, fn func(models.MediaObject) error) error
+}
+
+// UploadPresigner is implemented by stores that let clients upload a file
+// straight to the store, without passing it through Pixelfeed. The form
+// only accepts a file of contentType stored under key, until expiry.
+type UploadPresigner interface {
+	PresignUpload(ctx context.Context, key, contentType string, maxSize int64, expiry time.Duration) (models.UploadForm, error)
+}
+
+// NewMediaStore returns the MediaStore for backend, configured from the
+// environment.
+func NewMediaStore(backend string) (MediaStore, error) {
+	switch backend {
+	case "local":
+		return NewLocalMediaStore(configs.EnvStorageLocalDir(), configs.EnvStorageLocalBaseURL(), configs.EnvStorageSigningKey())
+	case "s3":
+		return NewS3MediaStore(configs.EnvS3Endpoint(), configs.EnvS3Region(), configs.EnvS3Bucket(),
+			configs.EnvS3AccessKey(), configs.EnvS3SecretKey(), configs.EnvS3PublicURL())
+	case "cloudinary":
+		return NewCloudinaryMediaStore(configs.EnvCloudName(), configs.EnvCloudAPIKey(), configs.EnvCloudAPISecret(), configs.EnvCloudUploadFolder())
+	}
+
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// SupportedImageType reports whether contentType is one of the image types
+// Pixelfeed accepts.
+func SupportedImageType(contentType string) bool {
+	_, ok := mediaExtensions[contentType]
+	return ok
+}
+
+// NewMediaKey returns a random key below prefix with the file extension of
+// contentType.
+func NewMediaKey(prefix, contentType string) (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + "/" + hex.EncodeToString(buf) + mediaExtensions[contentType], nil
+}
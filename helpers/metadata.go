@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"mini-project-alterra/models"
+)
+
+// pngMetadataChunks are the PNG chunks that can carry EXIF, XMP or free
+// text, such as the name of the author.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+}
+
+// webpMetadataChunks are the WebP chunks holding EXIF and XMP, and the
+// flags VP8X sets for them.
+var webpMetadataChunks = map[string]byte{
+	"EXIF": 0x08,
+	"XMP ": 0x04,
+}
+
+// CleanImage prepares an image of the given format for storage. JPEGs are
+// turned upright and keep their public EXIF fields, and the location when
+// keepLocation is set; the metadata of other images is removed. It fails
+// closed: JPEGs whose metadata cannot be read are re-encoded without any,
+// and other images are rejected. The public fields of JPEGs are returned.
+func CleanImage(data []byte, format string, keepLocation bool) ([]byte, *models.PhotoMetadata, error) {
+	switch format {
+	case "jpeg":
+		exif, err := ParseExif(data)
+		switch {
+		case err == nil:
+			clean, err := CleanJPEG(data, exif, keepLocation)
+			if err == nil {
+				return clean, exif.PhotoMetadata(keepLocation), nil
+			}
+		case errors.Is(err, ErrNoExif):
+			clean, err := StripPrivateExif(data, keepLocation)
+			if err == nil {
+				return clean, nil, nil
+			}
+		}
+
+		clean, err := ReencodeJPEG(data)
+		return clean, nil, err
+	case "png":
+		clean, err := StripPNGMetadata(data)
+		return clean, nil, err
+	case "webp":
+		clean, err := StripWebPMetadata(data)
+		return clean, nil, err
+	}
+
+	return data, nil, nil
+}
+
+// StripPNGMetadata returns a copy of a PNG without the chunks in
+// pngMetadataChunks or anything after its end.
+func StripPNGMetadata(data []byte) ([]byte, error) {
+	signature := []byte("\x89PNG\r\n\x1a\n")
+	if !bytes.HasPrefix(data, signature) {
+		return nil, fmt.Errorf("%w: not a PNG", ErrImageInvalid)
+	}
+
+	clean := append([]byte(nil), signature...)
+	for pos := len(signature); ; {
+		if pos+12 > len(data) {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrImageInvalid)
+		}
+		length := binary.BigEndian.Uint32(data[pos:])
+		if int64(length) > int64(len(data)-pos-12) {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrImageInvalid)
+		}
+		end := pos + 12 + int(length)
+
+		chunk := string(data[pos+4 : pos+8])
+		if !pngMetadataChunks[chunk] {
+			clean = append(clean, data[pos:end]...)
+		}
+		if chunk == "IEND" {
+			return clean, nil
+		}
+		pos = end
+	}
+}
+
+// StripWebPMetadata returns a copy of a WebP without its EXIF and XMP chunks
+// or anything after the RIFF container.
+func StripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], []byte("RIFF")) || !bytes.Equal(data[8:12], []byte("WEBP")) {
+		return nil, fmt.Errorf("%w: not a WebP", ErrImageInvalid)
+	}
+	size := binary.LittleEndian.Uint32(data[4:])
+	if int64(size) > int64(len(data)-8) {
+		return nil, fmt.Errorf("%w: truncated WebP", ErrImageInvalid)
+	}
+	riffEnd := 8 + int(size)
+
+	clean := append([]byte(nil), data[:12]...)
+	vp8x := -1
+	for pos := 12; pos < riffEnd; {
+		if pos+8 > riffEnd {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrImageInvalid)
+		}
+		length := binary.LittleEndian.Uint32(data[pos+4:])
+		if int64(length) > int64(riffEnd-pos-8) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrImageInvalid)
+		}
+		// Chunks are padded to an even length.
+		end := pos + 8 + int(length) + int(length&1)
+		if end > riffEnd {
+			end = riffEnd
+		}
+
+		chunk := string(data[pos : pos+4])
+		if _, ok := webpMetadataChunks[chunk]; !ok {
+			if chunk == "VP8X" {
+				vp8x = len(clean)
+			}
+			clean = append(clean, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	if vp8x >= 0 && vp8x+8 < len(clean) {
+		for _, flag := range webpMetadataChunks {
+			clean[vp8x+8] &^= flag
+		}
+	}
+	binary.LittleEndian.PutUint32(clean[4:], uint32(len(clean)-8))
+
+	return clean, nil
+}
@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanImageJPEG(t *testing.T) {
+	data := testExifJPEG(t, 8, 4, 1)
+
+	clean, metadata, err := CleanImage(data, "jpeg", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canon", metadata.CameraMake)
+	assert.Nil(t, metadata.Latitude)
+	assert.False(t, bytes.Contains(clean, []byte("CAM-SN-12345")))
+
+	// An EXIF segment whose TIFF header is broken cannot be cleaned in place.
+	broken := withSegment(encodeTestImage(t, "jpeg", 8, 4), 0xE1, []byte("Exif\x00\x00XX\x00\x00CAM-SN-12345"))
+	clean, metadata, err = CleanImage(broken, "jpeg", true)
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+	assert.False(t, bytes.Contains(clean, []byte("CAM-SN-12345")), "the image is re-encoded without metadata")
+	_, err = jpeg.Decode(bytes.NewReader(clean))
+	assert.NoError(t, err)
+}
+
+// withPNGChunk inserts a chunk after the IHDR chunk of a PNG. The checksum
+// is left empty, as the chunk is removed before anything reads it.
+func withPNGChunk(data []byte, chunk string, payload []byte) []byte {
+	ihdrEnd := 8 + 12 + 13
+
+	inserted := append([]byte{}, data[:ihdrEnd]...)
+	inserted = binary.BigEndian.AppendUint32(inserted, uint32(len(payload)))
+	inserted = append(inserted, chunk...)
+	inserted = append(inserted, payload...)
+	inserted = append(inserted, 0, 0, 0, 0)
+	return append(inserted, data[ihdrEnd:]...)
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	data := encodeTestImage(t, "png", 8, 4)
+	data = withPNGChunk(data, "tEXt", []byte("Author\x00Budi"))
+	data = withPNGChunk(data, "eXIf", []byte("MM\x00\x2aCAM-SN-12345"))
+	data = withPNGChunk(data, "iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00XMP-SECRET"))
+	data = append(data, "trailing"...)
+
+	clean, metadata, err := CleanImage(data, "png", true)
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+	for _, secret := range []string{"Budi", "CAM-SN-12345", "XMP-SECRET", "trailing"} {
+		assert.False(t, bytes.Contains(clean, []byte(secret)), secret)
+	}
+
+	info, err := ValidateImage(clean, ImageLimits{})
+	assert.NoError(t, err)
+	assert.Equal(t, "png", info.Format)
+
+	_, err = StripPNGMetadata(data[:40])
+	assert.ErrorIs(t, err, ErrImageInvalid)
+}
+
+func TestStripWebPMetadata(t *testing.T) {
+	vp8x := []byte("VP8X\x0a\x00\x00\x00\x0c\x00\x00\x00\x01\x00\x00\x02\x00\x00")
+	exif := []byte("EXIF\x0c\x00\x00\x00CAM-SN-12345")
+	xmp := []byte("XMP \x0b\x00\x00\x00XMP-SECRET\x00\x00")
+	image := webpLossless()[12:]
+
+	var chunks []byte
+	for _, chunk := range [][]byte{vp8x, image, exif, xmp} {
+		chunks = append(chunks, chunk...)
+	}
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	binary.LittleEndian.PutUint32(data[4:], uint32(4+len(chunks)))
+	data = append(data, chunks...)
+
+	clean, metadata, err := CleanImage(data, "webp", true)
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+	assert.False(t, bytes.Contains(clean, []byte("CAM-SN-12345")))
+	assert.False(t, bytes.Contains(clean, []byte("XMP-SECRET")))
+	assert.Equal(t, byte(0), clean[20], "the EXIF and XMP flags are cleared")
+	assert.Equal(t, uint32(len(clean)-8), binary.LittleEndian.Uint32(clean[4:]))
+
+	info, err := ValidateImage(clean, ImageLimits{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, info.Width)
+
+	_, err = StripWebPMetadata(data[:len(data)-4])
+	assert.ErrorIs(t, err, ErrImageInvalid)
+}
@@ -0,0 +1,168 @@
+package helpers
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// ImagePlaceholder is what clients show while an image loads.
+type ImagePlaceholder struct {
+	BlurHash      string
+	DominantColor string
+}
+
+// placeholderSize is the width images are scaled down to before their
+// placeholder is computed; the result is too blurry for more to matter.
+const placeholderSize = 32
+
+// BlurHash components along the width and height of an image.
+const (
+	blurHashX = 4
+	blurHashY = 3
+)
+
+// NewImagePlaceholder computes the BlurHash and dominant colour of img.
+// Transparent areas count as white, as in the variants.
+func NewImagePlaceholder(img image.Image) ImagePlaceholder {
+	small := ResizeVariant(img, VariantSpec{Size: placeholderSize}).(*image.RGBA)
+
+	return ImagePlaceholder{
+		BlurHash:      encodeBlurHash(small, blurHashX, blurHashY),
+		DominantColor: dominantColor(small),
+	}
+}
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func encodeBase83(value, length int) string {
+	var b strings.Builder
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		b.WriteByte(base83Chars[digit])
+	}
+	return b.String()
+}
+
+func sRGBToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
+
+// encodeBlurHash follows the reference encoder at
+// https://github.com/woltapp/blurhash.
+func encodeBlurHash(img *image.RGBA, xComponents, yComponents int) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var r, g, b float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					pixel := img.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+					r += basis * sRGBToLinear(pixel.R)
+					g += basis * sRGBToLinear(pixel.G)
+					b += basis * sRGBToLinear(pixel.B)
+				}
+			}
+
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	dc, ac := factors[0], factors[1:]
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maximumValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encodeBase83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+
+	for _, f := range ac {
+		quantise := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quantise(f[0])*19*19+quantise(f[1])*19+quantise(f[2]), 2))
+	}
+
+	return hash.String()
+}
+
+// dominantColor returns the average colour of the most common group of
+// similar colours in img as #rrggbb. Colours are grouped by the upper four
+// bits of every channel.
+func dominantColor(img *image.RGBA) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+
+	bounds := img.Bounds()
+	buckets := make(map[int]*bucket)
+	var best *bucket
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := img.RGBAAt(x, y)
+			key := int(pixel.R>>4)<<8 | int(pixel.G>>4)<<4 | int(pixel.B>>4)
+
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += int(pixel.R)
+			bk.g += int(pixel.G)
+			bk.b += int(pixel.B)
+
+			if best == nil || bk.count > best.count {
+				best = bk
+			}
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.count, best.g/best.count, best.b/best.count)
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePlaceholder(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+	placeholder := NewImagePlaceholder(img)
+	assert.Equal(t, "#ff0000", placeholder.DominantColor)
+	assert.Len(t, placeholder.BlurHash, 28)
+	assert.Equal(t, "L", placeholder.BlurHash[:1], "4x3 components")
+	assert.Equal(t, encodeBase83(0xff0000, 4), placeholder.BlurHash[2:6], "the average colour")
+
+	// The left two thirds are blue, the rest white.
+	draw.Draw(img, image.Rect(0, 0, 200, 200), image.NewUniform(color.RGBA{0, 0, 255, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 0, 300, 200), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	placeholder = NewImagePlaceholder(img)
+	assert.Equal(t, "#0000ff", placeholder.DominantColor)
+	assert.Len(t, placeholder.BlurHash, 28)
+	assert.NotEqual(t, NewImagePlaceholder(image.NewRGBA(image.Rect(0, 0, 300, 200))).BlurHash, placeholder.BlurHash)
+
+	transparent := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	assert.Equal(t, "#ffffff", NewImagePlaceholder(transparent).DominantColor, "transparency counts as white")
+}
+
+func TestEncodeBase83(t *testing.T) {
+	assert.Equal(t, "0", encodeBase83(0, 1))
+	assert.Equal(t, "~", encodeBase83(82, 1))
+	assert.Equal(t, "10", encodeBase83(83, 2))
+}
@@ -0,0 +1,207 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrRemoteFetch   = errors.New("could not fetch the photo URL")
+	ErrRemoteBlocked = errors.New("the photo URL is not allowed")
+)
+
+// blockedNetworks are ranges that are not reachable on the public internet,
+// on top of what the net.IP methods in publicIP catch.
+var blockedNetworks = parseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved, including broadcast
+	"64:ff9b::/96",    // NAT64, which can embed any IPv4 address
+	"64:ff9b:1::/48",  // local-use NAT64
+	"2001:db8::/32",   // documentation
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// publicIP reports whether ip is a public unicast address, as opposed to a
+// private, loopback, link-local or otherwise special one.
+func publicIP(ip net.IP) bool {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// RemoteFetcher downloads images from URLs given by users without letting
+// them reach the private network of the server. Only http and https are
+// followed, and every connection, including those of redirects, is checked
+// after DNS resolution, so a host cannot resolve to a public address when
+// checked and a private one when dialed.
+type RemoteFetcher struct {
+	MaxBytes     int64
+	MaxRedirects int
+	Timeout      time.Duration
+
+	allowIP func(ip net.IP) bool
+	client  *http.Client
+}
+
+// NewRemoteFetcher returns a RemoteFetcher accepting images of up to
+// maxBytes, following up to 3 redirects within 10 seconds.
+func NewRemoteFetcher(maxBytes int64) *RemoteFetcher {
+	f := &RemoteFetcher{
+		MaxBytes:     maxBytes,
+		MaxRedirects: 3,
+		Timeout:      10 * time.Second,
+		allowIP:      publicIP,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: f.control,
+	}
+	f.client = &http.Client{
+		Transport: &http.Transport{
+			// A proxy would dial on our behalf, past the address check.
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 5 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: f.checkRedirect,
+	}
+
+	return f
+}
+
+// control runs after DNS resolution, right before every connection.
+func (f *RemoteFetcher) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !f.allowIP(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrRemoteBlocked, host)
+	}
+
+	return nil
+}
+
+func (f *RemoteFetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.MaxRedirects {
+		return fmt.Errorf("%w: more than %d redirects", ErrRemoteFetch, f.MaxRedirects)
+	}
+	return checkScheme(req.URL)
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https URLs are supported", ErrRemoteBlocked)
+	}
+	return nil
+}
+
+// Fetch downloads rawURL and returns its body and content type. The
+// response must declare a supported image type and be no larger than
+// MaxBytes.
+func (f *RemoteFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrRemoteFetch, err)
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, "", err
+	}
+	if u.User != nil {
+		return nil, "", fmt.Errorf("%w: URLs with credentials are not supported", ErrRemoteBlocked)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrRemoteFetch, err)
+	}
+	req.Header.Set("Accept", "image/jpeg, image/png, image/gif, image/webp")
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrRemoteBlocked) || errors.Is(err, ErrRemoteFetch) {
+			return nil, "", unwrapURLError(err)
+		}
+		return nil, "", fmt.Errorf("%w: %v", ErrRemoteFetch, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: %s", ErrRemoteFetch, res.Status)
+	}
+
+	contentType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !SupportedImageType(contentType) {
+		return nil, "", fmt.Errorf("%w: the URL serves %q", ErrImageUnsupported, res.Header.Get("Content-Type"))
+	}
+
+	if res.ContentLength > f.MaxBytes {
+		return nil, "", fmt.Errorf("%w: the limit is %d bytes", ErrImageTooLarge, f.MaxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, f.MaxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrRemoteFetch, err)
+	}
+	if int64(len(data)) > f.MaxBytes {
+		return nil, "", fmt.Errorf("%w: the limit is %d bytes", ErrImageTooLarge, f.MaxBytes)
+	}
+
+	// The declared type has to match the content, so a server cannot pass
+	// off something else as an image.
+	if sniffed := http.DetectContentType(data); sniffed != contentType {
+		return nil, "", fmt.Errorf("%w: the URL declares %s but serves %s", ErrImageUnsupported, contentType, sniffed)
+	}
+
+	return data, contentType, nil
+}
+
+// unwrapURLError drops the *url.Error around err, which repeats the URL in
+// the message.
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+// VariantSpec describes a resized copy of an uploaded photo. Size is the
+// maximum width; Square variants are center-cropped to Size x Size.
+type VariantSpec struct {
+	Name   string
+	Size   int
+	Square bool
+}
+
+var PhotoVariantSpecs = []VariantSpec{
+	{Name: "thumb", Size: 150, Square: true},
+	{Name: "small", Size: 640},
+	{Name: "large", Size: 1080},
+}
+
+const variantJPEGQuality = 85
+
+// ResizeVariant returns src scaled down for spec. Images are never scaled up.
+func ResizeVariant(src image.Image, spec VariantSpec) image.Image {
+	bounds := src.Bounds()
+
+	if spec.Square {
+		side := bounds.Dx()
+		if bounds.Dy() < side {
+			side = bounds.Dy()
+		}
+		x := bounds.Min.X + (bounds.Dx()-side)/2
+		y := bounds.Min.Y + (bounds.Dy()-side)/2
+		bounds = image.Rect(x, y, x+side, y+side)
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > spec.Size {
+		height = height * spec.Size / width
+		width = spec.Size
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// JPEG has no alpha channel, so transparent areas become white.
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// EncodeVariant encodes a variant as JPEG.
+func EncodeVariant(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: variantJPEGQuality})
+
+	return buf.Bytes(), err
+}
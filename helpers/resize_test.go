@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeVariant(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		width  int
+		height int
+		spec   VariantSpec
+		expect image.Point
+	}{
+		{"square crop of landscape", 400, 200, VariantSpec{Size: 150, Square: true}, image.Pt(150, 150)},
+		{"square crop of small portrait", 100, 300, VariantSpec{Size: 150, Square: true}, image.Pt(100, 100)},
+		{"keeps aspect ratio", 2000, 1000, VariantSpec{Size: 640}, image.Pt(640, 320)},
+		{"never scales up", 300, 200, VariantSpec{Size: 1080}, image.Pt(300, 200)},
+		{"very wide", 5000, 2, VariantSpec{Size: 640}, image.Pt(640, 1)},
+	}
+
+	for _, testCase := range testCases {
+		src := image.NewRGBA(image.Rect(0, 0, testCase.width, testCase.height))
+		resized := ResizeVariant(src, testCase.spec)
+		assert.Equal(t, testCase.expect, resized.Bounds().Size(), testCase.name)
+	}
+}
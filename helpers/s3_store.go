@@ -0,0 +1,402 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mini-project-alterra/models"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// S3MediaStore talks to an S3 compatible server such as MinIO using path
+// style URLs (endpoint/bucket/key) and AWS Signature Version 4.
+type S3MediaStore struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+func NewS3MediaStore(endpoint, region, bucket, accessKey, secretKey, publicURL string) (*S3MediaStore, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	if bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("S3 storage needs S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+
+	if publicURL == "" {
+		publicURL = parsed.String() + "/" + bucket
+	}
+
+	return &S3MediaStore{
+		endpoint:  parsed,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// s3Escape percent-encodes s as required by SigV4, leaving '/' alone when
+// keepSlash is set.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *S3MediaStore) objectPath(key string) string {
+	return s.endpoint.Path + "/" + s.bucket + "/" + key
+}
+
+func (s *S3MediaStore) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request made of method, path, query and the
+// given headers, which must already be lower case.
+func (s *S3MediaStore) signature(now time.Time, method, path string, query url.Values, headers map[string]string, payloadHash string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		s3Escape(path, true),
+		s3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	return hex.EncodeToString(hmacSHA256(s.signingKey(now), stringToSign)), signedHeaders
+}
+
+func (s *S3MediaStore) signingKey(now time.Time) []byte {
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	return hmacSHA256(key, "aws4_request")
+}
+
+// do sends a signed request for key. body may be nil.
+func (s *S3MediaStore) do(ctx context.Context, method, key string, query url.Values, body []byte, contentType string) (*http.Response, error) {
+	now := time.Now().UTC()
+	path := s.objectPath(key)
+	payloadHash := sha256Hex(body)
+
+	headers := map[string]string{
+		"host":                 s.endpoint.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(s3TimeFormat),
+	}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+
+	signature, signedHeaders := s.signature(now, method, path, query, headers, payloadHash)
+
+	target := *s.endpoint
+	target.Path = path
+	target.RawPath = s3Escape(path, true)
+	target.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, s.scope(now), signedHeaders, signature))
+
+	return s.client.Do(req)
+}
+
+func s3Error(res *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3: %s: %s", res.Status, strings.TrimSpace(string(message)))
+}
+
+func (s *S3MediaStore) url(key string) string {
+	return s.publicURL + "/" + s3Escape(key, true)
+}
+
+func (s *S3MediaStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (models.MediaObject, error) {
+	var object models.MediaObject
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return object, err
+	}
+
+	res, err := s.do(ctx, http.MethodPut, key, url.Values{}, data, contentType)
+	if err != nil {
+		return object, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return object, s3Error(res)
+	}
+
+	object.Key = key
+	object.URL = s.url(key)
+	object.Size = int64(len(data))
+	object.ContentType = contentType
+	object.UpdatedAt = time.Now()
+
+	return object, nil
+}
+
+func (s *S3MediaStore) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, url.Values{}, nil, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return s3Error(res)
+	}
+	return nil
+}
+
+func (s *S3MediaStore) Stat(ctx context.Context, key string) (models.MediaObject, error) {
+	var object models.MediaObject
+
+	res, err := s.do(ctx, http.MethodHead, key, url.Values{}, nil, "")
+	if err != nil {
+		return object, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return object, ErrMediaNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return object, s3Error(res)
+	}
+
+	object.Key = key
+	object.URL = s.url(key)
+	object.Size, _ = strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64)
+	object.ContentType = res.Header.Get("Content-Type")
+	object.UpdatedAt, _ = http.ParseTime(res.Header.Get("Last-Modified"))
+
+	return object, nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3MediaStore) List(ctx context.Context, prefix string, fn func(models.MediaObject) error) error {
+	var token string
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		res, err := s.do(ctx, http.MethodGet, "", query, nil, "")
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			err = s3Error(res)
+			res.Body.Close()
+			return err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, content := range result.Contents {
+			err = fn(models.MediaObject{
+				Key:       content.Key,
+				URL:       s.url(content.Key),
+				Size:      content.Size,
+				UpdatedAt: content.LastModified,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// presign returns a query string signed URL for method on key.
+func (s *S3MediaStore) presign(method, key string, expiry time.Duration) string {
+	now := time.Now().UTC()
+	path := s.objectPath(key)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	signature, _ := s.signature(now, method, path, query, map[string]string{"host": s.endpoint.Host}, s3UnsignedPayload)
+	query.Set("X-Amz-Signature", signature)
+
+	target := *s.endpoint
+	target.Path = path
+	target.RawPath = s3Escape(path, true)
+	target.RawQuery = s3CanonicalQuery(query)
+
+	return target.String()
+}
+
+func (s *S3MediaStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.presign(http.MethodGet, key, expiry), nil
+}
+
+// PresignUpload returns a browser based POST upload form whose policy only
+// admits a file of contentType and at most maxSize bytes under key.
+func (s *S3MediaStore) PresignUpload(ctx context.Context, key, contentType string, maxSize int64, expiry time.Duration) (models.UploadForm, error) {
+	now := time.Now().UTC()
+	expires := now.Add(expiry)
+	credential := s.accessKey + "/" + s.scope(now)
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": expires.Format("2006-01-02T15:04:05.000Z"),
+		"conditions": []interface{}{
+			map[string]string{"bucket": s.bucket},
+			map[string]string{"key": key},
+			map[string]string{"Content-Type": contentType},
+			[]interface{}{"content-length-range", 1, maxSize},
+			map[string]string{"x-amz-algorithm": s3Algorithm},
+			map[string]string{"x-amz-credential": credential},
+			map[string]string{"x-amz-date": now.Format(s3TimeFormat)},
+		},
+	})
+	if err != nil {
+		return models.UploadForm{}, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(policy)
+
+	target := *s.endpoint
+	target.Path = s.endpoint.Path + "/" + s.bucket
+
+	return models.UploadForm{
+		Method: http.MethodPost,
+		URL:    target.String(),
+		Fields: map[string]string{
+			"key":              key,
+			"Content-Type":     contentType,
+			"policy":           encoded,
+			"x-amz-algorithm":  s3Algorithm,
+			"x-amz-credential": credential,
+			"x-amz-date":       now.Format(s3TimeFormat),
+			"x-amz-signature":  hex.EncodeToString(hmacSHA256(s.signingKey(now), encoded)),
+		},
+		ExpiresAt: expires,
+	}, nil
+}
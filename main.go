@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"mini-project-alterra/configs"
 	"mini-project-alterra/routes"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "mini-project-alterra/docs" // docs is generated by Swag CLI, you have to import it.
 
@@ -44,7 +51,27 @@ func main() {
 	}
 
 	e := echo.New()
-	routes.New(e, db)
+	shutdown := routes.New(e, db)
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
-	e.Logger.Fatal(e.Start(":8083"))
+
+	go func() {
+		err := e.Start(":8083")
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	// Stop taking requests on SIGINT or SIGTERM and let those in flight
+	// finish before the views counted in memory are written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = e.Shutdown(ctx)
+	if err != nil {
+		e.Logger.Error(err)
+	}
+	shutdown()
 }
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AlbumVisibilityPublic  = "public"
+	AlbumVisibilityPrivate = "private"
+)
+
+type Album struct {
+	gorm.Model
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	Visibility   string       `gorm:"size:16;default:public" json:"visibility"`
+	CoverPhotoID *uint        `json:"cover_photo_id"`
+	CoverPhoto   *Photo       `gorm:"foreignKey:CoverPhotoID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"cover_photo"`
+	UserID       int          `json:"users_id"`
+	User         User         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	Photos       []AlbumPhoto `gorm:"foreignKey:AlbumID" json:"photos"`
+}
+
+type AlbumPhoto struct {
+	AlbumID   uint      `gorm:"primaryKey" json:"albums_id"`
+	PhotoID   uint      `gorm:"primaryKey" json:"photos_id"`
+	Photo     Photo     `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photo"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AlbumInput struct {
+	Title        string `form:"title" json:"title" binding:"required" example:"Liburan"`
+	Description  string `form:"description" json:"description" example:"Foto-foto liburan ke bali"`
+	Visibility   string `form:"visibility" json:"visibility" example:"public"`
+	CoverPhotoID uint   `form:"cover_photo_id" json:"cover_photo_id" example:"1"`
+	PhotoIDs     []uint `form:"photo_ids" json:"photo_ids"`
+}
+
+type AlbumPhotoInput struct {
+	PhotoID uint `json:"photo_id" example:"1"`
+}
+
+type AlbumOrderInput struct {
+	PhotoIDs []uint `json:"photo_ids" example:"3,1,2"`
+}
+
+type AlbumResponse struct {
+	ID          int             `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Visibility  string          `json:"visibility"`
+	Cover       *PhotoResponses `json:"cover"`
+	PhotoCount  int             `json:"photo_count"`
+	Photos      []PhotoResponse `json:"photos"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	User        UserResponses   `json:"user"`
+}
+
+// AlbumCover returns the explicit cover photo of an album, falling back to
+// the first photo in album order when no cover has been chosen.
+func AlbumCover(album Album) *Photo {
+	if album.CoverPhoto != nil && album.CoverPhoto.ID != 0 {
+		return album.CoverPhoto
+	}
+	if len(album.Photos) > 0 {
+		return &album.Photos[0].Photo
+	}
+	return nil
+}
+
+func ParseAlbumToResponse(album Album) AlbumResponse {
+	photos := make([]Photo, 0, len(album.Photos))
+	for _, p := range album.Photos {
+		photos = append(photos, p.Photo)
+	}
+
+	response := AlbumResponse{
+		ID:          int(album.ID),
+		Title:       album.Title,
+		Description: album.Description,
+		Visibility:  album.Visibility,
+		PhotoCount:  len(album.Photos),
+		Photos:      ParsePhotoToResponseArray(photos),
+		CreatedAt:   album.CreatedAt,
+		UpdatedAt:   album.UpdatedAt,
+		User: UserResponses{
+			FullName: album.User.FullName,
+			Username: album.User.Username,
+			Email:    album.User.Email,
+		},
+	}
+
+	if cover := AlbumCover(album); cover != nil {
+		response.Cover = &PhotoResponses{
+			Title:    cover.Title,
+			Caption:  cover.Caption,
+			PhotoURL: SignedMediaURL(cover.PhotoURL),
+		}
+	}
+
+	return response
+}
+
+func ParseAlbumToResponseArray(albums []Album) []AlbumResponse {
+	responses := make([]AlbumResponse, 0, len(albums))
+
+	for _, s := range albums {
+		response := ParseAlbumToResponse(s)
+		responses = append(responses, response)
+	}
+
+	return responses
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Block records that BlockerID blocked BlockedID. Neither of them can follow
+// or tag the other while the block is in place.
+type Block struct {
+	BlockerID uint      `gorm:"primaryKey" json:"blocker_id"`
+	Blocker   User      `gorm:"foreignKey:BlockerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	BlockedID uint      `gorm:"primaryKey;index" json:"blocked_id"`
+	Blocked   User      `gorm:"foreignKey:BlockedID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
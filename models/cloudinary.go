@@ -3,9 +3,13 @@ package models
 import "mime/multipart"
 
 type File struct {
-	File multipart.File `json:"file,omitempty" validate:"required"`
+	File         multipart.File `json:"file,omitempty" validate:"required"`
+	KeepLocation bool           `json:"-"`
 }
 
 type Url struct {
-	Url string `json:"url,omitempty" validate:"required"`
+	Url          string `json:"url,omitempty" validate:"required"`
+	KeepLocation bool   `json:"-"`
+	// MaxBytes is the storage quota left to the user, if set.
+	MaxBytes int64 `json:"-"`
 }
@@ -12,7 +12,9 @@ type Comment struct {
 	Photo   Photo  `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photo"`
 	UserID  int    `json:"users_id"`
 	User    User   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
-	Message string `json:"message"`
+	Message string `gorm:"index:idx_comments_search,class:FULLTEXT" json:"message"`
+	// HiddenAt is set while the comment is hidden for too many reports.
+	HiddenAt *time.Time `gorm:"index" json:"-"`
 }
 
 type CommentInput struct {
@@ -50,7 +52,7 @@ func ParseCommentToResponse(comments Comment) CommentResponse {
 		Photo: PhotoResponses{
 			Title:    comments.Photo.Title,
 			Caption:  comments.Photo.Caption,
-			PhotoURL: comments.Photo.PhotoURL,
+			PhotoURL: SignedMediaURL(comments.Photo.PhotoURL),
 		},
 		User: UserResponses{
 			FullName: comments.User.FullName,
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Follow records that FollowerID follows FollowingID. Followers can see the
+// followers-only photos of the users they follow.
+type Follow struct {
+	FollowerID  uint      `gorm:"primaryKey" json:"follower_id"`
+	Follower    User      `gorm:"foreignKey:FollowerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	FollowingID uint      `gorm:"primaryKey;index" json:"following_id"`
+	Following   User      `gorm:"foreignKey:FollowingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// MediaObject describes a file stored in the media storage backend.
+type MediaObject struct {
+	Key           string                 `json:"key"`
+	URL           string                 `json:"url"`
+	Size          int64                  `json:"size"`
+	ContentType   string                 `json:"content_type"`
+	Width         int                    `json:"width,omitempty"`
+	Height        int                    `json:"height,omitempty"`
+	BlurHash      string                 `json:"blur_hash,omitempty"`
+	DominantColor string                 `json:"dominant_color,omitempty"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	Variants      map[string]MediaObject `json:"variants,omitempty"`
+	Metadata      *PhotoMetadata         `json:"metadata,omitempty"`
+}
+
+// mediaURLSigner signs the media URLs shown in responses, for storage
+// backends that only serve signed URLs. It is set once at startup.
+var mediaURLSigner func(url string) string
+
+// SetMediaURLSigner makes responses show media URLs as signed by signer.
+func SetMediaURLSigner(signer func(url string) string) {
+	mediaURLSigner = signer
+}
+
+// SignedMediaURL returns url the way clients may fetch it.
+func SignedMediaURL(url string) string {
+	if mediaURLSigner == nil || url == "" {
+		return url
+	}
+	return mediaURLSigner(url)
+}
+
+// StorageKeys returns the key of the object and of its variants.
+func (object MediaObject) StorageKeys() []string {
+	keys := make([]string, 0, len(object.Variants)+1)
+
+	if object.Key != "" {
+		keys = append(keys, object.Key)
+	}
+	for _, v := range object.Variants {
+		keys = append(keys, v.Key)
+	}
+
+	return keys
+}
+
+// MediaSweepResult reports a run of the orphaned media sweeper.
+type MediaSweepResult struct {
+	DryRun   bool     `json:"dry_run"`
+	Checked  int      `json:"checked"`
+	Orphaned []string `json:"orphaned"`
+	Deleted  int      `json:"deleted"`
+}
@@ -6,35 +6,120 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	PhotoVisibilityPublic    = "public"
+	PhotoVisibilityFollowers = "followers"
+	PhotoVisibilityPrivate   = "private"
+)
+
+// SensitiveCategories are the kinds of sensitive content a photo can be
+// flagged with.
+var SensitiveCategories = []string{"nudity", "violence", "gore", "self_harm", "drugs", "other"}
+
+// A photo with a SensitiveCategory is sensitive content. When a moderator
+// flags it, SensitiveByModerator is set and the uploader cannot change it.
+const (
+	ContentWarningByUploader  = "uploader"
+	ContentWarningByModerator = "moderator"
+)
+
+const (
+	PhotoStatusPublished = "published"
+	PhotoStatusDraft     = "draft"
+	PhotoStatusScheduled = "scheduled"
+)
+
 type Photo struct {
 	gorm.Model
-	Title    string `json:"title"`
-	Caption  string `json:"caption"`
-	PhotoURL string `json:"photo_url"`
-	UserID   int    `json:"users_id"`
-	User     User   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	Title                string         `gorm:"index:idx_photos_search,class:FULLTEXT" json:"title"`
+	Caption              string         `gorm:"index:idx_photos_search,class:FULLTEXT" json:"caption"`
+	PhotoURL             string         `json:"photo_url"`
+	Width                int            `json:"width"`
+	Height               int            `json:"height"`
+	BlurHash             string         `gorm:"size:64" json:"blur_hash"`
+	DominantColor        string         `gorm:"size:7" json:"dominant_color"`
+	StorageKey           string         `gorm:"size:255;index" json:"-"`
+	Visibility           string         `gorm:"size:16;default:public;index" json:"visibility"`
+	Status               string         `gorm:"size:16;default:published;index" json:"status"`
+	PublishAt            *time.Time     `gorm:"index" json:"publish_at"`
+	Latitude             *float64       `gorm:"index:idx_photos_location" json:"latitude"`
+	Longitude            *float64       `gorm:"index:idx_photos_location" json:"longitude"`
+	PlaceName            string         `gorm:"size:255" json:"place_name"`
+	EditedAt             *time.Time     `json:"edited_at"`
+	ArchivedAt           *time.Time     `gorm:"index" json:"archived_at"`
+	HiddenAt             *time.Time     `gorm:"index" json:"-"`
+	SensitiveCategory    string         `gorm:"size:32;index" json:"sensitive_category"`
+	SensitiveByModerator bool           `json:"-"`
+	UserID               int            `json:"users_id"`
+	User                 User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	Tags                 []Tag          `gorm:"many2many:photo_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"tags"`
+	Variants             []PhotoVariant `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"variants"`
+	Items                []PhotoItem    `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+	Metadata             *PhotoMetadata `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"metadata"`
+	UserTags             []UserTag      `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user_tags"`
 }
 
 type PhotoInput struct {
-	Title    string `form:"title" json:"title" binding:"required"`
-	Caption  string `form:"caption" json:"caption" binding:"required"`
-	PhotoURL string `form:"file" json:"file,omitempty" validate:"required" binding:"required"`
-	UserID   int    `json:"user_id"`
+	Title             string           `form:"title" json:"title" binding:"required"`
+	Caption           string           `form:"caption" json:"caption" binding:"required"`
+	Visibility        string           `form:"visibility" json:"visibility"`
+	Status            string           `form:"status" json:"status"`
+	PublishAt         string           `form:"publish_at" json:"publish_at"`
+	Latitude          *float64         `form:"latitude" json:"latitude"`
+	Longitude         *float64         `form:"longitude" json:"longitude"`
+	PlaceName         string           `form:"place_name" json:"place_name"`
+	SensitiveCategory string           `form:"sensitive_category" json:"sensitive_category"`
+	LocationFromExif  bool             `form:"location_from_exif" json:"-"`
+	RemoveLocation    bool             `json:"remove_location"`
+	PhotoURL          string           `form:"file" json:"file,omitempty" validate:"required" binding:"required"`
+	UploadIDs         []string         `form:"upload_id" json:"upload_ids"`
+	UserID            int              `json:"user_id"`
+	StorageKey        string           `json:"-"`
+	Items             []PhotoItem      `json:"-"`
+	ItemOrder         []PhotoItemInput `json:"items"`
+	Metadata          *PhotoMetadata   `json:"-"`
 }
 
 type PhotoResponse struct {
-	ID        int           `json:"id"`
-	Title     string        `json:"title"`
-	Caption   string        `json:"caption"`
-	PhotoURL  string        `json:"photo_url"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	User      UserResponses `json:"user"`
+	ID             int                     `json:"id"`
+	Title          string                  `json:"title"`
+	Caption        string                  `json:"caption"`
+	PhotoURL       string                  `json:"photo_url"`
+	Width          int                     `json:"width"`
+	Height         int                     `json:"height"`
+	BlurHash       string                  `json:"blur_hash"`
+	DominantColor  string                  `json:"dominant_color"`
+	Visibility     string                  `json:"visibility"`
+	Status         string                  `json:"status"`
+	PublishAt      *time.Time              `json:"publish_at,omitempty"`
+	Location       *PhotoLocationResponse  `json:"location,omitempty"`
+	Tags           []string                `json:"tags"`
+	Variants       map[string]string       `json:"variants"`
+	Items          []PhotoItemResponse     `json:"items"`
+	People         []UserTagResponse       `json:"people"`
+	Metadata       *PhotoMetadataResponse  `json:"metadata,omitempty"`
+	Edited         bool                    `json:"edited"`
+	EditedAt       *time.Time              `json:"edited_at,omitempty"`
+	Archived       bool                    `json:"archived"`
+	ArchivedAt     *time.Time              `json:"archived_at,omitempty"`
+	ContentWarning *ContentWarningResponse `json:"content_warning"`
+	Repost         *RepostedByResponse     `json:"repost,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+	User           UserResponses           `json:"user"`
 }
 
 type PhotoResponseWithoutPhotoURL struct {
-	Title   string `json:"title" example:"Bali"`
-	Caption string `json:"caption" example:"Liburan ke bali"`
+	Title          string           `json:"title" example:"Bali"`
+	Caption        string           `json:"caption" example:"Liburan ke bali"`
+	Visibility     string           `json:"visibility,omitempty" example:"followers"`
+	Status         string           `json:"status,omitempty" example:"scheduled"`
+	PublishAt      string           `json:"publish_at,omitempty" example:"2023-05-01T09:00:00+07:00"`
+	Latitude       *float64         `json:"latitude,omitempty" example:"-8.4095"`
+	Longitude      *float64         `json:"longitude,omitempty" example:"115.1889"`
+	PlaceName      string           `json:"place_name,omitempty" example:"Bali"`
+	RemoveLocation bool             `json:"remove_location,omitempty"`
+	Items          []PhotoItemInput `json:"items,omitempty"`
 }
 
 type PhotoResponses struct {
@@ -44,13 +129,37 @@ type PhotoResponses struct {
 }
 
 func ParsePhotoToResponse(photos Photo) PhotoResponse {
+	// The variants of a post are those of its cover.
+	variants := photos.Variants
+	if len(photos.Items) > 0 {
+		variants = photos.Items[0].Variants
+	}
+
 	return PhotoResponse{
-		ID:        int(photos.ID),
-		Title:     photos.Title,
-		Caption:   photos.Caption,
-		PhotoURL:  photos.PhotoURL,
-		CreatedAt: photos.CreatedAt,
-		UpdatedAt: photos.UpdatedAt,
+		ID:             int(photos.ID),
+		Title:          photos.Title,
+		Caption:        photos.Caption,
+		PhotoURL:       SignedMediaURL(photos.PhotoURL),
+		Width:          photos.Width,
+		Height:         photos.Height,
+		BlurHash:       photos.BlurHash,
+		DominantColor:  photos.DominantColor,
+		Visibility:     photos.Visibility,
+		Status:         photos.Status,
+		PublishAt:      photos.PublishAt,
+		Location:       ParsePhotoLocationToResponse(photos),
+		Tags:           ParseTagNames(photos.Tags),
+		Variants:       ParsePhotoVariantsToResponse(variants),
+		Items:          ParsePhotoItemsToResponse(photos.Items),
+		People:         ParseUserTagsToResponse(photos.UserTags),
+		Metadata:       ParsePhotoMetadataToResponse(photos.Metadata),
+		Edited:         photos.EditedAt != nil,
+		EditedAt:       photos.EditedAt,
+		Archived:       photos.ArchivedAt != nil,
+		ArchivedAt:     photos.ArchivedAt,
+		ContentWarning: ParseContentWarningToResponse(photos),
+		CreatedAt:      photos.CreatedAt,
+		UpdatedAt:      photos.UpdatedAt,
 		User: UserResponses{
 			FullName: photos.User.FullName,
 			Username: photos.User.Username,
@@ -59,6 +168,118 @@ func ParsePhotoToResponse(photos Photo) PhotoResponse {
 	}
 }
 
+// ContentWarningInput flags a photo as sensitive, or clears the flag when
+// Category is empty.
+type ContentWarningInput struct {
+	Category string `json:"category" example:"violence"`
+}
+
+// ContentWarningResponse tells clients to warn before showing a photo. Unless
+// the viewer's sensitive_content setting is show, they blur it.
+type ContentWarningResponse struct {
+	Category  string `json:"category"`
+	FlaggedBy string `json:"flagged_by"`
+}
+
+// ParseContentWarningToResponse returns nil for photos that are not
+// sensitive.
+func ParseContentWarningToResponse(photo Photo) *ContentWarningResponse {
+	if photo.SensitiveCategory == "" {
+		return nil
+	}
+
+	flaggedBy := ContentWarningByUploader
+	if photo.SensitiveByModerator {
+		flaggedBy = ContentWarningByModerator
+	}
+
+	return &ContentWarningResponse{
+		Category:  photo.SensitiveCategory,
+		FlaggedBy: flaggedBy,
+	}
+}
+
+type PhotoLocationResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	PlaceName string  `json:"place_name,omitempty"`
+}
+
+// ParsePhotoLocationToResponse returns nil for photos without a location.
+func ParsePhotoLocationToResponse(photo Photo) *PhotoLocationResponse {
+	if photo.Latitude == nil || photo.Longitude == nil {
+		return nil
+	}
+
+	return &PhotoLocationResponse{
+		Latitude:  *photo.Latitude,
+		Longitude: *photo.Longitude,
+		PlaceName: photo.PlaceName,
+	}
+}
+
+// NearbyPhoto is a photo found by a location search with its distance from
+// the searched position.
+type NearbyPhoto struct {
+	Photo      Photo
+	DistanceKm float64
+}
+
+type NearbyPhotoResponse struct {
+	PhotoResponse
+	DistanceKm float64 `json:"distance_km"`
+}
+
+func ParseNearbyPhotosToResponse(photos []NearbyPhoto) []NearbyPhotoResponse {
+	responses := make([]NearbyPhotoResponse, 0, len(photos))
+
+	for _, p := range photos {
+		responses = append(responses, NearbyPhotoResponse{ParsePhotoToResponse(p.Photo), p.DistanceKm})
+	}
+
+	return responses
+}
+
+// Published reports whether the photo is out of draft and past its
+// scheduled time. Photos from before drafts existed have no status.
+func (photo Photo) Published() bool {
+	return photo.Status == PhotoStatusPublished || photo.Status == ""
+}
+
+// PostedAt returns when the photo went out: its publish time, or for photos
+// from before drafts existed the time it was created. Scheduled posts are
+// created long before they are posted.
+func (photo Photo) PostedAt() time.Time {
+	if photo.PublishAt != nil {
+		return *photo.PublishAt
+	}
+	return photo.CreatedAt
+}
+
+// VisibleTo reports whether viewerID may see the photo. following tells
+// whether viewerID follows the owner of the photo. Archived photos and
+// photos hidden by moderation are only visible to their owner.
+func (photo Photo) VisibleTo(viewerID int, following bool) bool {
+	switch {
+	case photo.UserID == viewerID:
+		return true
+	case !photo.Published(), photo.ArchivedAt != nil, photo.HiddenAt != nil:
+		return false
+	case photo.Visibility == PhotoVisibilityFollowers:
+		return following
+	case photo.Visibility == PhotoVisibilityPrivate:
+		return false
+	}
+	return true
+}
+
+// HiddenBySetting reports whether the photo is left out of the listings of
+// viewerID, whose sensitive_content setting is setting. Owners always see
+// their own photos.
+func (photo Photo) HiddenBySetting(viewerID int, setting string) bool {
+	return photo.SensitiveCategory != "" && photo.UserID != viewerID && setting == SensitiveContentHide
+}
+
 func ParsePhotoToResponseArray(photos []Photo) []PhotoResponse {
 	responses := make([]PhotoResponse, 0, len(photos))
 
@@ -69,3 +290,42 @@ func ParsePhotoToResponseArray(photos []Photo) []PhotoResponse {
 
 	return responses
 }
+
+// SetCover makes the first item the cover of the post, whose image and
+// placeholder the photo shows.
+func (photo *Photo) SetCover() {
+	if len(photo.Items) == 0 {
+		return
+	}
+
+	cover := photo.Items[0]
+	photo.PhotoURL = cover.URL
+	photo.StorageKey = cover.StorageKey
+	photo.Width = cover.Width
+	photo.Height = cover.Height
+	photo.BlurHash = cover.BlurHash
+	photo.DominantColor = cover.DominantColor
+}
+
+// StorageKeys returns the media store keys of the photo, its items and their
+// variants. Photos uploaded before keys were tracked have an empty StorageKey.
+func (photo Photo) StorageKeys() []string {
+	keys := []string{photo.StorageKey}
+	for _, item := range photo.Items {
+		keys = append(keys, item.StorageKeys()...)
+	}
+	for _, v := range photo.Variants {
+		keys = append(keys, v.StorageKey)
+	}
+
+	seen := make(map[string]bool, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// MaxPhotoItems is the number of images a single post can hold.
+const MaxPhotoItems = 10
+
+// PhotoItem is one image of a photo post. Items are shown in Position order
+// and the first one is the cover of the post.
+type PhotoItem struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	PhotoID       uint           `gorm:"index" json:"photos_id"`
+	Position      int            `json:"position"`
+	URL           string         `json:"url"`
+	StorageKey    string         `gorm:"size:255" json:"-"`
+	AltText       string         `gorm:"size:1000" json:"alt_text"`
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	Size          int64          `json:"size"`
+	BlurHash      string         `gorm:"size:64" json:"blur_hash"`
+	DominantColor string         `gorm:"size:7" json:"dominant_color"`
+	Variants      []PhotoVariant `gorm:"foreignKey:PhotoItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"variants"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// StorageKeys returns the media store keys of the item and its variants.
+func (item PhotoItem) StorageKeys() []string {
+	keys := []string{item.StorageKey}
+	for _, v := range item.Variants {
+		keys = append(keys, v.StorageKey)
+	}
+	return keys
+}
+
+// PhotoItemInput sets the order of the items of a post on update. Items
+// left out are removed; a nil AltText keeps the current one.
+type PhotoItemInput struct {
+	ID      uint    `json:"id" example:"1"`
+	AltText *string `json:"alt_text" example:"Sunset over Kuta beach"`
+}
+
+type PhotoItemResponse struct {
+	ID            uint              `json:"id"`
+	URL           string            `json:"url"`
+	AltText       string            `json:"alt_text"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	BlurHash      string            `json:"blur_hash"`
+	DominantColor string            `json:"dominant_color"`
+	Variants      map[string]string `json:"variants"`
+}
+
+// ParseMediaToPhotoItems turns uploaded objects into post items, taking the
+// alt text at the same index of altTexts.
+func ParseMediaToPhotoItems(objects []MediaObject, altTexts []string) []PhotoItem {
+	items := make([]PhotoItem, 0, len(objects))
+
+	for i, object := range objects {
+		item := PhotoItem{
+			Position:      i,
+			URL:           object.URL,
+			StorageKey:    object.Key,
+			Width:         object.Width,
+			Height:        object.Height,
+			Size:          object.Size,
+			BlurHash:      object.BlurHash,
+			DominantColor: object.DominantColor,
+			Variants:      ParseMediaVariants(object.Variants),
+		}
+		if i < len(altTexts) {
+			item.AltText = altTexts[i]
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func ParsePhotoItemsToResponse(items []PhotoItem) []PhotoItemResponse {
+	responses := make([]PhotoItemResponse, 0, len(items))
+
+	for _, item := range items {
+		responses = append(responses, PhotoItemResponse{
+			ID:            item.ID,
+			URL:           SignedMediaURL(item.URL),
+			AltText:       item.AltText,
+			Width:         item.Width,
+			Height:        item.Height,
+			BlurHash:      item.BlurHash,
+			DominantColor: item.DominantColor,
+			Variants:      ParsePhotoVariantsToResponse(item.Variants),
+		})
+	}
+
+	return responses
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// PhotoMetadata holds the public EXIF fields of a photo. Latitude and
+// Longitude are only kept when the uploader opted in to keeping locations.
+type PhotoMetadata struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	PhotoID      uint       `gorm:"uniqueIndex" json:"photos_id"`
+	CameraMake   string     `gorm:"size:64" json:"camera_make"`
+	CameraModel  string     `gorm:"size:64" json:"camera_model"`
+	LensModel    string     `gorm:"size:128" json:"lens_model"`
+	ExposureTime string     `gorm:"size:16" json:"exposure_time"`
+	FNumber      float64    `json:"f_number"`
+	ISO          int        `json:"iso"`
+	FocalLength  float64    `json:"focal_length"`
+	TakenAt      *time.Time `json:"taken_at"`
+	Latitude     *float64   `json:"latitude"`
+	Longitude    *float64   `json:"longitude"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type PhotoMetadataResponse struct {
+	CameraMake   string     `json:"camera_make,omitempty"`
+	CameraModel  string     `json:"camera_model,omitempty"`
+	LensModel    string     `json:"lens_model,omitempty"`
+	ExposureTime string     `json:"exposure_time,omitempty"`
+	FNumber      float64    `json:"f_number,omitempty"`
+	ISO          int        `json:"iso,omitempty"`
+	FocalLength  float64    `json:"focal_length,omitempty"`
+	TakenAt      *time.Time `json:"taken_at,omitempty"`
+	Latitude     *float64   `json:"latitude,omitempty"`
+	Longitude    *float64   `json:"longitude,omitempty"`
+}
+
+// IsEmpty reports whether the photo carried none of the public fields.
+func (m PhotoMetadata) IsEmpty() bool {
+	return m.CameraMake == "" && m.CameraModel == "" && m.LensModel == "" && m.ExposureTime == "" &&
+		m.FNumber == 0 && m.ISO == 0 && m.FocalLength == 0 && m.TakenAt == nil && m.Latitude == nil && m.Longitude == nil
+}
+
+func ParsePhotoMetadataToResponse(metadata *PhotoMetadata) *PhotoMetadataResponse {
+	if metadata == nil {
+		return nil
+	}
+
+	return &PhotoMetadataResponse{
+		CameraMake:   metadata.CameraMake,
+		CameraModel:  metadata.CameraModel,
+		LensModel:    metadata.LensModel,
+		ExposureTime: metadata.ExposureTime,
+		FNumber:      metadata.FNumber,
+		ISO:          metadata.ISO,
+		FocalLength:  metadata.FocalLength,
+		TakenAt:      metadata.TakenAt,
+		Latitude:     metadata.Latitude,
+		Longitude:    metadata.Longitude,
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import "time"
+
+// PhotoSnapshot holds the fields of a photo that an edit can change.
+type PhotoSnapshot struct {
+	Title      string   `json:"title"`
+	Caption    string   `json:"caption"`
+	PhotoURL   string   `json:"photo_url"`
+	Visibility string   `json:"visibility"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+	PlaceName  string   `json:"place_name"`
+}
+
+type PhotoFieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// PhotoRevision records one edit of a photo, including drafts and scheduled
+// posts: the fields as they were before the edit and what changed.
+type PhotoRevision struct {
+	ID        uint                        `gorm:"primarykey" json:"id"`
+	PhotoID   uint                        `gorm:"index" json:"photos_id"`
+	Photo     Photo                       `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	UserID    int                         `json:"users_id"`
+	Before    PhotoSnapshot               `gorm:"serializer:json;type:text" json:"before"`
+	Changes   map[string]PhotoFieldChange `gorm:"serializer:json;type:text" json:"changes"`
+	CreatedAt time.Time                   `json:"created_at"`
+}
+
+// PhotoEdit is what an edit saves besides the photo and its items. When
+// Schedule is set the status and publish time are saved too.
+type PhotoEdit struct {
+	Schedule bool
+	Revision *PhotoRevision
+	Removed  []PhotoItem
+}
+
+type PhotoRevisionResponse struct {
+	ID        int                         `json:"id"`
+	Changes   map[string]PhotoFieldChange `json:"changes"`
+	CreatedAt time.Time                   `json:"created_at"`
+}
+
+func SnapshotPhoto(photo Photo) PhotoSnapshot {
+	return PhotoSnapshot{
+		Title:      photo.Title,
+		Caption:    photo.Caption,
+		PhotoURL:   photo.PhotoURL,
+		Visibility: photo.Visibility,
+		Latitude:   photo.Latitude,
+		Longitude:  photo.Longitude,
+		PlaceName:  photo.PlaceName,
+	}
+}
+
+func floatValue(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func sameFloat(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Changes returns the fields that differ between the snapshot and after,
+// keyed by their JSON name.
+func (s PhotoSnapshot) Changes(after PhotoSnapshot) map[string]PhotoFieldChange {
+	changes := map[string]PhotoFieldChange{}
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", s.Title, after.Title},
+		{"caption", s.Caption, after.Caption},
+		{"photo_url", s.PhotoURL, after.PhotoURL},
+		{"visibility", s.Visibility, after.Visibility},
+		{"place_name", s.PlaceName, after.PlaceName},
+	}
+	for _, field := range fields {
+		if field.old != field.new {
+			changes[field.name] = PhotoFieldChange{field.old, field.new}
+		}
+	}
+
+	if !sameFloat(s.Latitude, after.Latitude) {
+		changes["latitude"] = PhotoFieldChange{floatValue(s.Latitude), floatValue(after.Latitude)}
+	}
+	if !sameFloat(s.Longitude, after.Longitude) {
+		changes["longitude"] = PhotoFieldChange{floatValue(s.Longitude), floatValue(after.Longitude)}
+	}
+
+	return changes
+}
+
+// Restore sets the fields of photo back to the snapshot. The image is left
+// alone, as the media of a replaced image is deleted; callers only restore
+// snapshots with the current image.
+func (s PhotoSnapshot) Restore(photo *Photo) {
+	photo.Title = s.Title
+	photo.Caption = s.Caption
+	photo.Visibility = s.Visibility
+	photo.Latitude = s.Latitude
+	photo.Longitude = s.Longitude
+	photo.PlaceName = s.PlaceName
+}
+
+func ParsePhotoRevisionsToResponse(revisions []PhotoRevision) []PhotoRevisionResponse {
+	responses := make([]PhotoRevisionResponse, 0, len(revisions))
+
+	for _, r := range revisions {
+		// The revision keeps the stored URLs; only the response is signed.
+		changes := make(map[string]PhotoFieldChange, len(r.Changes))
+		for field, change := range r.Changes {
+			if field == "photo_url" {
+				old, _ := change.Old.(string)
+				new, _ := change.New.(string)
+				change = PhotoFieldChange{SignedMediaURL(old), SignedMediaURL(new)}
+			}
+			changes[field] = change
+		}
+
+		responses = append(responses, PhotoRevisionResponse{
+			ID:        int(r.ID),
+			Changes:   changes,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+
+	return responses
+}
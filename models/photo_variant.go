@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// PhotoVariant is a resized copy of an item of a photo, such as its
+// thumbnail. PhotoItemID is only nil for variants created before posts could
+// hold several items.
+type PhotoVariant struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	PhotoID     uint      `gorm:"index" json:"photos_id"`
+	PhotoItemID *uint     `gorm:"index" json:"photo_items_id"`
+	Name        string    `gorm:"size:32" json:"name"`
+	StorageKey  string    `json:"-"`
+	URL         string    `json:"url"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ParseMediaVariants(variants map[string]MediaObject) []PhotoVariant {
+	photoVariants := make([]PhotoVariant, 0, len(variants))
+
+	for name, v := range variants {
+		photoVariants = append(photoVariants, PhotoVariant{
+			Name:       name,
+			StorageKey: v.Key,
+			URL:        v.URL,
+			Width:      v.Width,
+			Height:     v.Height,
+			Size:       v.Size,
+		})
+	}
+
+	return photoVariants
+}
+
+func ParsePhotoVariantsToResponse(variants []PhotoVariant) map[string]string {
+	urls := make(map[string]string, len(variants))
+
+	for _, v := range variants {
+		urls[v.Name] = SignedMediaURL(v.URL)
+	}
+
+	return urls
+}
@@ -0,0 +1,82 @@
+package models
+
+// PhotoViewDateLayout is the layout of the dates view counters are kept
+// for, in UTC.
+const PhotoViewDateLayout = "2006-01-02"
+
+// PhotoViewCount holds the views of a photo on one day, rather than a row
+// per view.
+type PhotoViewCount struct {
+	PhotoID       uint   `gorm:"primaryKey;autoIncrement:false" json:"photos_id"`
+	Photo         Photo  `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Date          string `gorm:"primaryKey;size:10" json:"date"`
+	Views         int64  `json:"views"`
+	UniqueViewers int64  `json:"unique_viewers"`
+}
+
+// PhotoDailyViewer records that a user viewed a photo on a day, so they are
+// counted once in the unique viewers of that day. The rows are only needed
+// while the day is being counted and are pruned afterwards.
+type PhotoDailyViewer struct {
+	PhotoID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Photo    Photo  `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Date     string `gorm:"primaryKey;size:10;index"`
+	ViewerID uint   `gorm:"primaryKey;autoIncrement:false"`
+}
+
+// DailyCount is a number of things that happened on Date.
+type DailyCount struct {
+	Date  string
+	Count int64
+}
+
+type PhotoInsightsDay struct {
+	Date          string `json:"date"`
+	Views         int64  `json:"views"`
+	UniqueViewers int64  `json:"unique_viewers"`
+	Comments      int64  `json:"comments"`
+	Reposts       int64  `json:"reposts"`
+}
+
+type PhotoInsightsResponse struct {
+	PhotoID  uint               `json:"photos_id"`
+	Views    int64              `json:"views"`
+	Comments int64              `json:"comments"`
+	Reposts  int64              `json:"reposts"`
+	Days     []PhotoInsightsDay `json:"days"`
+}
+
+// ParsePhotoInsightsToResponse lays out the counters of a photo as one entry
+// per day from the first to the last of dates, including days without any
+// activity. Totals cover the same days.
+func ParsePhotoInsightsToResponse(photoID uint, dates []string, views []PhotoViewCount, comments, reposts []DailyCount) PhotoInsightsResponse {
+	response := PhotoInsightsResponse{PhotoID: photoID, Days: make([]PhotoInsightsDay, 0, len(dates))}
+
+	byDate := make(map[string]int, len(dates))
+	for _, date := range dates {
+		byDate[date] = len(response.Days)
+		response.Days = append(response.Days, PhotoInsightsDay{Date: date})
+	}
+
+	for _, count := range views {
+		if i, ok := byDate[count.Date]; ok {
+			response.Days[i].Views = count.Views
+			response.Days[i].UniqueViewers = count.UniqueViewers
+			response.Views += count.Views
+		}
+	}
+	for _, count := range comments {
+		if i, ok := byDate[count.Date]; ok {
+			response.Days[i].Comments = count.Count
+			response.Comments += count.Count
+		}
+	}
+	for _, count := range reposts {
+		if i, ok := byDate[count.Date]; ok {
+			response.Days[i].Reposts = count.Count
+			response.Reposts += count.Count
+		}
+	}
+
+	return response
+}
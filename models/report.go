@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+const (
+	ReportTargetPhoto       = "photo"
+	ReportTargetComment     = "comment"
+	ReportTargetSocialMedia = "social_media"
+	ReportTargetUser        = "user"
+)
+
+// ReportReasons are the reasons a report can give.
+var ReportReasons = []string{
+	"spam", "harassment", "hate_speech", "violence", "nudity",
+	"self_harm", "impersonation", "intellectual_property", "other",
+}
+
+const (
+	ModerationCaseOpen     = "open"
+	ModerationCaseClaimed  = "claimed"
+	ModerationCaseResolved = "resolved"
+)
+
+// Resolutions of a moderation case.
+const (
+	ModerationRemove  = "remove"
+	ModerationWarn    = "warn"
+	ModerationSuspend = "suspend"
+	ModerationDismiss = "dismiss"
+)
+
+// Actions recorded in the moderation log besides the resolutions.
+const (
+	ModerationActionReport   = "report"
+	ModerationActionAutoHide = "auto_hide"
+	ModerationActionClaim    = "claim"
+	ModerationActionNote     = "note"
+)
+
+// ModerationCase collects the reports about one piece of content until a
+// moderator resolves it. Content reported again after that gets a new case.
+type ModerationCase struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	TargetType string `gorm:"size:16;index:idx_moderation_cases_target" json:"target_type"`
+	TargetID   uint   `gorm:"index:idx_moderation_cases_target" json:"target_id"`
+	// TargetUserID is the owner of the content, or the reported user.
+	TargetUserID int    `gorm:"index" json:"target_user_id"`
+	Status       string `gorm:"size:16;index" json:"status"`
+	ReportCount  int64  `json:"report_count"`
+	// Hidden tells whether the content was hidden for passing the report
+	// threshold.
+	Hidden     bool               `json:"hidden"`
+	AssigneeID *int               `json:"assignee_id"`
+	Resolution string             `gorm:"size:16" json:"resolution,omitempty"`
+	ResolvedAt *time.Time         `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	Reports    []Report           `gorm:"foreignKey:CaseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"reports,omitempty"`
+	Notes      []ModerationNote   `gorm:"foreignKey:CaseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"notes,omitempty"`
+	Actions    []ModerationAction `gorm:"foreignKey:CaseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"actions,omitempty"`
+}
+
+// Report is one user's report of the content of a case. A user reports the
+// same content once per case.
+type Report struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CaseID     uint      `gorm:"uniqueIndex:idx_reports_case_reporter" json:"case_id"`
+	ReporterID int       `gorm:"uniqueIndex:idx_reports_case_reporter" json:"reporter_id"`
+	Reason     string    `gorm:"size:32" json:"reason"`
+	Details    string    `gorm:"size:1000" json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ModerationNote struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CaseID      uint      `gorm:"index" json:"case_id"`
+	ModeratorID int       `json:"moderator_id"`
+	Note        string    `gorm:"type:text" json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ModerationAction records everything that happens to a case. ActorID is
+// nil for actions taken automatically.
+type ModerationAction struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CaseID    uint      `gorm:"index" json:"case_id"`
+	ActorID   *int      `json:"actor_id"`
+	Action    string    `gorm:"size:16" json:"action"`
+	Details   string    `gorm:"size:1000" json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReportInput struct {
+	TargetType string `json:"target_type" example:"photo"`
+	TargetID   uint   `json:"target_id" example:"1"`
+	Reason     string `json:"reason" example:"spam"`
+	Details    string `json:"details" example:"Posts the same link under every photo"`
+}
+
+type ModerationNoteInput struct {
+	Note string `json:"note" example:"Second report about this account this week"`
+}
+
+type ModerationResolveInput struct {
+	Action string `json:"action" example:"remove"`
+	Note   string `json:"note" example:"Spam link"`
+}
+
+type ReportResponse struct {
+	ID         uint      `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ParseReportToResponse leaves out the case, which only moderators see.
+func ParseReportToResponse(report Report, moderationCase ModerationCase) ReportResponse {
+	return ReportResponse{
+		ID:         report.ID,
+		TargetType: moderationCase.TargetType,
+		TargetID:   moderationCase.TargetID,
+		Reason:     report.Reason,
+		CreatedAt:  report.CreatedAt,
+	}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxRepostCaption is the length of the caption a quote-share can add.
+const MaxRepostCaption = 2200
+
+// Repost shares the photo of another user with the followers of UserID. A
+// repost with a Caption is a quote-share. Reposts are only shown while the
+// photo is public and its author allows reposts, and are deleted with it.
+type Repost struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    int       `gorm:"uniqueIndex:idx_reposts_user_photo" json:"users_id"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	PhotoID   uint      `gorm:"uniqueIndex:idx_reposts_user_photo;index" json:"photos_id"`
+	Photo     Photo     `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photo"`
+	Caption   string    `gorm:"size:2200" json:"caption"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RepostInput struct {
+	Caption string `json:"caption" example:"Pengen ke sini juga"`
+}
+
+// RepostedByResponse tells who reposted a photo and what they added to it.
+type RepostedByResponse struct {
+	ID        uint          `json:"id"`
+	Caption   string        `json:"caption"`
+	User      UserResponses `json:"user"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func ParseRepostedByToResponse(repost Repost) *RepostedByResponse {
+	return &RepostedByResponse{
+		ID:      repost.ID,
+		Caption: repost.Caption,
+		User: UserResponses{
+			FullName: repost.User.FullName,
+			Username: repost.User.Username,
+			Email:    repost.User.Email,
+		},
+		CreatedAt: repost.CreatedAt,
+	}
+}
+
+func ParseRepostsToResponse(reposts []Repost) []RepostedByResponse {
+	responses := make([]RepostedByResponse, 0, len(reposts))
+
+	for _, repost := range reposts {
+		responses = append(responses, *ParseRepostedByToResponse(repost))
+	}
+
+	return responses
+}
+
+// ParseRepostToResponse shows the reposted photo, with who reposted it.
+func ParseRepostToResponse(repost Repost) PhotoResponse {
+	response := ParsePhotoToResponse(repost.Photo)
+	response.Repost = ParseRepostedByToResponse(repost)
+
+	return response
+}
+
+// ParseFeedToResponse puts photos and reposts into one feed, newest first,
+// and returns the given page of it. photos and reposts must each hold at
+// least the page*limit newest of their kind.
+func ParseFeedToResponse(photos []Photo, reposts []Repost, page, limit int) []PhotoResponse {
+	responses := make([]PhotoResponse, 0, len(photos)+len(reposts))
+	postedAt := make([]time.Time, 0, len(photos)+len(reposts))
+
+	for _, photo := range photos {
+		responses = append(responses, ParsePhotoToResponse(photo))
+		postedAt = append(postedAt, photo.PostedAt())
+	}
+	for _, repost := range reposts {
+		responses = append(responses, ParseRepostToResponse(repost))
+		postedAt = append(postedAt, repost.CreatedAt)
+	}
+
+	order := make([]int, len(responses))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return postedAt[order[a]].After(postedAt[order[b]])
+	})
+
+	start, end := (page-1)*limit, page*limit
+	if start > len(order) {
+		start = len(order)
+	}
+	if end > len(order) {
+		end = len(order)
+	}
+
+	feed := make([]PhotoResponse, 0, end-start)
+	for _, i := range order[start:end] {
+		feed = append(feed, responses[i])
+	}
+
+	return feed
+}
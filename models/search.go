@@ -0,0 +1,69 @@
+package models
+
+const (
+	SearchTypePhoto   = "photo"
+	SearchTypeUser    = "user"
+	SearchTypeComment = "comment"
+)
+
+var SearchTypes = []string{SearchTypePhoto, SearchTypeUser, SearchTypeComment}
+
+// SearchHit is a single match returned by a search index, before the matched
+// record is loaded.
+type SearchHit struct {
+	Type  string
+	ID    uint
+	Score float64
+}
+
+type SearchResult struct {
+	Type    string
+	Score   float64
+	Photo   *Photo
+	User    *User
+	Comment *Comment
+}
+
+type SearchResultResponse struct {
+	Type    string           `json:"type"`
+	Score   float64          `json:"score"`
+	Photo   *PhotoResponse   `json:"photo,omitempty"`
+	User    *UserResponses   `json:"user,omitempty"`
+	Comment *CommentResponse `json:"comment,omitempty"`
+}
+
+func ParseSearchResultToResponse(result SearchResult) SearchResultResponse {
+	response := SearchResultResponse{
+		Type:  result.Type,
+		Score: result.Score,
+	}
+
+	if result.Photo != nil {
+		photo := ParsePhotoToResponse(*result.Photo)
+		response.Photo = &photo
+	}
+	if result.User != nil {
+		response.User = &UserResponses{
+			FullName: result.User.FullName,
+			Username: result.User.Username,
+			Email:    result.User.Email,
+		}
+	}
+	if result.Comment != nil {
+		comment := ParseCommentToResponse(*result.Comment)
+		response.Comment = &comment
+	}
+
+	return response
+}
+
+func ParseSearchResultToResponseArray(results []SearchResult) []SearchResultResponse {
+	responses := make([]SearchResultResponse, 0, len(results))
+
+	for _, s := range results {
+		response := ParseSearchResultToResponse(s)
+		responses = append(responses, response)
+	}
+
+	return responses
+}
@@ -12,6 +12,8 @@ type SocialMedia struct {
 	SocialMediaURL string `json:"social_media_url"`
 	UserID         int    `json:"users_id"`
 	User           User   `json:"user" gorm:"primaryKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	// HiddenAt is set while the link is hidden for too many reports.
+	HiddenAt *time.Time `gorm:"index" json:"-"`
 }
 
 type SocialMediaInput struct {
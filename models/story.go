@@ -0,0 +1,228 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// StoryLifetime is how long a story stays in the story tray.
+const StoryLifetime = 24 * time.Hour
+
+// MaxHighlightStories is the number of stories a highlight can hold.
+const MaxHighlightStories = 100
+
+// Story is an image shown to the followers of its author until ExpiresAt.
+// Expired stories stay in the author's archive, where they can be saved
+// into a highlight, until the story expirer removes them with their media.
+type Story struct {
+	ID            uint              `gorm:"primarykey" json:"id"`
+	UserID        int               `gorm:"index" json:"users_id"`
+	User          User              `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	URL           string            `json:"url"`
+	Variants      map[string]string `gorm:"serializer:json;type:text" json:"variants"`
+	StorageKeys   []string          `gorm:"serializer:json;type:text" json:"-"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	BlurHash      string            `gorm:"size:64" json:"blur_hash"`
+	DominantColor string            `gorm:"size:7" json:"dominant_color"`
+	Size          int64             `json:"size"`
+	ExpiresAt     time.Time         `gorm:"index" json:"expires_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Views         []StoryView       `gorm:"foreignKey:StoryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// StoryView records that a user has seen a story.
+type StoryView struct {
+	StoryID   uint      `gorm:"primaryKey;autoIncrement:false" json:"stories_id"`
+	ViewerID  int       `gorm:"primaryKey;autoIncrement:false" json:"viewer_id"`
+	Viewer    User      `gorm:"foreignKey:ViewerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"viewer"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoryHighlight keeps stories on the profile of their author after they
+// expire.
+type StoryHighlight struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    int       `gorm:"index" json:"users_id"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	Title     string    `gorm:"size:64" json:"title"`
+	Stories   []Story   `gorm:"many2many:highlight_stories;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"stories"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MediaStory returns a story of userID showing object.
+func MediaStory(userID int, object MediaObject, now time.Time) Story {
+	story := Story{
+		UserID:        userID,
+		URL:           object.URL,
+		Variants:      make(map[string]string, len(object.Variants)),
+		StorageKeys:   object.StorageKeys(),
+		Width:         object.Width,
+		Height:        object.Height,
+		BlurHash:      object.BlurHash,
+		DominantColor: object.DominantColor,
+		Size:          object.Size,
+		ExpiresAt:     now.Add(StoryLifetime),
+	}
+	for name, v := range object.Variants {
+		story.Variants[name] = v.URL
+		story.Size += v.Size
+	}
+
+	return story
+}
+
+// Expired reports whether the story has left the story tray at now.
+func (story Story) Expired(now time.Time) bool {
+	return !now.Before(story.ExpiresAt)
+}
+
+type HighlightInput struct {
+	Title    string `json:"title" example:"Bali"`
+	StoryIDs []uint `json:"story_ids"`
+}
+
+type HighlightStoryInput struct {
+	StoryID uint `json:"story_id" example:"1"`
+}
+
+type StoryResponse struct {
+	ID            uint              `json:"id"`
+	URL           string            `json:"url"`
+	Variants      map[string]string `json:"variants"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	BlurHash      string            `json:"blur_hash"`
+	DominantColor string            `json:"dominant_color"`
+	Seen          bool              `json:"seen"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// ParseStoriesToResponse marks the stories whose ID is in seen as seen.
+func ParseStoriesToResponse(stories []Story, seen map[uint]bool) []StoryResponse {
+	responses := make([]StoryResponse, 0, len(stories))
+
+	for _, story := range stories {
+		variants := make(map[string]string, len(story.Variants))
+		for name, url := range story.Variants {
+			variants[name] = SignedMediaURL(url)
+		}
+
+		responses = append(responses, StoryResponse{
+			ID:            story.ID,
+			URL:           SignedMediaURL(story.URL),
+			Variants:      variants,
+			Width:         story.Width,
+			Height:        story.Height,
+			BlurHash:      story.BlurHash,
+			DominantColor: story.DominantColor,
+			Seen:          seen[story.ID],
+			ExpiresAt:     story.ExpiresAt,
+			CreatedAt:     story.CreatedAt,
+		})
+	}
+
+	return responses
+}
+
+// StoryTrayEntry holds the active stories of one user, oldest first.
+type StoryTrayEntry struct {
+	User      UserResponses   `json:"user"`
+	HasUnseen bool            `json:"has_unseen"`
+	LatestAt  time.Time       `json:"latest_at"`
+	Stories   []StoryResponse `json:"stories"`
+}
+
+// ParseStoryTray groups stories by author. Authors with stories the viewer
+// has not seen come first, and within that the most recently active ones.
+func ParseStoryTray(stories []Story, seen map[uint]bool) []StoryTrayEntry {
+	entries := make([]StoryTrayEntry, 0)
+	index := make(map[int]int)
+
+	for _, story := range stories {
+		i, ok := index[story.UserID]
+		if !ok {
+			i = len(entries)
+			index[story.UserID] = i
+			entries = append(entries, StoryTrayEntry{
+				User: UserResponses{
+					FullName: story.User.FullName,
+					Username: story.User.Username,
+					Email:    story.User.Email,
+				},
+				Stories: []StoryResponse{},
+			})
+		}
+
+		entry := &entries[i]
+		entry.Stories = append(entry.Stories, ParseStoriesToResponse([]Story{story}, seen)...)
+		entry.HasUnseen = entry.HasUnseen || !seen[story.ID]
+		if story.CreatedAt.After(entry.LatestAt) {
+			entry.LatestAt = story.CreatedAt
+		}
+	}
+
+	for i := range entries {
+		sort.SliceStable(entries[i].Stories, func(a, b int) bool {
+			return entries[i].Stories[a].CreatedAt.Before(entries[i].Stories[b].CreatedAt)
+		})
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].HasUnseen != entries[b].HasUnseen {
+			return entries[a].HasUnseen
+		}
+		return entries[a].LatestAt.After(entries[b].LatestAt)
+	})
+
+	return entries
+}
+
+type StoryViewerResponse struct {
+	FullName string    `json:"full_name"`
+	Username string    `json:"username"`
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+func ParseStoryViewersToResponse(views []StoryView) []StoryViewerResponse {
+	responses := make([]StoryViewerResponse, 0, len(views))
+
+	for _, view := range views {
+		responses = append(responses, StoryViewerResponse{
+			FullName: view.Viewer.FullName,
+			Username: view.Viewer.Username,
+			ViewedAt: view.CreatedAt,
+		})
+	}
+
+	return responses
+}
+
+type HighlightResponse struct {
+	ID        uint            `json:"id"`
+	Title     string          `json:"title"`
+	Stories   []StoryResponse `json:"stories"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func ParseHighlightToResponse(highlight StoryHighlight) HighlightResponse {
+	return HighlightResponse{
+		ID:        highlight.ID,
+		Title:     highlight.Title,
+		Stories:   ParseStoriesToResponse(highlight.Stories, nil),
+		CreatedAt: highlight.CreatedAt,
+		UpdatedAt: highlight.UpdatedAt,
+	}
+}
+
+func ParseHighlightsToResponse(highlights []StoryHighlight) []HighlightResponse {
+	responses := make([]HighlightResponse, 0, len(highlights))
+
+	for _, highlight := range highlights {
+		responses = append(responses, ParseHighlightToResponse(highlight))
+	}
+
+	return responses
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Name      string    `gorm:"size:64;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TagResponse struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+func ParseTagNames(tags []Tag) []string {
+	names := make([]string, 0, len(tags))
+
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
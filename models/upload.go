@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// MaxActiveUploads is how many unfinished resumable uploads a user may have
+// at a time.
+const MaxActiveUploads = 20
+
+// Upload is a resumable upload staged on the local disk. Offset is the
+// number of bytes received so far; the upload is complete once it reaches
+// Length and can then be attached to a new photo.
+type Upload struct {
+	ID        string    `gorm:"primaryKey;size:32" json:"id"`
+	UserID    int       `gorm:"index" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Length    int64     `json:"length"`
+	Offset    int64     `gorm:"column:upload_offset" json:"offset"`
+	Metadata  string    `gorm:"type:text" json:"metadata"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Complete reports whether every byte of the upload has been received.
+func (upload Upload) Complete() bool {
+	return upload.Offset == upload.Length
+}
+
+type UploadResponse struct {
+	ID        string    `json:"id"`
+	Length    int64     `json:"length"`
+	Offset    int64     `json:"offset"`
+	Complete  bool      `json:"complete"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func ParseUploadToResponse(upload Upload) UploadResponse {
+	return UploadResponse{
+		ID:        upload.ID,
+		Length:    upload.Length,
+		Offset:    upload.Offset,
+		Complete:  upload.Complete(),
+		ExpiresAt: upload.ExpiresAt,
+	}
+}
+
+// UploadForm describes the request a client sends to upload a file straight
+// to the media store: Method to URL with the extra headers, or as a
+// multipart form with Fields and the file last, as "file".
+type UploadForm struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// PresignedUpload is a direct upload to the media store under Key that has
+// to be finalized before it becomes a photo. Size, ContentType and Checksum,
+// a hex SHA-256, are declared by the client and checked on finalizing.
+type PresignedUpload struct {
+	ID          string    `gorm:"primaryKey;size:32" json:"id"`
+	UserID      int       `gorm:"index" json:"user_id"`
+	User        User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Key         string    `gorm:"size:255" json:"key"`
+	ContentType string    `gorm:"size:32" json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `gorm:"size:64" json:"checksum"`
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type PresignUploadInput struct {
+	ContentType string `json:"content_type" example:"image/jpeg"`
+	Size        int64  `json:"size" example:"2048576"`
+	Checksum    string `json:"checksum_sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+type PresignedUploadResponse struct {
+	ID        string     `json:"id"`
+	Upload    UploadForm `json:"upload"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// StorageQuota bounds what a user may store. Zero means no limit.
+type StorageQuota struct {
+	MaxBytes  int64
+	MaxPhotos int64
+}
+
+// StorageUsage is what a user stores: the bytes of the images of their
+// photos and of the resized variants, and the number of photos.
+type StorageUsage struct {
+	Bytes  int64
+	Photos int64
+}
+
+type StorageUsageResponse struct {
+	Tier        string `json:"tier"`
+	BytesUsed   int64  `json:"bytes_used"`
+	BytesLimit  int64  `json:"bytes_limit"`
+	PhotosUsed  int64  `json:"photos_used"`
+	PhotosLimit int64  `json:"photos_limit"`
+}
+
+func ParseStorageUsageToResponse(tier string, usage StorageUsage, quota StorageQuota) StorageUsageResponse {
+	return StorageUsageResponse{
+		Tier:        tier,
+		BytesUsed:   usage.Bytes,
+		BytesLimit:  quota.MaxBytes,
+		PhotosUsed:  usage.Photos,
+		PhotosLimit: quota.MaxPhotos,
+	}
+}
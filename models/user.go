@@ -6,12 +6,46 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	UserQuotaStandard = "standard"
+	UserQuotaExtended = "extended"
+)
+
+// Settings for how sensitive photos of other users are shown.
+const (
+	SensitiveContentShow = "show"
+	SensitiveContentBlur = "blur"
+	SensitiveContentHide = "hide"
+)
+
+const (
+	UserRoleUser      = "user"
+	UserRoleModerator = "moderator"
+)
+
 type User struct {
 	gorm.Model
-	FullName string `json:"full_name"`
-	Username string `json:"username"`
+	FullName string `gorm:"index:idx_users_search,class:FULLTEXT" json:"full_name"`
+	Username string `gorm:"index:idx_users_search,class:FULLTEXT" json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// KeepPhotoLocation opts in to keeping the GPS position of uploads.
+	KeepPhotoLocation bool `gorm:"default:false" json:"keep_photo_location"`
+	// DefaultVisibility is used for new photos that do not set one.
+	DefaultVisibility string `gorm:"size:16;default:public" json:"default_visibility"`
+	// SensitiveContent is how sensitive photos of other users are shown.
+	SensitiveContent string `gorm:"size:8;default:blur" json:"sensitive_content"`
+	// AllowReposts lets other users repost the public photos of the account.
+	AllowReposts bool `gorm:"default:true" json:"allow_reposts"`
+	// QuotaTier selects the storage quota. Flagged accounts are moved to
+	// the extended tier by an administrator.
+	QuotaTier string `gorm:"size:16;default:standard" json:"quota_tier"`
+	// Role is UserRoleModerator for accounts that work the moderation
+	// queue. It is granted by an administrator.
+	Role string `gorm:"size:16;default:user" json:"role"`
+	// SuspendedAt is set when a moderator suspends the account, which
+	// can no longer sign in.
+	SuspendedAt *time.Time `json:"-"`
 }
 
 type LoginInput struct {
@@ -26,12 +60,25 @@ type RegisterInput struct {
 	Password string `form:"password" json:"password" binding:"required,min=6" example:"qweqwe123"`
 }
 
+// UserSettingsInput changes the account settings. Fields left out of the
+// request keep their current value.
+type UserSettingsInput struct {
+	KeepPhotoLocation *bool   `json:"keep_photo_location" example:"false"`
+	DefaultVisibility *string `json:"default_visibility" example:"followers"`
+	SensitiveContent  *string `json:"sensitive_content" example:"hide"`
+	AllowReposts      *bool   `json:"allow_reposts" example:"false"`
+}
+
 type UserResponse struct {
-	FullName  string    `json:"full_name"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	FullName          string    `json:"full_name"`
+	Username          string    `json:"username"`
+	Email             string    `json:"email"`
+	KeepPhotoLocation bool      `json:"keep_photo_location"`
+	DefaultVisibility string    `json:"default_visibility"`
+	SensitiveContent  string    `json:"sensitive_content"`
+	AllowReposts      bool      `json:"allow_reposts"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type UserResponses struct {
@@ -42,10 +89,14 @@ type UserResponses struct {
 
 func ParseUserToResponse(user User) UserResponse {
 	return UserResponse{
-		FullName:  user.FullName,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		FullName:          user.FullName,
+		Username:          user.Username,
+		Email:             user.Email,
+		KeepPhotoLocation: user.KeepPhotoLocation,
+		DefaultVisibility: user.DefaultVisibility,
+		SensitiveContent:  user.SensitiveContent,
+		AllowReposts:      user.AllowReposts,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
 	}
 }
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+const (
+	UserTagPending  = "pending"
+	UserTagApproved = "approved"
+)
+
+// MaxUserTags is how many people can be tagged in one photo.
+const MaxUserTags = 20
+
+// UserTag tags a user at a position on a photo. X and Y are fractions of the
+// image width and height measured from the top left corner. The tag is shown
+// once the tagged user approves it.
+type UserTag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	PhotoID   uint      `gorm:"uniqueIndex:idx_user_tags_photo_user" json:"photos_id"`
+	Photo     Photo     `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photo"`
+	UserID    uint      `gorm:"uniqueIndex:idx_user_tags_photo_user;index" json:"users_id"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+	Status    string    `gorm:"size:16;default:pending;index" json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UserTagInput struct {
+	Username string  `json:"username" example:"hanif"`
+	X        float64 `json:"x" example:"0.25"`
+	Y        float64 `json:"y" example:"0.6"`
+}
+
+type UserTagResponse struct {
+	ID        int             `json:"id"`
+	X         float64         `json:"x"`
+	Y         float64         `json:"y"`
+	Status    string          `json:"status"`
+	User      UserResponses   `json:"user"`
+	Photo     *PhotoResponses `json:"photo,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func ParseUserTagToResponse(tag UserTag) UserTagResponse {
+	response := UserTagResponse{
+		ID:     int(tag.ID),
+		X:      tag.X,
+		Y:      tag.Y,
+		Status: tag.Status,
+		User: UserResponses{
+			FullName: tag.User.FullName,
+			Username: tag.User.Username,
+			Email:    tag.User.Email,
+		},
+		CreatedAt: tag.CreatedAt,
+	}
+	if tag.Photo.ID != 0 {
+		response.Photo = &PhotoResponses{
+			Title:    tag.Photo.Title,
+			Caption:  tag.Photo.Caption,
+			PhotoURL: SignedMediaURL(tag.Photo.PhotoURL),
+		}
+	}
+
+	return response
+}
+
+func ParseUserTagsToResponse(tags []UserTag) []UserTagResponse {
+	responses := make([]UserTagResponse, 0, len(tags))
+
+	for _, t := range tags {
+		responses = append(responses, ParseUserTagToResponse(t))
+	}
+
+	return responses
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+)
+
+type AlbumRepository interface {
+	CreateAlbum(album models.Album) (models.Album, error)
+	FindByID(albumID int) (models.Album, error)
+	GetAlbumsByUser(userId int, includePrivate bool) ([]models.Album, error)
+	UpdateAlbum(album models.Album) (models.Album, error)
+	DeleteAlbum(album models.Album) error
+	AddPhoto(albumID, photoID uint) error
+	RemovePhoto(albumID, photoID uint) error
+	SetPhotos(albumID uint, photoIDs []uint) error
+}
+
+type albumRepository struct {
+	DB *gorm.DB
+}
+
+func NewAlbumRepository(db *gorm.DB) *albumRepository {
+	return &albumRepository{db}
+}
+
+func preloadAlbum(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").
+		Preload("CoverPhoto").
+		Preload("Photos", func(db *gorm.DB) *gorm.DB {
+			return db.Joins("JOIN photos ON photos.id = album_photos.photo_id").
+				Where("photos.deleted_at IS NULL").
+				Order("album_photos.position ASC")
+		}).
+		Preload("Photos.Photo.User")
+}
+
+func (ar *albumRepository) CreateAlbum(album models.Album) (models.Album, error) {
+	err := ar.DB.Omit("Photos").Create(&album).Error
+	if err != nil {
+		return album, err
+	}
+
+	return ar.FindByID(int(album.ID))
+}
+
+func (ar *albumRepository) FindByID(albumID int) (models.Album, error) {
+	var album models.Album
+
+	err := preloadAlbum(ar.DB).Where("id = ?", albumID).First(&album).Error
+
+	return album, err
+}
+
+func (ar *albumRepository) GetAlbumsByUser(userId int, includePrivate bool) ([]models.Album, error) {
+	var albums []models.Album
+
+	query := preloadAlbum(ar.DB).Where("user_id = ?", userId)
+	if !includePrivate {
+		query = query.Where("visibility = ?", models.AlbumVisibilityPublic)
+	}
+
+	err := query.Order("created_at DESC").Find(&albums).Error
+
+	return albums, err
+}
+
+func (ar *albumRepository) UpdateAlbum(album models.Album) (models.Album, error) {
+	err := ar.DB.Model(&album).Select("Title", "Description", "Visibility", "CoverPhotoID", "UpdatedAt").Updates(&album).Error
+
+	return album, err
+}
+
+func (ar *albumRepository) DeleteAlbum(album models.Album) error {
+	return ar.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("album_id = ?", album.ID).Delete(&models.AlbumPhoto{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&album).Error
+	})
+}
+
+func (ar *albumRepository) AddPhoto(albumID, photoID uint) error {
+	return ar.DB.Transaction(func(tx *gorm.DB) error {
+		var position int
+		err := tx.Model(&models.AlbumPhoto{}).Where("album_id = ?", albumID).
+			Select("COALESCE(MAX(position), -1) + 1").Scan(&position).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.AlbumPhoto{AlbumID: albumID, PhotoID: photoID, Position: position}).Error
+	})
+}
+
+func (ar *albumRepository) RemovePhoto(albumID, photoID uint) error {
+	return ar.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("album_id = ? AND photo_id = ?", albumID, photoID).Delete(&models.AlbumPhoto{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Album{}).Where("id = ? AND cover_photo_id = ?", albumID, photoID).
+			Update("cover_photo_id", nil).Error
+	})
+}
+
+// SetPhotos replaces the album's photos with photoIDs, using the slice order
+// as the album order.
+func (ar *albumRepository) SetPhotos(albumID uint, photoIDs []uint) error {
+	return ar.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("album_id = ?", albumID).Delete(&models.AlbumPhoto{}).Error
+		if err != nil {
+			return err
+		}
+
+		for i, photoID := range photoIDs {
+			err = tx.Create(&models.AlbumPhoto{AlbumID: albumID, PhotoID: photoID, Position: i}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		keep := append([]uint{0}, photoIDs...)
+		return tx.Model(&models.Album{}).Where("id = ? AND cover_photo_id NOT IN ?", albumID, keep).
+			Update("cover_photo_id", nil).Error
+	})
+}
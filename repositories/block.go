@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockRepository interface {
+	Block(blockerID, blockedID uint) error
+	Unblock(blockerID, blockedID uint) error
+	IsBlocked(userID, otherID int) (bool, error)
+}
+
+type blockRepository struct {
+	DB *gorm.DB
+}
+
+func NewBlockRepository(db *gorm.DB) *blockRepository {
+	return &blockRepository{db}
+}
+
+// Block blocks blockedID for blockerID and drops the follows between them
+// and the tags of either of them in the other's photos.
+func (br *blockRepository) Block(blockerID, blockedID uint) error {
+	return br.DB.Transaction(func(tx *gorm.DB) error {
+		block := models.Block{BlockerID: blockerID, BlockedID: blockedID}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("(follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).Delete(&models.Follow{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("(user_id = ? AND photo_id IN (SELECT id FROM photos WHERE user_id = ?)) OR (user_id = ? AND photo_id IN (SELECT id FROM photos WHERE user_id = ?))",
+			blockedID, blockerID, blockerID, blockedID).Delete(&models.UserTag{}).Error
+	})
+}
+
+func (br *blockRepository) Unblock(blockerID, blockedID uint) error {
+	return br.DB.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.Block{}).Error
+}
+
+// IsBlocked reports whether either user blocked the other.
+func (br *blockRepository) IsBlocked(userID, otherID int) (bool, error) {
+	var count int64
+
+	err := br.DB.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&count).Error
+
+	return count > 0, err
+}
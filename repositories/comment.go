@@ -11,7 +11,7 @@ type CommentRepository interface {
 	FindByID(commentID int) (models.Comment, error)
 	GetMyComment(userId int) ([]models.Comment, error)
 	GetMyCommentByID(userId, ID int) (models.Comment, error)
-	GetAllComments() ([]models.Comment, error)
+	GetAllComments(viewerID int) ([]models.Comment, error)
 	DeleteCommentRepository(comment models.Comment) error
 	UpdateComment(comment models.Comment) (models.Comment, error)
 	GetPhotoData(commentID int) (models.Comment, error)
@@ -25,6 +25,15 @@ func NewCommentRepository(db *gorm.DB) *commentRepository {
 	return &commentRepository{db}
 }
 
+// commentsOnVisiblePhotos limits a comments query to comments on photos
+// viewerID may see, as every comment embeds its photo.
+func commentsOnVisiblePhotos(viewerID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN photos ON photos.id = comments.photo_id AND photos.deleted_at IS NULL").
+			Scopes(visiblePhotos(viewerID))
+	}
+}
+
 func (cr *commentRepository) CreateComment(comment models.Comment) (models.Comment, error) {
 	err := cr.DB.Create(&comment).Error
 
@@ -50,7 +59,7 @@ func (cr *commentRepository) GetPhotoData(commentID int) (models.Comment, error)
 func (cr *commentRepository) GetMyCommentByID(userId, ID int) (models.Comment, error) {
 	var comments models.Comment
 
-	err := cr.DB.Where("user_id = ? AND id = ?", userId, ID).Preload("User").Preload("Photo").Find(&comments).Error
+	err := cr.DB.Scopes(commentsOnVisiblePhotos(userId)).Where("comments.user_id = ? AND comments.id = ?", userId, ID).Preload("User").Preload("Photo").Find(&comments).Error
 
 	return comments, err
 }
@@ -58,14 +67,14 @@ func (cr *commentRepository) GetMyCommentByID(userId, ID int) (models.Comment, e
 func (cr *commentRepository) GetMyComment(userId int) ([]models.Comment, error) {
 	var comments []models.Comment
 
-	err := cr.DB.Where("user_id = ?", userId).Preload("User").Preload("Photo").Find(&comments).Error
+	err := cr.DB.Scopes(commentsOnVisiblePhotos(userId)).Where("comments.user_id = ?", userId).Preload("User").Preload("Photo").Find(&comments).Error
 
 	return comments, err
 }
 
-func (cr *commentRepository) GetAllComments() ([]models.Comment, error) {
+func (cr *commentRepository) GetAllComments(viewerID int) ([]models.Comment, error) {
 	var comments []models.Comment
-	err := cr.DB.Joins("JOIN users ON users.id = comments.user_id").Where("comments.deleted_at IS NULL AND users.deleted_at IS NULL").Preload("User").Preload("Photo").Find(&comments).Error
+	err := cr.DB.Joins("JOIN users ON users.id = comments.user_id").Where("comments.deleted_at IS NULL AND comments.hidden_at IS NULL AND users.deleted_at IS NULL").Scopes(commentsOnVisiblePhotos(viewerID)).Preload("User").Preload("Photo").Find(&comments).Error
 	return comments, err
 }
 
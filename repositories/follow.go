@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepository interface {
+	Follow(followerID, followingID uint) error
+	Unfollow(followerID, followingID uint) error
+	IsFollowing(followerID, followingID int) (bool, error)
+}
+
+type followRepository struct {
+	DB *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) *followRepository {
+	return &followRepository{db}
+}
+
+func (fr *followRepository) Follow(followerID, followingID uint) error {
+	follow := models.Follow{FollowerID: followerID, FollowingID: followingID}
+	return fr.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error
+}
+
+func (fr *followRepository) Unfollow(followerID, followingID uint) error {
+	return fr.DB.Where("follower_id = ? AND following_id = ?", followerID, followingID).Delete(&models.Follow{}).Error
+}
+
+func (fr *followRepository) IsFollowing(followerID, followingID int) (bool, error) {
+	var count int64
+
+	err := fr.DB.Model(&models.Follow{}).Where("follower_id = ? AND following_id = ?", followerID, followingID).Count(&count).Error
+
+	return count > 0, err
+}
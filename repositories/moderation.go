@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ModerationRepository interface {
+	FindTargetOwner(targetType string, targetID uint) (int, error)
+	SetHidden(targetType string, targetID uint, hidden bool) error
+	SuspendUser(userID int, at time.Time) error
+	FindOpenCase(targetType string, targetID uint) (models.ModerationCase, error)
+	CreateCase(moderationCase models.ModerationCase) (models.ModerationCase, error)
+	AddReport(report models.Report) (models.ModerationCase, bool, error)
+	FindCase(caseID int) (models.ModerationCase, error)
+	GetCases(statuses []string, limit, offset int) ([]models.ModerationCase, error)
+	ClaimCase(caseID uint, moderatorID int) (bool, error)
+	UpdateCase(moderationCase models.ModerationCase) error
+	CreateNote(note models.ModerationNote) (models.ModerationNote, error)
+	CreateAction(action models.ModerationAction) error
+}
+
+type moderationRepository struct {
+	DB *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) *moderationRepository {
+	return &moderationRepository{db}
+}
+
+// targetTables are the tables reports can point into and the column
+// holding the owner of a row.
+var targetTables = map[string]struct{ table, owner string }{
+	models.ReportTargetPhoto:       {"photos", "user_id"},
+	models.ReportTargetComment:     {"comments", "user_id"},
+	models.ReportTargetSocialMedia: {"social_media", "user_id"},
+	models.ReportTargetUser:        {"users", "id"},
+}
+
+// FindTargetOwner returns the user owning the reported content, or the
+// reported user. It fails with gorm.ErrRecordNotFound if the content does
+// not exist.
+func (mr *moderationRepository) FindTargetOwner(targetType string, targetID uint) (int, error) {
+	target, ok := targetTables[targetType]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+
+	var owners []int
+	err := mr.DB.Table(target.table).Where("id = ? AND deleted_at IS NULL", targetID).Pluck(target.owner, &owners).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(owners) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+
+	return owners[0], nil
+}
+
+// SetHidden hides the reported content from other users or shows it again.
+func (mr *moderationRepository) SetHidden(targetType string, targetID uint, hidden bool) error {
+	target, ok := targetTables[targetType]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	var hiddenAt *time.Time
+	if hidden {
+		now := time.Now()
+		hiddenAt = &now
+	}
+
+	return mr.DB.Table(target.table).Where("id = ?", targetID).UpdateColumn("hidden_at", hiddenAt).Error
+}
+
+func (mr *moderationRepository) SuspendUser(userID int, at time.Time) error {
+	return mr.DB.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("suspended_at", at).Error
+}
+
+// FindOpenCase returns the case of the content that has not been resolved
+// yet.
+func (mr *moderationRepository) FindOpenCase(targetType string, targetID uint) (models.ModerationCase, error) {
+	var moderationCase models.ModerationCase
+
+	err := mr.DB.Where("target_type = ? AND target_id = ? AND status <> ?", targetType, targetID, models.ModerationCaseResolved).
+		First(&moderationCase).Error
+
+	return moderationCase, err
+}
+
+func (mr *moderationRepository) CreateCase(moderationCase models.ModerationCase) (models.ModerationCase, error) {
+	err := mr.DB.Create(&moderationCase).Error
+	return moderationCase, err
+}
+
+// AddReport adds report to its case and returns the case with the new
+// report count. It reports false, without changing the case, when the
+// reporter already reported it.
+func (mr *moderationRepository) AddReport(report models.Report) (models.ModerationCase, bool, error) {
+	var (
+		moderationCase models.ModerationCase
+		created        bool
+	)
+
+	err := mr.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected == 1
+
+		if created {
+			err := tx.Model(&models.ModerationCase{}).Where("id = ?", report.CaseID).
+				UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("id = ?", report.CaseID).First(&moderationCase).Error
+	})
+
+	return moderationCase, created, err
+}
+
+func (mr *moderationRepository) FindCase(caseID int) (models.ModerationCase, error) {
+	var moderationCase models.ModerationCase
+
+	err := mr.DB.Where("id = ?", caseID).
+		Preload("Reports", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Notes", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Actions", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		First(&moderationCase).Error
+
+	return moderationCase, err
+}
+
+// GetCases returns the cases in statuses, the most reported and then the
+// oldest first.
+func (mr *moderationRepository) GetCases(statuses []string, limit, offset int) ([]models.ModerationCase, error) {
+	var cases []models.ModerationCase
+
+	err := mr.DB.Where("status IN ?", statuses).
+		Order("report_count DESC, created_at ASC").
+		Limit(limit).Offset(offset).
+		Find(&cases).Error
+
+	return cases, err
+}
+
+// ClaimCase assigns an open case to moderatorID. It reports false when the
+// case is no longer open, because another moderator claimed it first.
+func (mr *moderationRepository) ClaimCase(caseID uint, moderatorID int) (bool, error) {
+	result := mr.DB.Model(&models.ModerationCase{}).
+		Where("id = ? AND status = ?", caseID, models.ModerationCaseOpen).
+		Updates(map[string]interface{}{"status": models.ModerationCaseClaimed, "assignee_id": moderatorID})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (mr *moderationRepository) UpdateCase(moderationCase models.ModerationCase) error {
+	return mr.DB.Model(&moderationCase).
+		Select("Status", "Hidden", "AssigneeID", "Resolution", "ResolvedAt", "UpdatedAt").
+		Updates(&moderationCase).Error
+}
+
+func (mr *moderationRepository) CreateNote(note models.ModerationNote) (models.ModerationNote, error) {
+	err := mr.DB.Create(&note).Error
+	return note, err
+}
+
+func (mr *moderationRepository) CreateAction(action models.ModerationAction) error {
+	return mr.DB.Create(&action).Error
+}
@@ -1,19 +1,43 @@
 package repositories
 
 import (
+	"errors"
+	"mini-project-alterra/helpers"
 	"mini-project-alterra/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PhotoRepository interface {
 	CreatePhoto(photo models.Photo) (models.Photo, error)
 	DeletePhotoRepository(photo models.Photo) error
 	FindByID(photoID int) (models.Photo, error)
-	GetAllMyPhoto(userId int) ([]models.Photo, error)
+	FindVisibleByID(photoID, viewerID int) (models.Photo, error)
+	GetAllMyPhoto(userId int, archived bool) ([]models.Photo, error)
+	GetDrafts(userId int) ([]models.Photo, error)
+	GetDuePhotos(now time.Time, limit int) ([]models.Photo, error)
+	PublishScheduledPhoto(photo models.Photo) (bool, error)
 	GetAllMyPhotoByID(userId, ID int) (models.Photo, error)
-	GetAllPhoto() ([]models.Photo, error)
-	UpdatePhoto(photo models.Photo) (models.Photo, error)
+	GetAllPhoto(viewerID, limit int) ([]models.Photo, error)
+	GetNearbyPhotos(viewerID int, lat, lng, radiusKm float64, box helpers.GeoBox, limit, offset int) ([]models.Photo, error)
+	UpdatePhoto(photo models.Photo, edit models.PhotoEdit) (models.Photo, bool, error)
+	SetArchived(photo models.Photo) (models.Photo, error)
+	SetContentWarning(photo models.Photo) (models.Photo, error)
+	GetItemsWithoutVariants(afterID uint, limit int) ([]models.PhotoItem, error)
+	CreateVariants(variants []models.PhotoVariant) error
+	GetItemsWithoutPlaceholder(afterID uint, limit int) ([]models.PhotoItem, error)
+	SetItemPlaceholder(item models.PhotoItem) error
+	GetItemsWithoutSize(afterID uint, limit int) ([]models.PhotoItem, error)
+	SetItemSize(itemID uint, size int64) error
+	GetVariantsWithoutSize(afterID uint, limit int) ([]models.PhotoVariant, error)
+	SetVariantSize(variantID uint, size int64) error
+	GetPhotosOfDeletedUsers(limit int) ([]models.Photo, error)
+	GetStorageKeys() ([]string, error)
+	GetStorageUsage(userID int) (models.StorageUsage, error)
+	GetRevisions(photoID uint) ([]models.PhotoRevision, error)
+	FindRevision(photoID uint, revisionID int) (models.PhotoRevision, error)
 }
 
 type photoRepository struct {
@@ -24,21 +48,94 @@ func NewPhotoRepository(db *gorm.DB) *photoRepository {
 	return &photoRepository{db}
 }
 
+// visiblePhotos limits a query that includes the photos table to the
+// published photos viewerID may see: their own, public ones and the
+// followers-only ones of users they follow. Archived photos and photos
+// hidden by moderation are left out, including those of viewerID.
+func visiblePhotos(viewerID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("photos.status = ? AND photos.archived_at IS NULL AND photos.hidden_at IS NULL", models.PhotoStatusPublished).
+			Where("photos.user_id = ? OR photos.visibility = ? OR (photos.visibility = ? AND EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = ? AND follows.following_id = photos.user_id))",
+				viewerID, models.PhotoVisibilityPublic, models.PhotoVisibilityFollowers, viewerID)
+	}
+}
+
+// listablePhotos leaves sensitive photos out of listings for viewers whose
+// sensitive_content setting is hide. Single photos are still shown to them,
+// with a content warning.
+func listablePhotos(viewerID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("photos.sensitive_category = '' OR photos.user_id = ? OR NOT EXISTS (SELECT 1 FROM users AS viewers WHERE viewers.id = ? AND viewers.sensitive_content = ?)",
+			viewerID, viewerID, models.SensitiveContentHide)
+	}
+}
+
+// preloadPhoto loads the associations shown in photo responses, with the
+// items of a post in carousel order and only the approved user tags.
+func preloadPhoto(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").
+		Preload("UserTags", "status = ?", models.UserTagApproved).
+		Preload("UserTags.User").
+		Preload("Tags").
+		Preload("Variants").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("photo_items.position ASC, photo_items.id ASC")
+		}).
+		Preload("Items.Variants")
+}
+
+// CreatePhoto saves photo together with its items. The variants of every
+// item are linked to both the item and the photo.
 func (pr *photoRepository) CreatePhoto(photo models.Photo) (models.Photo, error) {
-	err := pr.DB.Create(&photo).Preload("User").First(&photo).Error
+	items := photo.Items
+	photo.Items = nil
+
+	err := pr.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&photo).Error
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		for i := range items {
+			items[i].PhotoID = photo.ID
+			for j := range items[i].Variants {
+				items[i].Variants[j].PhotoID = photo.ID
+			}
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		return photo, err
+	}
+
+	err = preloadPhoto(pr.DB).Preload("Metadata").First(&photo, photo.ID).Error
 
 	return photo, err
 }
 
+// DeletePhotoRepository permanently deletes photo. Its variants, metadata,
+// comments and tag links go with it through the foreign keys.
 func (pr *photoRepository) DeletePhotoRepository(photo models.Photo) error {
-	err := pr.DB.Delete(&photo).Error
+	err := pr.DB.Unscoped().Delete(&photo).Error
 	return err
 }
 
 func (pr *photoRepository) FindByID(photoID int) (models.Photo, error) {
 	var photo models.Photo
 
-	err := pr.DB.Where("id = ?", photoID).Preload("User").First(&photo).Error
+	err := preloadPhoto(pr.DB).Where("id = ?", photoID).Preload("Metadata").First(&photo).Error
+
+	return photo, err
+}
+
+// FindVisibleByID returns photoID if viewerID may see it.
+func (pr *photoRepository) FindVisibleByID(photoID, viewerID int) (models.Photo, error) {
+	var photo models.Photo
+
+	err := preloadPhoto(pr.DB).Scopes(visiblePhotos(viewerID)).Where("photos.id = ?", photoID).Preload("Metadata").First(&photo).Error
 
 	return photo, err
 }
@@ -46,30 +143,370 @@ func (pr *photoRepository) FindByID(photoID int) (models.Photo, error) {
 func (pr *photoRepository) GetAllMyPhotoByID(userId, ID int) (models.Photo, error) {
 	var photos models.Photo
 
-	err := pr.DB.Where("user_id = ? AND id = ?", userId, ID).Preload("User").Find(&photos).Error
+	err := preloadPhoto(pr.DB).Where("user_id = ? AND id = ?", userId, ID).Preload("Metadata").Find(&photos).Error
 
 	return photos, err
 }
 
-func (pr *photoRepository) GetAllMyPhoto(userId int) ([]models.Photo, error) {
+// GetAllMyPhoto returns the published photos of userId, either the archived
+// ones or the others.
+func (pr *photoRepository) GetAllMyPhoto(userId int, archived bool) ([]models.Photo, error) {
 	var photos []models.Photo
 
-	err := pr.DB.Where("user_id = ?", userId).Preload("User").Find(&photos).Error
+	query := preloadPhoto(pr.DB).Where("user_id = ? AND status = ?", userId, models.PhotoStatusPublished)
+	if archived {
+		query = query.Where("archived_at IS NOT NULL").Order("archived_at DESC")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+
+	err := query.Find(&photos).Error
 
 	return photos, err
 }
 
-func (pr *photoRepository) GetAllPhoto() ([]models.Photo, error) {
+// GetDrafts returns the drafts and scheduled posts of userId, the next to be
+// published first.
+func (pr *photoRepository) GetDrafts(userId int) ([]models.Photo, error) {
 	var photos []models.Photo
 
-	err := pr.DB.Joins("JOIN users ON users.id = photos.user_id").Where("photos.deleted_at IS NULL AND users.deleted_at IS NULL").Preload("User").Find(&photos).Error
+	err := preloadPhoto(pr.DB).Where("user_id = ? AND status IN ?", userId, []string{models.PhotoStatusDraft, models.PhotoStatusScheduled}).
+		Order("publish_at IS NULL, publish_at ASC, id ASC").Find(&photos).Error
 
 	return photos, err
 }
 
-func (pr *photoRepository) UpdatePhoto(photo models.Photo) (models.Photo, error) {
-	err := pr.DB.Session(&gorm.Session{FullSaveAssociations: true}).Updates(&photo).Error
+// GetDuePhotos returns up to limit scheduled posts whose publish time is not
+// after now.
+func (pr *photoRepository) GetDuePhotos(now time.Time, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := preloadPhoto(pr.DB).Where("status = ? AND publish_at <= ?", models.PhotoStatusScheduled, now).
+		Order("publish_at ASC, id ASC").Limit(limit).Find(&photos).Error
+
+	return photos, err
+}
+
+// PublishScheduledPhoto publishes a scheduled post. It reports false when the
+// post was published, cancelled or rescheduled in the meantime, so each post
+// is published once even with several schedulers running.
+func (pr *photoRepository) PublishScheduledPhoto(photo models.Photo) (bool, error) {
+	result := pr.DB.Model(&models.Photo{}).
+		Where("id = ? AND status = ? AND publish_at = ?", photo.ID, models.PhotoStatusScheduled, photo.PublishAt).
+		Update("status", models.PhotoStatusPublished)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// photoDistanceKm is helpers.HaversineKm in SQL: the distance of a photo
+// from a position in kilometres. Its parameters are the radius of the
+// Earth, the latitude of the position twice and its longitude.
+const photoDistanceKm = "2 * ? * ASIN(LEAST(1, SQRT(POW(SIN(RADIANS(photos.latitude - ?) / 2), 2) + " +
+	"COS(RADIANS(?)) * COS(RADIANS(photos.latitude)) * POW(SIN(RADIANS(photos.longitude - ?) / 2), 2))))"
+
+// GetNearbyPhotos returns a page of the published photos viewerID may see
+// within radiusKm of lat, lng, nearest first. box has to hold the circle;
+// it prefilters the photos on the location index before their distance is
+// worked out.
+func (pr *photoRepository) GetNearbyPhotos(viewerID int, lat, lng, radiusKm float64, box helpers.GeoBox, limit, offset int) ([]models.Photo, error) {
+	var photos []models.Photo
+	distance := []interface{}{helpers.EarthRadiusKm, lat, lat, lng}
+
+	db := preloadPhoto(pr.DB).Joins("JOIN users ON users.id = photos.user_id").
+		Where("photos.deleted_at IS NULL AND users.deleted_at IS NULL").
+		Scopes(visiblePhotos(viewerID), listablePhotos(viewerID)).
+		Where("photos.latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+	if box.MinLng > box.MaxLng {
+		db = db.Where("photos.longitude >= ? OR photos.longitude <= ?", box.MinLng, box.MaxLng)
+	} else {
+		db = db.Where("photos.longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+	}
+
+	err := db.Where(photoDistanceKm+" <= ?", append(distance, radiusKm)...).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: photoDistanceKm + ", photos.id", Vars: distance}}).
+		Limit(limit).Offset(offset).Find(&photos).Error
+
+	return photos, err
+}
+
+// GetAllPhoto returns the limit most recently posted photos viewerID may
+// see, newest first.
+func (pr *photoRepository) GetAllPhoto(viewerID, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := preloadPhoto(pr.DB).Joins("JOIN users ON users.id = photos.user_id").Where("photos.deleted_at IS NULL AND users.deleted_at IS NULL").Scopes(visiblePhotos(viewerID), listablePhotos(viewerID)).
+		Order("COALESCE(photos.publish_at, photos.created_at) DESC, photos.id DESC").Limit(limit).Find(&photos).Error
+
+	return photos, err
+}
+
+// errPhotoPublished rolls back an edit that would reschedule a published
+// post.
+var errPhotoPublished = errors.New("photo is published")
+
+// UpdatePhoto saves an edit of photo in one transaction: the photo, its
+// items and their variants, and what edit holds. The other associations are
+// loaded with the photo and may be stale by now, so they are not saved: the
+// owner, tags and user tags have their own repositories and the metadata
+// never changes. The location is always written so that it can be removed.
+//
+// The status and publish time are only saved with edit.Schedule, as the
+// scheduler may publish the post concurrently. UpdatePhoto reports false and
+// saves nothing when the post has been published in the meantime.
+func (pr *photoRepository) UpdatePhoto(photo models.Photo, edit models.PhotoEdit) (models.Photo, bool, error) {
+	err := pr.DB.Transaction(func(tx *gorm.DB) error {
+		if edit.Schedule {
+			result := tx.Model(&models.Photo{}).
+				Where("id = ? AND status IN ?", photo.ID, []string{models.PhotoStatusDraft, models.PhotoStatusScheduled}).
+				Updates(map[string]interface{}{"status": photo.Status, "publish_at": photo.PublishAt})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return errPhotoPublished
+			}
+		}
+
+		err := tx.Session(&gorm.Session{FullSaveAssociations: true}).
+			Omit("status", "publish_at", "User", "Tags", "Variants", "Metadata", "UserTags").Updates(&photo).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&photo).Select("latitude", "longitude", "place_name").Updates(&photo).Error
+		if err != nil {
+			return err
+		}
+
+		if edit.Revision != nil {
+			err = tx.Create(edit.Revision).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// The variants of removed items go with them through the foreign key.
+		if len(edit.Removed) > 0 {
+			return tx.Delete(&edit.Removed).Error
+		}
+		return nil
+	})
+	if err == errPhotoPublished {
+		return photo, false, nil
+	}
+
+	return photo, err == nil, err
+}
+
+// SetArchived saves the archived time of photo. Archiving is not an edit, so
+// the updated time is kept.
+func (pr *photoRepository) SetArchived(photo models.Photo) (models.Photo, error) {
+	err := pr.DB.Model(&photo).UpdateColumn("archived_at", photo.ArchivedAt).Error
 
 	return photo, err
+}
+
+// SetContentWarning saves the sensitive content flag of photo. Like
+// archiving, it is not an edit.
+func (pr *photoRepository) SetContentWarning(photo models.Photo) (models.Photo, error) {
+	err := pr.DB.Model(&photo).UpdateColumns(map[string]interface{}{
+		"sensitive_category":     photo.SensitiveCategory,
+		"sensitive_by_moderator": photo.SensitiveByModerator,
+	}).Error
+
+	return photo, err
+}
+
+// GetItemsWithoutVariants returns up to limit items of photos that are not
+// deleted with an ID above afterID that have no resized variants yet, in ID
+// order.
+func (pr *photoRepository) GetItemsWithoutVariants(afterID uint, limit int) ([]models.PhotoItem, error) {
+	var items []models.PhotoItem
+
+	err := pr.DB.Joins("JOIN photos ON photos.id = photo_items.photo_id AND photos.deleted_at IS NULL").
+		Where("photo_items.id > ? AND NOT EXISTS (SELECT 1 FROM photo_variants WHERE photo_variants.photo_item_id = photo_items.id)", afterID).
+		Order("photo_items.id ASC").Limit(limit).Find(&items).Error
+
+	return items, err
+}
+
+// GetItemsWithoutPlaceholder returns up to limit items of photos that are
+// not deleted with an ID above afterID that have no BlurHash yet, in ID
+// order.
+func (pr *photoRepository) GetItemsWithoutPlaceholder(afterID uint, limit int) ([]models.PhotoItem, error) {
+	var items []models.PhotoItem
+
+	err := pr.DB.Joins("JOIN photos ON photos.id = photo_items.photo_id AND photos.deleted_at IS NULL").
+		Where("photo_items.id > ? AND photo_items.blur_hash = ''", afterID).
+		Order("photo_items.id ASC").Limit(limit).Find(&items).Error
+
+	return items, err
+}
+
+// SetItemPlaceholder saves the dimensions and placeholder of item, and of
+// its photo when item is the cover.
+func (pr *photoRepository) SetItemPlaceholder(item models.PhotoItem) error {
+	columns := map[string]interface{}{
+		"width":          item.Width,
+		"height":         item.Height,
+		"blur_hash":      item.BlurHash,
+		"dominant_color": item.DominantColor,
+	}
+
+	return pr.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PhotoItem{}).Where("id = ?", item.ID).UpdateColumns(columns).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Photo{}).Where("id = ? AND photo_url = ?", item.PhotoID, item.URL).UpdateColumns(columns).Error
+	})
+}
+
+// GetItemsWithoutSize returns up to limit stored items of photos that are
+// not deleted with an ID above afterID whose size is not known, in ID
+// order. Items of photos from before quotas were migrated without one.
+func (pr *photoRepository) GetItemsWithoutSize(afterID uint, limit int) ([]models.PhotoItem, error) {
+	var items []models.PhotoItem
+
+	err := pr.DB.Joins("JOIN photos ON photos.id = photo_items.photo_id AND photos.deleted_at IS NULL").
+		Where("photo_items.id > ? AND photo_items.size = 0 AND photo_items.storage_key <> ''", afterID).
+		Order("photo_items.id ASC").Limit(limit).Find(&items).Error
+
+	return items, err
+}
+
+func (pr *photoRepository) SetItemSize(itemID uint, size int64) error {
+	return pr.DB.Model(&models.PhotoItem{}).Where("id = ?", itemID).UpdateColumn("size", size).Error
+}
+
+// GetVariantsWithoutSize is GetItemsWithoutSize for variants.
+func (pr *photoRepository) GetVariantsWithoutSize(afterID uint, limit int) ([]models.PhotoVariant, error) {
+	var variants []models.PhotoVariant
+
+	err := pr.DB.Joins("JOIN photos ON photos.id = photo_variants.photo_id AND photos.deleted_at IS NULL").
+		Where("photo_variants.id > ? AND photo_variants.size = 0 AND photo_variants.storage_key <> ''", afterID).
+		Order("photo_variants.id ASC").Limit(limit).Find(&variants).Error
+
+	return variants, err
+}
+
+func (pr *photoRepository) SetVariantSize(variantID uint, size int64) error {
+	return pr.DB.Model(&models.PhotoVariant{}).Where("id = ?", variantID).UpdateColumn("size", size).Error
+}
+
+func (pr *photoRepository) CreateVariants(variants []models.PhotoVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	return pr.DB.Create(&variants).Error
+}
+
+// GetPhotosOfDeletedUsers returns up to limit photos whose user has deleted
+// their account, with the items and variants holding their media.
+func (pr *photoRepository) GetPhotosOfDeletedUsers(limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := pr.DB.Preload("Variants").Preload("Items").Preload("Items.Variants").
+		Joins("JOIN users ON users.id = photos.user_id AND users.deleted_at IS NOT NULL").
+		Order("photos.id ASC").Limit(limit).Find(&photos).Error
+
+	return photos, err
+}
+
+// GetStorageKeys returns the media store keys of every photo that is not
+// deleted, including the keys of their items and variants, and of every
+// story, which is stored alongside them.
+func (pr *photoRepository) GetStorageKeys() ([]string, error) {
+	var keys []string
+
+	err := pr.DB.Model(&models.Photo{}).Where("storage_key <> ''").Pluck("storage_key", &keys).Error
+	if err != nil {
+		return keys, err
+	}
+
+	var itemKeys []string
+	err = pr.DB.Model(&models.PhotoItem{}).
+		Joins("JOIN photos ON photos.id = photo_items.photo_id AND photos.deleted_at IS NULL").
+		Where("photo_items.storage_key <> ''").
+		Pluck("photo_items.storage_key", &itemKeys).Error
+	if err != nil {
+		return keys, err
+	}
+	keys = append(keys, itemKeys...)
+
+	var variantKeys []string
+	err = pr.DB.Model(&models.PhotoVariant{}).
+		Joins("JOIN photos ON photos.id = photo_variants.photo_id AND photos.deleted_at IS NULL").
+		Where("photo_variants.storage_key <> ''").
+		Pluck("photo_variants.storage_key", &variantKeys).Error
+	if err != nil {
+		return keys, err
+	}
+	keys = append(keys, variantKeys...)
+
+	var stories []models.Story
+	err = pr.DB.Select("storage_keys").Find(&stories).Error
+	for _, story := range stories {
+		keys = append(keys, story.StorageKeys...)
+	}
+
+	return keys, err
+}
+
+// GetStorageUsage sums up the photos of userID and the bytes of their items
+// and variants and of their stories, which are stored alongside them.
+// Photos and stories are deleted for good, so their bytes are released with
+// them.
+func (pr *photoRepository) GetStorageUsage(userID int) (models.StorageUsage, error) {
+	var usage models.StorageUsage
+
+	err := pr.DB.Model(&models.Photo{}).Where("user_id = ?", userID).Count(&usage.Photos).Error
+	if err != nil {
+		return usage, err
+	}
+
+	var itemBytes int64
+	err = pr.DB.Model(&models.PhotoItem{}).
+		Joins("JOIN photos ON photos.id = photo_items.photo_id AND photos.deleted_at IS NULL").
+		Where("photos.user_id = ?", userID).
+		Select("COALESCE(SUM(photo_items.size), 0)").Scan(&itemBytes).Error
+	if err != nil {
+		return usage, err
+	}
+
+	var variantBytes int64
+	err = pr.DB.Model(&models.PhotoVariant{}).
+		Joins("JOIN photos ON photos.id = photo_variants.photo_id AND photos.deleted_at IS NULL").
+		Where("photos.user_id = ?", userID).
+		Select("COALESCE(SUM(photo_variants.size), 0)").Scan(&variantBytes).Error
+
+	if err != nil {
+		return usage, err
+	}
+
+	var storyBytes int64
+	err = pr.DB.Model(&models.Story{}).Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").Scan(&storyBytes).Error
+
+	usage.Bytes = itemBytes + variantBytes + storyBytes
+
+	return usage, err
+}
+
+// GetRevisions returns the revisions of a photo, newest first.
+func (pr *photoRepository) GetRevisions(photoID uint) ([]models.PhotoRevision, error) {
+	var revisions []models.PhotoRevision
+
+	err := pr.DB.Where("photo_id = ?", photoID).Order("id DESC").Find(&revisions).Error
+
+	return revisions, err
+}
+
+func (pr *photoRepository) FindRevision(photoID uint, revisionID int) (models.PhotoRevision, error) {
+	var revision models.PhotoRevision
+
+	err := pr.DB.Where("photo_id = ? AND id = ?", photoID, revisionID).First(&revision).Error
 
+	return revision, err
 }
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recording holds the statements run on a recordingConn, including the end
+// of transactions, and the rows each statement reports as affected.
+type recording struct {
+	statements []string
+	affected   int64
+}
+
+// recordingConn stands in for a MySQL connection. It records the statements
+// run on it instead of sending them to a database. Queries fail after being
+// recorded.
+type recordingConn struct {
+	*recording
+}
+
+type recordingResult int64
+
+func (r recordingResult) LastInsertId() (int64, error) { return 0, nil }
+func (r recordingResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+var errNoQueries = errors.New("recordingConn does not run queries")
+
+func (c recordingConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errNoQueries
+}
+
+func (c recordingConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.statements = append(c.statements, query)
+	return recordingResult(c.affected), nil
+}
+
+// QueryContext records query but fails, as there are no rows to return.
+func (c recordingConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	c.statements = append(c.statements, query)
+	return nil, errNoQueries
+}
+
+func (c recordingConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+// recordingDB is the recordingConn transactions are started on. Like
+// sql.Tx, a recordingTx cannot start another.
+type recordingDB struct {
+	recordingConn
+}
+
+func (db recordingDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &recordingTx{db.recordingConn}, nil
+}
+
+type recordingTx struct {
+	recordingConn
+}
+
+func (tx *recordingTx) Commit() error {
+	tx.statements = append(tx.statements, "COMMIT")
+	return nil
+}
+
+func (tx *recordingTx) Rollback() error {
+	tx.statements = append(tx.statements, "ROLLBACK")
+	return nil
+}
+
+// newRecordingDB opens a database on a recordingConn whose statements each
+// affect one row.
+func newRecordingDB(t *testing.T) (*gorm.DB, *recording) {
+	rec := &recording{affected: 1}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      recordingDB{recordingConn{rec}},
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, rec
+}
+
+func TestUpdatePhotoLeavesAssociationsAlone(t *testing.T) {
+	db, rec := newRecordingDB(t)
+
+	// The photo is loaded with its owner, tags and metadata, which may be
+	// stale by the time it is saved.
+	itemID := uint(1)
+	photo := models.Photo{
+		Model:   gorm.Model{ID: 1},
+		Title:   "Bali",
+		Caption: "Liburan ke #bali",
+		UserID:  1,
+		User:    models.User{Model: gorm.Model{ID: 1}, Username: "hanif", Password: "stale"},
+		Tags:    []models.Tag{{ID: 1, Name: "bali"}},
+		Metadata: &models.PhotoMetadata{
+			ID:      1,
+			PhotoID: 1,
+		},
+		Items: []models.PhotoItem{{
+			ID:       1,
+			PhotoID:  1,
+			AltText:  "Kuta beach",
+			Variants: []models.PhotoVariant{{ID: 1, PhotoID: 1, PhotoItemID: &itemID, Name: "thumb"}},
+		}},
+	}
+
+	_, ok, err := NewPhotoRepository(db).UpdatePhoto(photo, models.PhotoEdit{})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	var items, variants bool
+	for _, statement := range rec.statements {
+		for _, table := range []string{"`users`", "`tags`", "`photo_tags`", "`photo_metadata`", "`user_tags`"} {
+			assert.NotContains(t, statement, table)
+		}
+		items = items || strings.Contains(statement, "`photo_items`")
+		variants = variants || strings.Contains(statement, "`photo_variants`")
+	}
+	assert.True(t, items, "items are saved")
+	assert.True(t, variants, "variants of items are saved")
+}
+
+func TestUpdatePhotoSavesEditInOneTransaction(t *testing.T) {
+	db, rec := newRecordingDB(t)
+	photo := models.Photo{Model: gorm.Model{ID: 1}, Title: "Bali", Status: models.PhotoStatusDraft, UserID: 1}
+	edit := models.PhotoEdit{
+		Schedule: true,
+		Revision: &models.PhotoRevision{PhotoID: 1, UserID: 1},
+		Removed:  []models.PhotoItem{{ID: 2, PhotoID: 1}},
+	}
+
+	_, ok, err := NewPhotoRepository(db).UpdatePhoto(photo, edit)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	if assert.Len(t, rec.statements, 6) {
+		assert.Contains(t, rec.statements[0], "UPDATE `photos` SET `publish_at`=?,`status`=?")
+		assert.Contains(t, rec.statements[3], "INSERT INTO `photo_revisions`")
+		assert.Contains(t, rec.statements[4], "DELETE FROM `photo_items`")
+		assert.Equal(t, "COMMIT", rec.statements[5])
+	}
+
+	// The post was published after it was loaded, so nothing is saved.
+	rec.statements, rec.affected = nil, 0
+	_, ok, err = NewPhotoRepository(db).UpdatePhoto(photo, edit)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Len(t, rec.statements, 2)
+	assert.Equal(t, "ROLLBACK", rec.statements[len(rec.statements)-1])
+}
+
+func TestGetNearbyPhotosPagesInSQL(t *testing.T) {
+	db, rec := newRecordingDB(t)
+
+	_, err := NewPhotoRepository(db).GetNearbyPhotos(1, -6.2088, 106.8456, 5, helpers.BoundingBox(-6.2088, 106.8456, 5), 20, 40)
+	assert.ErrorIs(t, err, errNoQueries)
+	if assert.Len(t, rec.statements, 1) {
+		query := rec.statements[0]
+		assert.Contains(t, query, photoDistanceKm+" <= ?")
+		assert.Contains(t, query, "ORDER BY "+photoDistanceKm+", photos.id")
+		assert.Contains(t, query, "LIMIT 20 OFFSET 40")
+	}
+}
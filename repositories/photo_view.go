@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PhotoViewRepository interface {
+	AddViews(photoID uint, date string, views int64, viewerIDs []uint) error
+	GetViewCounts(photoID uint, from string) ([]models.PhotoViewCount, error)
+	GetCommentCounts(photoID uint, from string) ([]models.DailyCount, error)
+	GetRepostCounts(photoID uint, from string) ([]models.DailyCount, error)
+	DeleteViewersBefore(date string) error
+}
+
+type photoViewRepository struct {
+	DB *gorm.DB
+}
+
+func NewPhotoViewRepository(db *gorm.DB) *photoViewRepository {
+	return &photoViewRepository{db}
+}
+
+// AddViews adds views to the counter of photoID on date. Of viewerIDs, only
+// those that have not viewed the photo on date yet add to its unique
+// viewers.
+func (pr *photoViewRepository) AddViews(photoID uint, date string, views int64, viewerIDs []uint) error {
+	return pr.DB.Transaction(func(tx *gorm.DB) error {
+		var unique int64
+		if len(viewerIDs) > 0 {
+			viewers := make([]models.PhotoDailyViewer, 0, len(viewerIDs))
+			for _, viewerID := range viewerIDs {
+				viewers = append(viewers, models.PhotoDailyViewer{PhotoID: photoID, Date: date, ViewerID: viewerID})
+			}
+
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&viewers)
+			if result.Error != nil {
+				return result.Error
+			}
+			unique = result.RowsAffected
+		}
+
+		count := models.PhotoViewCount{PhotoID: photoID, Date: date, Views: views, UniqueViewers: unique}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "photo_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"views":          gorm.Expr("views + ?", views),
+				"unique_viewers": gorm.Expr("unique_viewers + ?", unique),
+			}),
+		}).Create(&count).Error
+	})
+}
+
+func (pr *photoViewRepository) GetViewCounts(photoID uint, from string) ([]models.PhotoViewCount, error) {
+	var counts []models.PhotoViewCount
+
+	err := pr.DB.Where("photo_id = ? AND date >= ?", photoID, from).Order("date ASC").Find(&counts).Error
+
+	return counts, err
+}
+
+// GetCommentCounts counts the comments on photoID per day since from.
+func (pr *photoViewRepository) GetCommentCounts(photoID uint, from string) ([]models.DailyCount, error) {
+	var counts []models.DailyCount
+
+	err := pr.DB.Model(&models.Comment{}).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS date, COUNT(*) AS count").
+		Where("photo_id = ? AND created_at >= ?", photoID, from).
+		Group("date").Order("date ASC").
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+// GetRepostCounts counts the reposts of photoID per day since from,
+// including those not shown while the photo cannot be reposted.
+func (pr *photoViewRepository) GetRepostCounts(photoID uint, from string) ([]models.DailyCount, error) {
+	var counts []models.DailyCount
+
+	err := pr.DB.Model(&models.Repost{}).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS date, COUNT(*) AS count").
+		Where("photo_id = ? AND created_at >= ?", photoID, from).
+		Group("date").Order("date ASC").
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+// DeleteViewersBefore prunes the unique viewers of the days before date,
+// whose counters are final.
+func (pr *photoViewRepository) DeleteViewersBefore(date string) error {
+	return pr.DB.Where("date < ?", date).Delete(&models.PhotoDailyViewer{}).Error
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+)
+
+type RepostRepository interface {
+	CreateRepost(repost models.Repost) (models.Repost, error)
+	FindByID(repostID int) (models.Repost, error)
+	FindRepost(userID int, photoID uint) (models.Repost, error)
+	DeleteRepost(repost models.Repost) error
+	GetPhotoReposts(photoID uint) ([]models.Repost, error)
+	GetFeedReposts(viewerID, limit int) ([]models.Repost, error)
+}
+
+type repostRepository struct {
+	DB *gorm.DB
+}
+
+func NewRepostRepository(db *gorm.DB) *repostRepository {
+	return &repostRepository{db}
+}
+
+// shownReposts limits a query on reposts to those whose photo can still be
+// reposted: published, public, not archived or hidden, and of an author who
+// allows reposts. Reposts of users that are deleted are left out as well.
+func shownReposts(db *gorm.DB) *gorm.DB {
+	return db.Joins("JOIN photos ON photos.id = reposts.photo_id AND photos.deleted_at IS NULL").
+		Joins("JOIN users AS authors ON authors.id = photos.user_id AND authors.deleted_at IS NULL").
+		Joins("JOIN users AS reposters ON reposters.id = reposts.user_id AND reposters.deleted_at IS NULL").
+		Where("photos.status = ? AND photos.visibility = ? AND photos.archived_at IS NULL AND photos.hidden_at IS NULL AND authors.allow_reposts = ?",
+			models.PhotoStatusPublished, models.PhotoVisibilityPublic, true)
+}
+
+// preloadRepost loads the reposter and the associations shown in responses
+// of the reposted photo.
+func preloadRepost(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").
+		Preload("Photo").
+		Preload("Photo.User").
+		Preload("Photo.UserTags", "status = ?", models.UserTagApproved).
+		Preload("Photo.UserTags.User").
+		Preload("Photo.Tags").
+		Preload("Photo.Variants").
+		Preload("Photo.Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("photo_items.position ASC, photo_items.id ASC")
+		}).
+		Preload("Photo.Items.Variants")
+}
+
+func (rr *repostRepository) CreateRepost(repost models.Repost) (models.Repost, error) {
+	err := rr.DB.Omit("User", "Photo").Create(&repost).Error
+	if err != nil {
+		return repost, err
+	}
+
+	err = preloadRepost(rr.DB).First(&repost, repost.ID).Error
+
+	return repost, err
+}
+
+func (rr *repostRepository) FindByID(repostID int) (models.Repost, error) {
+	var repost models.Repost
+
+	err := rr.DB.Where("id = ?", repostID).First(&repost).Error
+
+	return repost, err
+}
+
+func (rr *repostRepository) FindRepost(userID int, photoID uint) (models.Repost, error) {
+	var repost models.Repost
+
+	err := rr.DB.Where("user_id = ? AND photo_id = ?", userID, photoID).First(&repost).Error
+
+	return repost, err
+}
+
+func (rr *repostRepository) DeleteRepost(repost models.Repost) error {
+	return rr.DB.Delete(&repost).Error
+}
+
+// GetPhotoReposts returns the shown reposts of photoID, most recent first.
+func (rr *repostRepository) GetPhotoReposts(photoID uint) ([]models.Repost, error) {
+	var reposts []models.Repost
+
+	err := rr.DB.Preload("User").Scopes(shownReposts).
+		Where("reposts.photo_id = ?", photoID).
+		Order("reposts.created_at DESC").Find(&reposts).Error
+
+	return reposts, err
+}
+
+// GetFeedReposts returns the limit most recent shown reposts of viewerID and
+// the users they follow, newest first, leaving out sensitive photos the
+// viewer hides.
+func (rr *repostRepository) GetFeedReposts(viewerID, limit int) ([]models.Repost, error) {
+	var reposts []models.Repost
+
+	err := preloadRepost(rr.DB).Scopes(shownReposts, listablePhotos(viewerID)).
+		Where("reposts.user_id = ? OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = ? AND follows.following_id = reposts.user_id)", viewerID, viewerID).
+		Order("reposts.created_at DESC, reposts.id DESC").Limit(limit).Find(&reposts).Error
+
+	return reposts, err
+}
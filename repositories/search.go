@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// SearchIndex finds photos, users and comments matching a free text query.
+// The Index and Remove methods are called by the usecases after every write
+// so that implementations keeping their own index stay in sync. Search
+// leaves out what viewerID may not see before paging, where the
+// implementation is able to tell.
+type SearchIndex interface {
+	IndexPhoto(photo models.Photo) error
+	IndexUser(user models.User) error
+	IndexComment(comment models.Comment) error
+	Remove(docType string, id uint) error
+	Search(query string, types []string, viewerID, limit, offset int) ([]models.SearchHit, error)
+}
+
+type mysqlSearchIndex struct {
+	DB *gorm.DB
+}
+
+// NewMySQLSearchIndex returns a SearchIndex backed by the FULLTEXT indexes
+// declared on the photos, users and comments tables. MySQL keeps those indexes
+// up to date by itself, so the Index and Remove methods do nothing.
+func NewMySQLSearchIndex(db *gorm.DB) *mysqlSearchIndex {
+	return &mysqlSearchIndex{db}
+}
+
+func (si *mysqlSearchIndex) IndexPhoto(photo models.Photo) error {
+	return nil
+}
+
+func (si *mysqlSearchIndex) IndexUser(user models.User) error {
+	return nil
+}
+
+func (si *mysqlSearchIndex) IndexComment(comment models.Comment) error {
+	return nil
+}
+
+func (si *mysqlSearchIndex) Remove(docType string, id uint) error {
+	return nil
+}
+
+var mysqlSearchColumns = map[string]struct {
+	table   string
+	columns string
+	// visible limits the matching rows to those viewerID may see.
+	visible func(viewerID int) func(db *gorm.DB) *gorm.DB
+}{
+	models.SearchTypePhoto:   {"photos", "photos.title, photos.caption", searchablePhotos},
+	models.SearchTypeUser:    {"users", "users.full_name, users.username", searchableUsers},
+	models.SearchTypeComment: {"comments", "comments.message", searchableComments},
+}
+
+// searchablePhotos keeps the photos viewerID may see in listings, by users
+// who are not deleted.
+func searchablePhotos(viewerID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN users ON users.id = photos.user_id AND users.deleted_at IS NULL").
+			Scopes(visiblePhotos(viewerID), listablePhotos(viewerID))
+	}
+}
+
+// searchableUsers keeps every user who is not deleted.
+func searchableUsers(viewerID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+// searchableComments keeps the comments that are not hidden, by users who
+// are not deleted, on photos viewerID may see.
+func searchableComments(viewerID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN users ON users.id = comments.user_id AND users.deleted_at IS NULL").
+			Joins("JOIN photos ON photos.id = comments.photo_id AND photos.deleted_at IS NULL").
+			Where("comments.hidden_at IS NULL").
+			Scopes(visiblePhotos(viewerID))
+	}
+}
+
+// Search filters the matches in SQL, so that pages are full whenever there
+// are enough results viewerID may see.
+func (si *mysqlSearchIndex) Search(query string, types []string, viewerID, limit, offset int) ([]models.SearchHit, error) {
+	hits := []models.SearchHit{}
+
+	for _, docType := range types {
+		target, ok := mysqlSearchColumns[docType]
+		if !ok {
+			continue
+		}
+
+		var rows []struct {
+			ID    uint
+			Score float64
+		}
+
+		match := "MATCH(" + target.columns + ") AGAINST (? IN NATURAL LANGUAGE MODE)"
+		err := si.DB.Table(target.table).
+			Select(target.table+".id, "+match+" AS score", query).
+			Where(target.table+".deleted_at IS NULL AND "+match, query).
+			Scopes(target.visible(viewerID)).
+			Order("score DESC").
+			Limit(offset + limit).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			hits = append(hits, models.SearchHit{Type: docType, ID: row.ID, Score: row.Score})
+		}
+	}
+
+	return pageSearchHits(hits, limit, offset), nil
+}
+
+// pageSearchHits orders hits by score, newest record first on ties, and
+// returns the requested page.
+func pageSearchHits(hits []models.SearchHit, limit, offset int) []models.SearchHit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].ID != hits[j].ID {
+			return hits[i].ID > hits[j].ID
+		}
+		return hits[i].Type < hits[j].Type
+	})
+
+	if offset >= len(hits) {
+		return []models.SearchHit{}
+	}
+	hits = hits[offset:]
+	if limit < len(hits) {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
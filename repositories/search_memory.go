@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"math"
+	"mini-project-alterra/models"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// BM25 tuning, using the usual defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type searchDocKey struct {
+	docType string
+	id      uint
+}
+
+type memorySearchIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[searchDocKey]int
+	docTerms map[searchDocKey][]string
+	totalLen map[string]int
+}
+
+// NewMemorySearchIndex returns an in-process inverted index ranking matches
+// with BM25. It needs no database and is meant for tests and local runs.
+// Without a database it cannot tell what a viewer may see, so it returns
+// every match and leaves filtering to the caller.
+func NewMemorySearchIndex() *memorySearchIndex {
+	return &memorySearchIndex{
+		postings: map[string]map[searchDocKey]int{},
+		docTerms: map[searchDocKey][]string{},
+		totalLen: map[string]int{},
+	}
+}
+
+// tokenize splits text into lower case words made of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func (si *memorySearchIndex) index(key searchDocKey, text string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(key)
+
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return
+	}
+
+	for _, term := range terms {
+		if si.postings[term] == nil {
+			si.postings[term] = map[searchDocKey]int{}
+		}
+		si.postings[term][key]++
+	}
+	si.docTerms[key] = terms
+	si.totalLen[key.docType] += len(terms)
+}
+
+// remove drops key from the index. The caller must hold the write lock.
+func (si *memorySearchIndex) remove(key searchDocKey) {
+	terms, ok := si.docTerms[key]
+	if !ok {
+		return
+	}
+
+	for _, term := range terms {
+		delete(si.postings[term], key)
+		if len(si.postings[term]) == 0 {
+			delete(si.postings, term)
+		}
+	}
+	si.totalLen[key.docType] -= len(terms)
+	delete(si.docTerms, key)
+}
+
+func (si *memorySearchIndex) IndexPhoto(photo models.Photo) error {
+	si.index(searchDocKey{models.SearchTypePhoto, photo.ID}, photo.Title+" "+photo.Caption)
+	return nil
+}
+
+func (si *memorySearchIndex) IndexUser(user models.User) error {
+	si.index(searchDocKey{models.SearchTypeUser, user.ID}, user.FullName+" "+user.Username)
+	return nil
+}
+
+func (si *memorySearchIndex) IndexComment(comment models.Comment) error {
+	si.index(searchDocKey{models.SearchTypeComment, comment.ID}, comment.Message)
+	return nil
+}
+
+func (si *memorySearchIndex) Remove(docType string, id uint) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(searchDocKey{docType, id})
+	return nil
+}
+
+func (si *memorySearchIndex) Search(query string, types []string, viewerID, limit, offset int) ([]models.SearchHit, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, docType := range types {
+		wanted[docType] = true
+	}
+
+	docCount := map[string]int{}
+	for key := range si.docTerms {
+		docCount[key.docType]++
+	}
+
+	scores := map[searchDocKey]float64{}
+	seen := map[string]bool{}
+	for _, term := range tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		// Document frequency and collection size are counted per type so
+		// that, for example, a rare word in comments is not penalised by the
+		// number of photos.
+		df := map[string]int{}
+		for key := range si.postings[term] {
+			df[key.docType]++
+		}
+
+		for key, tf := range si.postings[term] {
+			if !wanted[key.docType] {
+				continue
+			}
+
+			n := float64(docCount[key.docType])
+			avgLen := float64(si.totalLen[key.docType]) / n
+			idf := math.Log(1 + (n-float64(df[key.docType])+0.5)/(float64(df[key.docType])+0.5))
+			docLen := float64(len(si.docTerms[key]))
+			freq := float64(tf)
+
+			scores[key] += idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+		}
+	}
+
+	hits := make([]models.SearchHit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, models.SearchHit{Type: key.docType, ID: key.id, Score: score})
+	}
+
+	return pageSearchHits(hits, limit, offset), nil
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newTestSearchIndex() *memorySearchIndex {
+	index := NewMemorySearchIndex()
+
+	index.IndexPhoto(models.Photo{Model: gorm.Model{ID: 1}, Title: "Bali", Caption: "Liburan ke bali, pantai kuta"})
+	index.IndexPhoto(models.Photo{Model: gorm.Model{ID: 2}, Title: "Jakarta", Caption: "Macet di jakarta"})
+	index.IndexPhoto(models.Photo{Model: gorm.Model{ID: 3}, Title: "Sunset", Caption: "Sunset di pantai bali yang sangat indah sekali hari ini"})
+	index.IndexUser(models.User{Model: gorm.Model{ID: 1}, FullName: "Mochammad Hanif", Username: "hanif"})
+	index.IndexComment(models.Comment{Model: gorm.Model{ID: 1}, Message: "Wah keren, bali memang indah!"})
+
+	return index
+}
+
+func TestMemorySearchIndexRanking(t *testing.T) {
+	index := newTestSearchIndex()
+
+	hits, err := index.Search("bali", models.SearchTypes, 0, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 3)
+
+	// "bali" appears twice in the short first photo, so it ranks highest.
+	assert.Equal(t, models.SearchHit{Type: models.SearchTypePhoto, ID: 1, Score: hits[0].Score}, hits[0])
+	for i := 1; i < len(hits); i++ {
+		assert.GreaterOrEqual(t, hits[i-1].Score, hits[i].Score)
+	}
+}
+
+func TestMemorySearchIndexTypeFilter(t *testing.T) {
+	index := newTestSearchIndex()
+
+	hits, err := index.Search("bali hanif", []string{models.SearchTypeUser, models.SearchTypeComment}, 0, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 2)
+	for _, hit := range hits {
+		assert.NotEqual(t, models.SearchTypePhoto, hit.Type)
+	}
+}
+
+func TestMemorySearchIndexUpdates(t *testing.T) {
+	index := newTestSearchIndex()
+
+	index.IndexPhoto(models.Photo{Model: gorm.Model{ID: 2}, Title: "Jakarta", Caption: "Hujan di jakarta"})
+	hits, _ := index.Search("macet", models.SearchTypes, 0, 10, 0)
+	assert.Empty(t, hits)
+	hits, _ = index.Search("HUJAN", models.SearchTypes, 0, 10, 0)
+	assert.Len(t, hits, 1)
+
+	index.Remove(models.SearchTypeComment, 1)
+	hits, _ = index.Search("keren", models.SearchTypes, 0, 10, 0)
+	assert.Empty(t, hits)
+}
+
+func TestMemorySearchIndexPaging(t *testing.T) {
+	index := newTestSearchIndex()
+
+	all, _ := index.Search("bali", models.SearchTypes, 0, 10, 0)
+	page, _ := index.Search("bali", models.SearchTypes, 0, 1, 1)
+	assert.Equal(t, all[1:2], page)
+
+	page, _ = index.Search("bali", models.SearchTypes, 0, 10, 10)
+	assert.Empty(t, page)
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoryRepository interface {
+	CreateStory(story models.Story) (models.Story, error)
+	FindByID(storyID int) (models.Story, error)
+	DeleteStory(story models.Story) error
+	GetActiveStories(userID int, now time.Time) ([]models.Story, error)
+	GetTrayStories(viewerID int, now time.Time) ([]models.Story, error)
+	GetArchivedStories(userID int, now time.Time) ([]models.Story, error)
+	GetExpiredStories(before time.Time, limit int) ([]models.Story, error)
+	GetStoriesOfDeletedUsers(limit int) ([]models.Story, error)
+	AddView(view models.StoryView) error
+	GetSeenStoryIDs(viewerID int, storyIDs []uint) ([]uint, error)
+	GetViewers(storyID uint) ([]models.StoryView, error)
+	CreateHighlight(highlight models.StoryHighlight) (models.StoryHighlight, error)
+	FindHighlight(highlightID int) (models.StoryHighlight, error)
+	GetHighlights(userID int) ([]models.StoryHighlight, error)
+	AddHighlightStories(highlight models.StoryHighlight, stories []models.Story) error
+	DeleteHighlight(highlight models.StoryHighlight) error
+}
+
+type storyRepository struct {
+	DB *gorm.DB
+}
+
+func NewStoryRepository(db *gorm.DB) *storyRepository {
+	return &storyRepository{db}
+}
+
+func (sr *storyRepository) CreateStory(story models.Story) (models.Story, error) {
+	err := sr.DB.Create(&story).Error
+	return story, err
+}
+
+func (sr *storyRepository) FindByID(storyID int) (models.Story, error) {
+	var story models.Story
+
+	err := sr.DB.Preload("User").Where("id = ?", storyID).First(&story).Error
+
+	return story, err
+}
+
+// DeleteStory removes story. Its views and its place in highlights go with
+// it through their foreign keys.
+func (sr *storyRepository) DeleteStory(story models.Story) error {
+	return sr.DB.Delete(&story).Error
+}
+
+// GetActiveStories returns the stories of userID that have not expired at
+// now, oldest first.
+func (sr *storyRepository) GetActiveStories(userID int, now time.Time) ([]models.Story, error) {
+	var stories []models.Story
+
+	err := sr.DB.Preload("User").
+		Where("user_id = ? AND expires_at > ?", userID, now).
+		Order("created_at ASC").Find(&stories).Error
+
+	return stories, err
+}
+
+// GetTrayStories returns the active stories of the users viewerID follows.
+func (sr *storyRepository) GetTrayStories(viewerID int, now time.Time) ([]models.Story, error) {
+	var stories []models.Story
+
+	err := sr.DB.Preload("User").
+		Joins("JOIN follows ON follows.following_id = stories.user_id AND follows.follower_id = ?", viewerID).
+		Joins("JOIN users ON users.id = stories.user_id AND users.deleted_at IS NULL").
+		Where("stories.expires_at > ?", now).
+		Order("stories.created_at ASC").Find(&stories).Error
+
+	return stories, err
+}
+
+// GetArchivedStories returns the expired stories of userID that are still
+// kept, newest first.
+func (sr *storyRepository) GetArchivedStories(userID int, now time.Time) ([]models.Story, error) {
+	var stories []models.Story
+
+	err := sr.DB.Where("user_id = ? AND expires_at <= ?", userID, now).
+		Order("created_at DESC").Find(&stories).Error
+
+	return stories, err
+}
+
+// GetExpiredStories returns up to limit stories that expired before before
+// and are in no highlight.
+func (sr *storyRepository) GetExpiredStories(before time.Time, limit int) ([]models.Story, error) {
+	var stories []models.Story
+
+	err := sr.DB.Where("expires_at <= ? AND NOT EXISTS (SELECT 1 FROM highlight_stories WHERE highlight_stories.story_id = stories.id)", before).
+		Order("expires_at ASC").Limit(limit).Find(&stories).Error
+
+	return stories, err
+}
+
+// GetStoriesOfDeletedUsers returns up to limit stories, highlighted or not,
+// whose user has deleted their account.
+func (sr *storyRepository) GetStoriesOfDeletedUsers(limit int) ([]models.Story, error) {
+	var stories []models.Story
+
+	err := sr.DB.Joins("JOIN users ON users.id = stories.user_id AND users.deleted_at IS NOT NULL").
+		Order("stories.id ASC").Limit(limit).Find(&stories).Error
+
+	return stories, err
+}
+
+// AddView records that the viewer has seen the story. Seeing it again
+// keeps the first view.
+func (sr *storyRepository) AddView(view models.StoryView) error {
+	return sr.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&view).Error
+}
+
+func (sr *storyRepository) GetSeenStoryIDs(viewerID int, storyIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(storyIDs) == 0 {
+		return ids, nil
+	}
+
+	err := sr.DB.Model(&models.StoryView{}).
+		Where("viewer_id = ? AND story_id IN ?", viewerID, storyIDs).
+		Pluck("story_id", &ids).Error
+
+	return ids, err
+}
+
+// GetViewers returns who has seen the story, most recent first.
+func (sr *storyRepository) GetViewers(storyID uint) ([]models.StoryView, error) {
+	var views []models.StoryView
+
+	err := sr.DB.Preload("Viewer").
+		Joins("JOIN users ON users.id = story_views.viewer_id AND users.deleted_at IS NULL").
+		Where("story_views.story_id = ?", storyID).
+		Order("story_views.created_at DESC").Find(&views).Error
+
+	return views, err
+}
+
+// CreateHighlight saves highlight and links it to its stories, which have to
+// exist already.
+func (sr *storyRepository) CreateHighlight(highlight models.StoryHighlight) (models.StoryHighlight, error) {
+	err := sr.DB.Omit("Stories.*").Create(&highlight).Error
+	return highlight, err
+}
+
+func preloadHighlightStories(db *gorm.DB) *gorm.DB {
+	return db.Preload("Stories", func(db *gorm.DB) *gorm.DB {
+		return db.Order("stories.created_at ASC")
+	})
+}
+
+func (sr *storyRepository) FindHighlight(highlightID int) (models.StoryHighlight, error) {
+	var highlight models.StoryHighlight
+
+	err := preloadHighlightStories(sr.DB).Where("id = ?", highlightID).First(&highlight).Error
+
+	return highlight, err
+}
+
+func (sr *storyRepository) GetHighlights(userID int) ([]models.StoryHighlight, error) {
+	var highlights []models.StoryHighlight
+
+	err := preloadHighlightStories(sr.DB).Where("user_id = ?", userID).
+		Order("created_at ASC").Find(&highlights).Error
+
+	return highlights, err
+}
+
+func (sr *storyRepository) AddHighlightStories(highlight models.StoryHighlight, stories []models.Story) error {
+	return sr.DB.Model(&highlight).Omit("Stories.*").Association("Stories").Append(stories)
+}
+
+// DeleteHighlight removes highlight. Its stories are kept until the story
+// expirer removes them.
+func (sr *storyRepository) DeleteHighlight(highlight models.StoryHighlight) error {
+	return sr.DB.Select("Stories").Delete(&highlight).Error
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository interface {
+	FirstOrCreateTags(names []string) ([]models.Tag, error)
+	SetPhotoTags(photo models.Photo, tags []models.Tag) error
+	GetTagByName(name string) (models.Tag, error)
+	CountPhotosByTag(tagID uint, viewerID int) (int64, error)
+	GetPhotosByTag(tagID uint, viewerID, limit, offset int) ([]models.Photo, error)
+}
+
+type tagRepository struct {
+	DB *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *tagRepository {
+	return &tagRepository{db}
+}
+
+func (tr *tagRepository) FirstOrCreateTags(names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	newTags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		newTags = append(newTags, models.Tag{Name: name})
+	}
+
+	err := tr.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&newTags).Error
+	if err != nil {
+		return tags, err
+	}
+
+	err = tr.DB.Where("name IN ?", names).Find(&tags).Error
+
+	return tags, err
+}
+
+// SetPhotoTags replaces every tag link of photo with tags.
+func (tr *tagRepository) SetPhotoTags(photo models.Photo, tags []models.Tag) error {
+	return tr.DB.Model(&photo).Association("Tags").Replace(tags)
+}
+
+func (tr *tagRepository) GetTagByName(name string) (models.Tag, error) {
+	var tag models.Tag
+
+	err := tr.DB.Where("name = ?", name).First(&tag).Error
+
+	return tag, err
+}
+
+func (tr *tagRepository) CountPhotosByTag(tagID uint, viewerID int) (int64, error) {
+	var count int64
+
+	err := tr.DB.Model(&models.Photo{}).
+		Joins("JOIN photo_tags ON photo_tags.photo_id = photos.id").
+		Joins("JOIN users ON users.id = photos.user_id").
+		Where("photo_tags.tag_id = ? AND users.deleted_at IS NULL", tagID).
+		Scopes(visiblePhotos(viewerID), listablePhotos(viewerID)).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (tr *tagRepository) GetPhotosByTag(tagID uint, viewerID, limit, offset int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := preloadPhoto(tr.DB).Joins("JOIN photo_tags ON photo_tags.photo_id = photos.id").
+		Joins("JOIN users ON users.id = photos.user_id").
+		Where("photo_tags.tag_id = ? AND users.deleted_at IS NULL", tagID).
+		Scopes(visiblePhotos(viewerID), listablePhotos(viewerID)).
+		Order("COALESCE(photos.publish_at, photos.created_at) DESC").
+		Limit(limit).Offset(offset).
+		Find(&photos).Error
+
+	return photos, err
+}
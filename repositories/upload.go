@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UploadRepository interface {
+	CreateUpload(upload models.Upload) (models.Upload, error)
+	FindUpload(id string) (models.Upload, error)
+	CountActiveUploads(userID int, now time.Time) (int64, error)
+	UpdateOffset(upload models.Upload, from int64) (bool, error)
+	DeleteUpload(upload models.Upload) error
+	GetExpiredUploads(now time.Time, limit int) ([]models.Upload, error)
+	CreatePresignedUpload(upload models.PresignedUpload) (models.PresignedUpload, error)
+	FindPresignedUpload(id string) (models.PresignedUpload, error)
+	CountActivePresignedUploads(userID int, now time.Time) (int64, error)
+	DeletePresignedUpload(upload models.PresignedUpload) error
+	GetExpiredPresignedUploads(now time.Time, limit int) ([]models.PresignedUpload, error)
+}
+
+type uploadRepository struct {
+	DB *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) *uploadRepository {
+	return &uploadRepository{db}
+}
+
+func (ur *uploadRepository) CreateUpload(upload models.Upload) (models.Upload, error) {
+	err := ur.DB.Create(&upload).Error
+	return upload, err
+}
+
+func (ur *uploadRepository) FindUpload(id string) (models.Upload, error) {
+	var upload models.Upload
+
+	err := ur.DB.Where("id = ?", id).First(&upload).Error
+
+	return upload, err
+}
+
+// CountActiveUploads counts the uploads of userID that are not complete and
+// have not expired.
+func (ur *uploadRepository) CountActiveUploads(userID int, now time.Time) (int64, error) {
+	var count int64
+
+	err := ur.DB.Model(&models.Upload{}).
+		Where("user_id = ? AND upload_offset < length AND expires_at > ?", userID, now).
+		Count(&count).Error
+
+	return count, err
+}
+
+// UpdateOffset saves the offset and expiry time of upload. It reports false
+// when the stored offset is no longer from, because another request wrote to
+// the upload in the meantime.
+func (ur *uploadRepository) UpdateOffset(upload models.Upload, from int64) (bool, error) {
+	result := ur.DB.Model(&models.Upload{}).
+		Where("id = ? AND upload_offset = ?", upload.ID, from).
+		Updates(map[string]interface{}{"upload_offset": upload.Offset, "expires_at": upload.ExpiresAt})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (ur *uploadRepository) DeleteUpload(upload models.Upload) error {
+	return ur.DB.Delete(&upload).Error
+}
+
+// GetExpiredUploads returns up to limit uploads that expired before now,
+// oldest first.
+func (ur *uploadRepository) GetExpiredUploads(now time.Time, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+
+	err := ur.DB.Where("expires_at <= ?", now).Order("expires_at ASC").Limit(limit).Find(&uploads).Error
+
+	return uploads, err
+}
+
+func (ur *uploadRepository) CreatePresignedUpload(upload models.PresignedUpload) (models.PresignedUpload, error) {
+	err := ur.DB.Create(&upload).Error
+	return upload, err
+}
+
+func (ur *uploadRepository) FindPresignedUpload(id string) (models.PresignedUpload, error) {
+	var upload models.PresignedUpload
+
+	err := ur.DB.Where("id = ?", id).First(&upload).Error
+
+	return upload, err
+}
+
+// CountActivePresignedUploads counts the direct uploads of userID that have
+// not been finalized and have not expired.
+func (ur *uploadRepository) CountActivePresignedUploads(userID int, now time.Time) (int64, error) {
+	var count int64
+
+	err := ur.DB.Model(&models.PresignedUpload{}).
+		Where("user_id = ? AND expires_at > ?", userID, now).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (ur *uploadRepository) DeletePresignedUpload(upload models.PresignedUpload) error {
+	return ur.DB.Delete(&upload).Error
+}
+
+// GetExpiredPresignedUploads returns up to limit direct uploads that expired
+// before now without being finalized, oldest first.
+func (ur *uploadRepository) GetExpiredPresignedUploads(now time.Time, limit int) ([]models.PresignedUpload, error) {
+	var uploads []models.PresignedUpload
+
+	err := ur.DB.Where("expires_at <= ?", now).Order("expires_at ASC").Limit(limit).Find(&uploads).Error
+
+	return uploads, err
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"mini-project-alterra/models"
+
+	"gorm.io/gorm"
+)
+
+type UserTagRepository interface {
+	CreateUserTag(tag models.UserTag) (models.UserTag, error)
+	FindByID(tagID int) (models.UserTag, error)
+	CountPhotoUserTags(photoID uint) (int64, error)
+	IsTagged(photoID, userID uint) (bool, error)
+	ApproveUserTag(tag models.UserTag) error
+	DeleteUserTag(tag models.UserTag) error
+	GetPendingUserTags(userID int) ([]models.UserTag, error)
+	GetTaggedPhotos(userID uint, viewerID, limit, offset int) ([]models.Photo, error)
+}
+
+type userTagRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserTagRepository(db *gorm.DB) *userTagRepository {
+	return &userTagRepository{db}
+}
+
+func (tr *userTagRepository) CreateUserTag(tag models.UserTag) (models.UserTag, error) {
+	err := tr.DB.Create(&tag).Error
+	if err != nil {
+		return tag, err
+	}
+
+	return tr.FindByID(int(tag.ID))
+}
+
+func (tr *userTagRepository) FindByID(tagID int) (models.UserTag, error) {
+	var tag models.UserTag
+
+	err := tr.DB.Preload("User").Preload("Photo").First(&tag, tagID).Error
+
+	return tag, err
+}
+
+func (tr *userTagRepository) CountPhotoUserTags(photoID uint) (int64, error) {
+	var count int64
+
+	err := tr.DB.Model(&models.UserTag{}).Where("photo_id = ?", photoID).Count(&count).Error
+
+	return count, err
+}
+
+func (tr *userTagRepository) IsTagged(photoID, userID uint) (bool, error) {
+	var count int64
+
+	err := tr.DB.Model(&models.UserTag{}).Where("photo_id = ? AND user_id = ?", photoID, userID).Count(&count).Error
+
+	return count > 0, err
+}
+
+func (tr *userTagRepository) ApproveUserTag(tag models.UserTag) error {
+	return tr.DB.Model(&tag).Update("status", models.UserTagApproved).Error
+}
+
+func (tr *userTagRepository) DeleteUserTag(tag models.UserTag) error {
+	return tr.DB.Delete(&tag).Error
+}
+
+// GetPendingUserTags returns the tags of userID waiting for approval, newest
+// first, leaving out photos that are not published yet.
+func (tr *userTagRepository) GetPendingUserTags(userID int) ([]models.UserTag, error) {
+	var tags []models.UserTag
+
+	err := tr.DB.Joins("JOIN photos ON photos.id = user_tags.photo_id AND photos.deleted_at IS NULL").
+		Where("user_tags.user_id = ? AND user_tags.status = ? AND photos.status = ?", userID, models.UserTagPending, models.PhotoStatusPublished).
+		Preload("User").Preload("Photo").Order("user_tags.created_at DESC").Find(&tags).Error
+
+	return tags, err
+}
+
+// GetTaggedPhotos returns the photos viewerID may see in which userID has
+// an approved tag, most recently tagged first.
+func (tr *userTagRepository) GetTaggedPhotos(userID uint, viewerID, limit, offset int) ([]models.Photo, error) {
+	var photos []models.Photo
+
+	err := preloadPhoto(tr.DB).
+		Joins("JOIN user_tags ON user_tags.photo_id = photos.id").
+		Where("user_tags.user_id = ? AND user_tags.status = ?", userID, models.UserTagApproved).
+		Scopes(visiblePhotos(viewerID), listablePhotos(viewerID)).
+		Order("user_tags.created_at DESC").Limit(limit).Offset(offset).Find(&photos).Error
+
+	return photos, err
+}
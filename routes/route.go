@@ -2,9 +2,13 @@ package routes
 
 import (
 	"log"
+	"mini-project-alterra/configs"
 	"mini-project-alterra/controllers"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
 	"mini-project-alterra/repositories"
 	"mini-project-alterra/usecases"
+	"net/url"
 	"os"
 
 	"github.com/joho/godotenv"
@@ -18,7 +22,10 @@ func init() {
 	middleware.ErrJWTMissing.Message = "Unauthorized"
 }
 
-func New(e *echo.Echo, db *gorm.DB) {
+// New registers the routes on e and starts the background workers. The
+// returned function must be called on shutdown, once the server has
+// stopped, to write the views that are still counted in memory.
+func New(e *echo.Echo, db *gorm.DB) func() {
 
 	err := godotenv.Load()
 	if err != nil {
@@ -27,27 +34,125 @@ func New(e *echo.Echo, db *gorm.DB) {
 
 	jwtMiddleware := middleware.JWT([]byte(os.Getenv("SECRET_JWT")))
 
+	searchIndex := repositories.NewMySQLSearchIndex(db)
+
 	userRepository := repositories.NewUserRepository(db)
-	userUsecase := usecases.NewUserUsecase(userRepository)
+	userUsecase := usecases.NewUserUsecase(userRepository, searchIndex)
 	userController := controllers.NewUserController(userUsecase)
 
+	followRepository := repositories.NewFollowRepository(db)
+	blockRepository := repositories.NewBlockRepository(db)
+	followUsecase := usecases.NewFollowUsecase(followRepository, userRepository, blockRepository)
+	followController := controllers.NewFollowController(userUsecase, followUsecase)
+
 	socialMediaRepository := repositories.NewSocialMediaRepository(db)
 	socialMediaUsecase := usecases.NewSocialMediaUsecase(socialMediaRepository)
 	socialMediaController := controllers.NewSocialMediaController(userUsecase, socialMediaUsecase)
 
+	tagRepository := repositories.NewTagRepository(db)
+	tagUsecase := usecases.NewTagUsecase(tagRepository)
+	tagController := controllers.NewTagController(userUsecase, tagUsecase)
+
+	mediaStore, err := helpers.NewMediaStore(configs.EnvStorageBackend())
+	if err != nil {
+		log.Fatal(err)
+	}
+	mediaUpload := usecases.NewMediaUpload(mediaStore, helpers.ImageLimitsFromEnv())
+
 	photoRepository := repositories.NewPhotoRepository(db)
-	photoUsecase := usecases.NewPhotoUsecase(photoRepository)
-	photoController := controllers.NewPhotoController(userUsecase, photoUsecase)
+	photoUsecase := usecases.NewPhotoUsecase(photoRepository, tagRepository, searchIndex, mediaUpload)
+	uploadRepository := repositories.NewUploadRepository(db)
+	photoViewUsecase := usecases.NewPhotoViewUsecase(repositories.NewPhotoViewRepository(db), photoRepository, configs.EnvPhotoViewWindow())
+	quotaUsecase := usecases.NewQuotaUsecase(photoRepository, userRepository, configs.EnvStorageQuotas())
+	quotaController := controllers.NewQuotaController(userUsecase, quotaUsecase)
+	uploadUsecase := usecases.NewUploadUsecase(uploadRepository, mediaUpload, quotaUsecase, configs.EnvUploadStagingDir(), helpers.ImageLimitsFromEnv().MaxBytes, configs.EnvUploadExpiry(), configs.EnvUploadPresignExpiry())
+	uploadController := controllers.NewUploadController(userUsecase, uploadUsecase)
+	repostUsecase := usecases.NewRepostUsecase(repositories.NewRepostRepository(db), photoRepository, blockRepository)
+	repostController := controllers.NewRepostController(userUsecase, repostUsecase)
+	photoController := controllers.NewPhotoController(userUsecase, photoUsecase, mediaUpload, uploadUsecase, quotaUsecase, photoViewUsecase, repostUsecase)
+
+	if interval := configs.EnvMediaSweepInterval(); interval > 0 {
+		mediaSweeper := usecases.NewMediaSweeper(mediaStore, photoRepository, configs.EnvMediaSweepGrace())
+		usecases.StartMediaSweeper(mediaSweeper, interval, configs.EnvMediaSweepDryRun())
+	}
+
+	if interval := configs.EnvPhotoScheduleInterval(); interval > 0 {
+		usecases.StartPhotoScheduler(photoUsecase, interval)
+	}
+
+	flushViews := func() {
+		_, err := photoViewUsecase.FlushViews()
+		if err != nil {
+			log.Printf("photo view flusher: %v", err)
+		}
+	}
+	if interval := configs.EnvPhotoViewFlushInterval(); interval > 0 {
+		flushViews = usecases.StartPhotoViewFlusher(photoViewUsecase, interval)
+	}
+
+	if interval := configs.EnvUploadExpireInterval(); interval > 0 {
+		usecases.StartUploadExpirer(uploadUsecase, interval)
+	}
 
 	commentRepository := repositories.NewCommentRepository(db)
-	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository)
+	commentUsecase := usecases.NewCommentUsecase(commentRepository, photoRepository, searchIndex, followRepository)
 	commentController := controllers.NewCommentController(userUsecase, commentUsecase)
 
+	searchUsecase := usecases.NewSearchUsecase(searchIndex, photoRepository, userRepository, commentRepository, followRepository)
+	searchController := controllers.NewSearchController(userUsecase, searchUsecase)
+
+	userTagRepository := repositories.NewUserTagRepository(db)
+	userTagUsecase := usecases.NewUserTagUsecase(userTagRepository, photoRepository, userRepository, followRepository, blockRepository)
+	userTagController := controllers.NewUserTagController(userUsecase, userTagUsecase)
+
+	albumRepository := repositories.NewAlbumRepository(db)
+	albumUsecase := usecases.NewAlbumUsecase(albumRepository, photoRepository, userRepository, followRepository)
+	albumController := controllers.NewAlbumController(userUsecase, albumUsecase)
+
+	moderationRepository := repositories.NewModerationRepository(db)
+	moderationUsecase := usecases.NewModerationUsecase(moderationRepository, userRepository, photoRepository, commentRepository, socialMediaRepository, searchIndex, mediaUpload, configs.EnvReportHideThreshold())
+	moderationController := controllers.NewModerationController(userUsecase, moderationUsecase)
+
+	storyRepository := repositories.NewStoryRepository(db)
+	storyUsecase := usecases.NewStoryUsecase(storyRepository, userRepository, followRepository, blockRepository, mediaUpload, configs.EnvStoryArchiveRetention())
+	storyController := controllers.NewStoryController(userUsecase, storyUsecase, mediaUpload, quotaUsecase)
+
+	if interval := configs.EnvStoryExpireInterval(); interval > 0 {
+		usecases.StartStoryExpirer(storyUsecase, interval)
+	}
+
+	if interval := configs.EnvAccountPurgeInterval(); interval > 0 {
+		accountPurger := usecases.NewAccountPurger(photoRepository, storyRepository, searchIndex, mediaUpload)
+		usecases.StartAccountPurger(accountPurger, interval)
+	}
+
+	if localStore, ok := mediaStore.(*helpers.LocalMediaStore); ok {
+		urlExpiry := configs.EnvStorageURLExpiry()
+		models.SetMediaURLSigner(func(url string) string {
+			return localStore.SignMediaURL(url, urlExpiry)
+		})
+
+		mediaController := controllers.NewMediaController(localStore, helpers.ImageLimitsFromEnv().MaxBytes)
+		baseURL, err := url.Parse(localStore.BaseURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		e.GET(baseURL.Path+"/*", mediaController.ServeMedia)
+		e.PUT(baseURL.Path+"/*", mediaController.UploadMedia)
+	}
+
 	e.POST("/users/login", userController.SignIn)
 	e.POST("/users/register", userController.SignUp)
 	e.GET("/users", userController.GetCredential, jwtMiddleware)
 	e.PATCH("/users", userController.UpdateUser, jwtMiddleware)
+	e.PATCH("/users/settings", userController.UpdateSettings, jwtMiddleware)
 	e.DELETE("/users", userController.DeleteUser, jwtMiddleware)
+	e.GET("/users/usage", quotaController.GetUsage, jwtMiddleware)
+	e.POST("/users/:username/follow", followController.FollowUser, jwtMiddleware)
+	e.DELETE("/users/:username/follow", followController.UnfollowUser, jwtMiddleware)
+	e.POST("/users/:username/block", followController.BlockUser, jwtMiddleware)
+	e.DELETE("/users/:username/block", followController.UnblockUser, jwtMiddleware)
+	e.GET("/users/:username/tagged", userTagController.GetTaggedPhotos, jwtMiddleware)
 
 	e.GET("/socialmedia", socialMediaController.GetMySocialMedia, jwtMiddleware)
 	e.GET("/socialmedia/:id", socialMediaController.GetMySocialMediaByID, jwtMiddleware)
@@ -59,9 +164,33 @@ func New(e *echo.Echo, db *gorm.DB) {
 	e.GET("/photo", photoController.GetMyPhoto, jwtMiddleware)
 	e.GET("/photo/:id", photoController.GetMyPhotoByID, jwtMiddleware)
 	e.GET("/photos", photoController.GetPhotos, jwtMiddleware)
+	e.GET("/photos/drafts", photoController.GetDrafts, jwtMiddleware)
+	e.GET("/photos/nearby", photoController.GetNearbyPhotos, jwtMiddleware)
 	e.POST("/photos", photoController.CreatePhoto, jwtMiddleware)
+	e.GET("/photos/:id", photoController.GetPhoto, jwtMiddleware)
 	e.PATCH("/photos/:id", photoController.UpdatePhoto, jwtMiddleware)
 	e.DELETE("/photos/:id", photoController.DeletePhoto, jwtMiddleware)
+	e.GET("/photos/:id/revisions", photoController.GetRevisions, jwtMiddleware)
+	e.GET("/photos/:id/insights", photoController.GetInsights, jwtMiddleware)
+	e.POST("/photos/:id/archive", photoController.ArchivePhoto, jwtMiddleware)
+	e.POST("/photos/:id/unarchive", photoController.UnarchivePhoto, jwtMiddleware)
+	e.PUT("/photos/:id/content-warning", photoController.SetContentWarning, jwtMiddleware)
+	e.POST("/photos/:id/revisions/:revisionId/revert", photoController.RevertPhoto, jwtMiddleware)
+	e.POST("/photos/:id/reposts", repostController.CreateRepost, jwtMiddleware)
+	e.GET("/photos/:id/reposts", repostController.GetPhotoReposts, jwtMiddleware)
+	e.DELETE("/reposts/:id", repostController.DeleteRepost, jwtMiddleware)
+
+	e.OPTIONS("/uploads", uploadController.GetOptions)
+	e.POST("/uploads/presign", uploadController.PresignUpload, jwtMiddleware)
+	e.POST("/uploads/presign/:id/finalize", photoController.FinalizeUpload, jwtMiddleware)
+	e.POST("/uploads", uploadController.CreateUpload, jwtMiddleware)
+	e.HEAD("/uploads/:id", uploadController.GetUpload, jwtMiddleware)
+	e.PATCH("/uploads/:id", uploadController.WriteChunk, jwtMiddleware)
+
+	e.POST("/photos/:id/user-tags", userTagController.TagUser, jwtMiddleware)
+	e.GET("/user-tags/pending", userTagController.GetPendingTags, jwtMiddleware)
+	e.POST("/user-tags/:id/approve", userTagController.ApproveTag, jwtMiddleware)
+	e.DELETE("/user-tags/:id", userTagController.RemoveTag, jwtMiddleware)
 
 	e.GET("/comment", commentController.GetAllMyComment, jwtMiddleware)
 	e.GET("/comment/:id", commentController.GetAllMyCommenByID, jwtMiddleware)
@@ -70,4 +199,38 @@ func New(e *echo.Echo, db *gorm.DB) {
 	e.DELETE("/comments/:id", commentController.DeleteComment, jwtMiddleware)
 	e.PATCH("/comments/:id", commentController.UpdateComment, jwtMiddleware)
 
+	e.GET("/search", searchController.Search, jwtMiddleware)
+
+	e.POST("/reports", moderationController.CreateReport, jwtMiddleware)
+	e.GET("/moderation/cases", moderationController.GetCases, jwtMiddleware)
+	e.GET("/moderation/cases/:id", moderationController.GetCase, jwtMiddleware)
+	e.POST("/moderation/cases/:id/claim", moderationController.ClaimCase, jwtMiddleware)
+	e.POST("/moderation/cases/:id/notes", moderationController.AddNote, jwtMiddleware)
+	e.POST("/moderation/cases/:id/resolve", moderationController.ResolveCase, jwtMiddleware)
+
+	e.GET("/tags/:tag", tagController.GetTag, jwtMiddleware)
+	e.GET("/tags/:tag/photos", tagController.GetTagPhotos, jwtMiddleware)
+
+	e.GET("/users/:username/albums", albumController.GetUserAlbums, jwtMiddleware)
+	e.GET("/albums/:id", albumController.GetAlbum, jwtMiddleware)
+	e.POST("/albums", albumController.CreateAlbum, jwtMiddleware)
+	e.PATCH("/albums/:id", albumController.UpdateAlbum, jwtMiddleware)
+	e.DELETE("/albums/:id", albumController.DeleteAlbum, jwtMiddleware)
+	e.POST("/albums/:id/photos", albumController.AddAlbumPhoto, jwtMiddleware)
+	e.PUT("/albums/:id/photos", albumController.ReorderAlbumPhotos, jwtMiddleware)
+	e.DELETE("/albums/:id/photos/:photoId", albumController.RemoveAlbumPhoto, jwtMiddleware)
+
+	e.POST("/stories", storyController.CreateStory, jwtMiddleware)
+	e.GET("/stories/tray", storyController.GetTray, jwtMiddleware)
+	e.GET("/stories/archive", storyController.GetArchive, jwtMiddleware)
+	e.POST("/stories/:id/seen", storyController.MarkSeen, jwtMiddleware)
+	e.GET("/stories/:id/viewers", storyController.GetViewers, jwtMiddleware)
+	e.DELETE("/stories/:id", storyController.DeleteStory, jwtMiddleware)
+	e.GET("/users/:username/stories", storyController.GetUserStories, jwtMiddleware)
+	e.GET("/users/:username/highlights", storyController.GetHighlights, jwtMiddleware)
+	e.POST("/highlights", storyController.CreateHighlight, jwtMiddleware)
+	e.POST("/highlights/:id/stories", storyController.AddHighlightStory, jwtMiddleware)
+	e.DELETE("/highlights/:id", storyController.DeleteHighlight, jwtMiddleware)
+
+	return flushViews
 }
@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"log"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"time"
+)
+
+// accountPurgeBatchSize is how many photos or stories the purger loads at a
+// time.
+const accountPurgeBatchSize = 100
+
+// AccountPurger removes the photos and stories of deleted accounts with
+// their media. Deleting an account only marks the user as deleted, so
+// without it their media would keep using storage.
+type AccountPurger interface {
+	Purge() (int, error)
+}
+
+type accountPurger struct {
+	photoRepository repositories.PhotoRepository
+	storyRepository repositories.StoryRepository
+	searchIndex     repositories.SearchIndex
+	mediaUpload     MediaUpload
+}
+
+func NewAccountPurger(photoRepository repositories.PhotoRepository, storyRepository repositories.StoryRepository, searchIndex repositories.SearchIndex, mediaUpload MediaUpload) *accountPurger {
+	return &accountPurger{photoRepository, storyRepository, searchIndex, mediaUpload}
+}
+
+// Purge removes the photos and stories of every deleted account and returns
+// how many were removed.
+func (ap *accountPurger) Purge() (int, error) {
+	var purged int
+
+	for {
+		photos, err := ap.photoRepository.GetPhotosOfDeletedUsers(accountPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		if len(photos) == 0 {
+			break
+		}
+
+		for _, photo := range photos {
+			err = ap.photoRepository.DeletePhotoRepository(photo)
+			if err != nil {
+				return purged, err
+			}
+			logIndexError(ap.searchIndex.Remove(models.SearchTypePhoto, photo.ID))
+			logMediaError(ap.mediaUpload.DeleteMedia(photo.StorageKeys()))
+			purged++
+		}
+	}
+
+	for {
+		stories, err := ap.storyRepository.GetStoriesOfDeletedUsers(accountPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		if len(stories) == 0 {
+			return purged, nil
+		}
+
+		for _, story := range stories {
+			err = ap.storyRepository.DeleteStory(story)
+			if err != nil {
+				return purged, err
+			}
+			logMediaError(ap.mediaUpload.DeleteMedia(story.StorageKeys))
+			purged++
+		}
+	}
+}
+
+// StartAccountPurger purges deleted accounts every interval in the
+// background until the returned function is called.
+func StartAccountPurger(accountPurger AccountPurger, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				purged, err := accountPurger.Purge()
+				if purged > 0 {
+					log.Printf("account purger: removed %d photos and stories", purged)
+				}
+				if err != nil {
+					log.Printf("account purger: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
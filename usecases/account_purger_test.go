@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// deletedUsersPhotoRepository holds the photos of deleted accounts until
+// they are deleted.
+type deletedUsersPhotoRepository struct {
+	repositories.PhotoRepository
+	photos []models.Photo
+}
+
+func (r *deletedUsersPhotoRepository) GetPhotosOfDeletedUsers(limit int) ([]models.Photo, error) {
+	if limit < len(r.photos) {
+		return r.photos[:limit], nil
+	}
+	return r.photos, nil
+}
+
+func (r *deletedUsersPhotoRepository) DeletePhotoRepository(photo models.Photo) error {
+	for i := range r.photos {
+		if r.photos[i].ID == photo.ID {
+			r.photos = append(r.photos[:i], r.photos[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+type deletedUsersStoryRepository struct {
+	*memoryStoryRepository
+}
+
+func (r deletedUsersStoryRepository) GetStoriesOfDeletedUsers(limit int) ([]models.Story, error) {
+	var stories []models.Story
+	for _, story := range r.stories {
+		stories = append(stories, story)
+	}
+	return stories, nil
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	photos := &deletedUsersPhotoRepository{photos: []models.Photo{
+		{Model: gorm.Model{ID: 1}, StorageKey: "photos/a.jpg", Items: []models.PhotoItem{
+			{StorageKey: "photos/a.jpg", Variants: []models.PhotoVariant{{StorageKey: "photos/a_thumb.jpg"}}},
+		}},
+		{Model: gorm.Model{ID: 2}, StorageKey: "photos/b.jpg", Items: []models.PhotoItem{{StorageKey: "photos/b.jpg"}}},
+	}}
+	stories := deletedUsersStoryRepository{newMemoryStoryRepository(
+		models.Story{ID: 1, UserID: 1, StorageKeys: []string{"stories/c.jpg"}},
+	)}
+	mediaUpload := &deletedMediaUpload{}
+
+	purged, err := NewAccountPurger(photos, stories, repositories.NewMemorySearchIndex(), mediaUpload).Purge()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	assert.Empty(t, photos.photos)
+	assert.Empty(t, stories.stories)
+	assert.ElementsMatch(t, []string{"photos/a.jpg", "photos/a_thumb.jpg", "photos/b.jpg", "stories/c.jpg"}, mediaUpload.deleted)
+}
@@ -0,0 +1,413 @@
+package usecases
+
+import (
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"time"
+)
+
+type AlbumUsecase interface {
+	CreateAlbum(input models.AlbumInput, userID int) (models.Album, error)
+	GetAlbum(albumID, viewerID int) (models.Album, error)
+	GetUserAlbums(username string, viewerID int) ([]models.Album, error)
+	UpdateAlbum(input models.AlbumInput, albumID, userID int) (models.Album, int, error)
+	DeleteAlbum(albumID, userID int) (int, error)
+	AddPhoto(input models.AlbumPhotoInput, albumID, userID int) (models.Album, int, error)
+	RemovePhoto(albumID, photoID, userID int) (models.Album, int, error)
+	ReorderPhotos(input models.AlbumOrderInput, albumID, userID int) (models.Album, int, error)
+}
+
+type albumUsecase struct {
+	repository       repositories.AlbumRepository
+	photoRepository  repositories.PhotoRepository
+	userRepository   repositories.UserRepository
+	followRepository repositories.FollowRepository
+}
+
+func NewAlbumUsecase(repository repositories.AlbumRepository, photoRepository repositories.PhotoRepository, userRepository repositories.UserRepository, followRepository repositories.FollowRepository) *albumUsecase {
+	return &albumUsecase{repository, photoRepository, userRepository, followRepository}
+}
+
+func validAlbumVisibility(visibility string) bool {
+	return visibility == models.AlbumVisibilityPublic || visibility == models.AlbumVisibilityPrivate
+}
+
+// checkOwnPhotos makes sure every photo exists, belongs to userID and is
+// listed only once.
+func (as *albumUsecase) checkOwnPhotos(photoIDs []uint, userID int) error {
+	seen := make(map[uint]bool, len(photoIDs))
+
+	for _, photoID := range photoIDs {
+		if seen[photoID] {
+			return errors.New("Photo is listed more than once")
+		}
+		seen[photoID] = true
+
+		photo, err := as.photoRepository.FindByID(int(photoID))
+		if err != nil || photo.UserID != userID {
+			return errors.New("Photo ID cannot be found")
+		}
+	}
+
+	return nil
+}
+
+// visibleAlbumPhotos drops the photos of album that viewerID may not see.
+// An album only holds photos of its owner, so one follow lookup is enough.
+func (as *albumUsecase) visibleAlbumPhotos(album models.Album, viewerID int) models.Album {
+	if album.UserID == viewerID {
+		return album
+	}
+
+	following, err := as.followRepository.IsFollowing(viewerID, album.UserID)
+	following = err == nil && following
+	setting := sensitiveContentSetting(as.userRepository, viewerID)
+
+	photos := make([]models.AlbumPhoto, 0, len(album.Photos))
+	for _, p := range album.Photos {
+		if p.Photo.VisibleTo(viewerID, following) && !p.Photo.HiddenBySetting(viewerID, setting) {
+			photos = append(photos, p)
+		}
+	}
+	album.Photos = photos
+
+	if album.CoverPhoto != nil && (!album.CoverPhoto.VisibleTo(viewerID, following) || album.CoverPhoto.HiddenBySetting(viewerID, setting)) {
+		album.CoverPhoto = nil
+		album.CoverPhotoID = nil
+	}
+
+	return album
+}
+
+func albumHasPhoto(album models.Album, photoID uint) bool {
+	for _, p := range album.Photos {
+		if p.PhotoID == photoID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAlbum godoc
+// @Summary      Create album
+// @Description  Create an album from the user's own photos, in the given order
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Param        request body models.AlbumInput true "Payload Body [RAW]"
+// @Success      201
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /albums [post]
+// @Security BearerAuth
+func (as *albumUsecase) CreateAlbum(input models.AlbumInput, userID int) (models.Album, error) {
+	var album models.Album
+
+	if input.Title == "" {
+		return album, errors.New("Title is required")
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = models.AlbumVisibilityPublic
+	}
+	if !validAlbumVisibility(input.Visibility) {
+		return album, errors.New("Visibility must be public or private")
+	}
+
+	err := as.checkOwnPhotos(input.PhotoIDs, userID)
+	if err != nil {
+		return album, err
+	}
+
+	if input.CoverPhotoID != 0 {
+		found := false
+		for _, photoID := range input.PhotoIDs {
+			found = found || photoID == input.CoverPhotoID
+		}
+		if !found {
+			return album, errors.New("Cover photo must be part of the album")
+		}
+		album.CoverPhotoID = &input.CoverPhotoID
+	}
+
+	album.Title = input.Title
+	album.Description = input.Description
+	album.Visibility = input.Visibility
+	album.UserID = userID
+
+	album, err = as.repository.CreateAlbum(album)
+	if err != nil {
+		return album, err
+	}
+
+	if len(input.PhotoIDs) > 0 {
+		err = as.repository.SetPhotos(album.ID, input.PhotoIDs)
+		if err != nil {
+			return album, err
+		}
+	}
+
+	return as.repository.FindByID(int(album.ID))
+}
+
+// GetAlbum godoc
+// @Summary      Get album
+// @Description  Get an album with its photos in album order. Private albums are only visible to their owner, and photos the user may not see are left out
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Album ID"
+// @Router       /albums/{id} [get]
+// @Security BearerAuth
+func (as *albumUsecase) GetAlbum(albumID, viewerID int) (models.Album, error) {
+	album, err := as.repository.FindByID(albumID)
+	if err != nil {
+		return album, errors.New("Album not found")
+	}
+
+	if album.Visibility != models.AlbumVisibilityPublic && album.UserID != viewerID {
+		return models.Album{}, errors.New("Album not found")
+	}
+
+	return as.visibleAlbumPhotos(album, viewerID), nil
+}
+
+// GetUserAlbums godoc
+// @Summary      Get user albums
+// @Description  Get the albums on a user's profile. Private albums are only listed for their owner
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/albums [get]
+// @Security BearerAuth
+func (as *albumUsecase) GetUserAlbums(username string, viewerID int) ([]models.Album, error) {
+	user, err := as.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return nil, errors.New("User not found")
+	}
+
+	albums, err := as.repository.GetAlbumsByUser(int(user.ID), int(user.ID) == viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range albums {
+		albums[i] = as.visibleAlbumPhotos(albums[i], viewerID)
+	}
+
+	return albums, nil
+}
+
+// UpdateAlbum godoc
+// @Summary      Update album
+// @Description  Update album title, description, visibility or cover photo
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param        request body models.AlbumInput true "Payload Body [RAW]"
+// @Param id path int true "Album ID"
+// @Router       /albums/{id} [patch]
+// @Security BearerAuth
+func (as *albumUsecase) UpdateAlbum(input models.AlbumInput, albumID, userID int) (models.Album, int, error) {
+	album, err := as.repository.FindByID(albumID)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	if album.UserID != userID {
+		return album, album.UserID, err
+	}
+
+	if input.Title != "" {
+		album.Title = input.Title
+	}
+	if input.Description != "" {
+		album.Description = input.Description
+	}
+	if input.Visibility != "" {
+		if !validAlbumVisibility(input.Visibility) {
+			return album, album.UserID, errors.New("Visibility must be public or private")
+		}
+		album.Visibility = input.Visibility
+	}
+	if input.CoverPhotoID != 0 {
+		if !albumHasPhoto(album, input.CoverPhotoID) {
+			return album, album.UserID, errors.New("Cover photo must be part of the album")
+		}
+		album.CoverPhotoID = &input.CoverPhotoID
+	}
+	album.UpdatedAt = time.Now()
+
+	_, err = as.repository.UpdateAlbum(album)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	album, err = as.repository.FindByID(albumID)
+	return album, album.UserID, err
+}
+
+// DeleteAlbum godoc
+// @Summary      Delete album
+// @Description  Delete album. The photos themselves are kept
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Album ID"
+// @Router       /albums/{id} [delete]
+// @Security BearerAuth
+func (as *albumUsecase) DeleteAlbum(albumID, userID int) (int, error) {
+	album, err := as.repository.FindByID(albumID)
+	if err != nil {
+		return album.UserID, err
+	}
+
+	if album.UserID != userID {
+		return album.UserID, nil
+	}
+
+	return album.UserID, as.repository.DeleteAlbum(album)
+}
+
+// AddPhoto godoc
+// @Summary      Add photo to album
+// @Description  Append one of the user's own photos to the end of the album
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param        request body models.AlbumPhotoInput true "Payload Body [RAW]"
+// @Param id path int true "Album ID"
+// @Router       /albums/{id}/photos [post]
+// @Security BearerAuth
+func (as *albumUsecase) AddPhoto(input models.AlbumPhotoInput, albumID, userID int) (models.Album, int, error) {
+	album, err := as.repository.FindByID(albumID)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	if album.UserID != userID {
+		return album, album.UserID, nil
+	}
+
+	if albumHasPhoto(album, input.PhotoID) {
+		return album, album.UserID, errors.New("Photo is already in the album")
+	}
+
+	err = as.checkOwnPhotos([]uint{input.PhotoID}, userID)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	err = as.repository.AddPhoto(album.ID, input.PhotoID)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	album, err = as.repository.FindByID(albumID)
+	return album, album.UserID, err
+}
+
+// RemovePhoto godoc
+// @Summary      Remove photo from album
+// @Description  Remove a photo from the album. The photo itself is kept
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Album ID"
+// @Param photoId path int true "Photo ID"
+// @Router       /albums/{id}/photos/{photoId} [delete]
+// @Security BearerAuth
+func (as *albumUsecase) RemovePhoto(albumID, photoID, userID int) (models.Album, int, error) {
+	album, err := as.repository.FindByID(albumID)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	if album.UserID != userID {
+		return album, album.UserID, nil
+	}
+
+	if !albumHasPhoto(album, uint(photoID)) {
+		return album, album.UserID, errors.New("Photo is not in the album")
+	}
+
+	err = as.repository.RemovePhoto(album.ID, uint(photoID))
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	album, err = as.repository.FindByID(albumID)
+	return album, album.UserID, err
+}
+
+// ReorderPhotos godoc
+// @Summary      Reorder album photos
+// @Description  Set the album order. photo_ids must list exactly the photos already in the album
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param        request body models.AlbumOrderInput true "Payload Body [RAW]"
+// @Param id path int true "Album ID"
+// @Router       /albums/{id}/photos [put]
+// @Security BearerAuth
+func (as *albumUsecase) ReorderPhotos(input models.AlbumOrderInput, albumID, userID int) (models.Album, int, error) {
+	album, err := as.repository.FindByID(albumID)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	if album.UserID != userID {
+		return album, album.UserID, nil
+	}
+
+	if len(input.PhotoIDs) != len(album.Photos) {
+		return album, album.UserID, errors.New("photo_ids must contain every photo in the album")
+	}
+
+	seen := make(map[uint]bool, len(input.PhotoIDs))
+	for _, photoID := range input.PhotoIDs {
+		if seen[photoID] || !albumHasPhoto(album, photoID) {
+			return album, album.UserID, errors.New("photo_ids must contain every photo in the album")
+		}
+		seen[photoID] = true
+	}
+
+	err = as.repository.SetPhotos(album.ID, input.PhotoIDs)
+	if err != nil {
+		return album, album.UserID, err
+	}
+
+	album, err = as.repository.FindByID(albumID)
+	return album, album.UserID, err
+}
@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"bytes"
+	"image"
+	"log"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+)
+
+// BackfillUsecase fills in data for photos uploaded before the feature
+// producing it existed. It is run by cmd/backfill.
+type BackfillUsecase interface {
+	BackfillVariants(batchSize int) (int, error)
+	BackfillPlaceholders(batchSize int) (int, error)
+	BackfillSizes(batchSize int) (int, error)
+}
+
+type backfillUsecase struct {
+	photoRepository repositories.PhotoRepository
+	mediaUpload     MediaUpload
+}
+
+func NewBackfillUsecase(photoRepository repositories.PhotoRepository, mediaUpload MediaUpload) *backfillUsecase {
+	return &backfillUsecase{photoRepository, mediaUpload}
+}
+
+// BackfillVariants generates the resized variants of every photo item that
+// has none and returns how many items were updated. Items whose image cannot
+// be fetched or decoded are logged and skipped.
+func (bs *backfillUsecase) BackfillVariants(batchSize int) (int, error) {
+	var (
+		afterID uint
+		updated int
+	)
+
+	for {
+		items, err := bs.photoRepository.GetItemsWithoutVariants(afterID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(items) == 0 {
+			return updated, nil
+		}
+
+		for _, item := range items {
+			afterID = item.ID
+
+			data, err := bs.mediaUpload.FetchOriginal(item.URL)
+			if err != nil {
+				log.Printf("backfill variants: photo item %d: %v", item.ID, err)
+				continue
+			}
+
+			baseKey, err := helpers.NewMediaKey("photos", "")
+			if err != nil {
+				return updated, err
+			}
+
+			objects, err := bs.mediaUpload.StoreVariants(baseKey, data)
+			if err != nil {
+				log.Printf("backfill variants: photo item %d: %v", item.ID, err)
+				continue
+			}
+
+			itemID := item.ID
+			variants := models.ParseMediaVariants(objects)
+			for i := range variants {
+				variants[i].PhotoID = item.PhotoID
+				variants[i].PhotoItemID = &itemID
+			}
+
+			err = bs.photoRepository.CreateVariants(variants)
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// BackfillPlaceholders computes the dimensions, BlurHash and dominant colour
+// of every photo item that has no BlurHash and copies them to the photo of
+// cover items. It returns how many items were updated. Items whose image
+// cannot be fetched or decoded are logged and skipped.
+func (bs *backfillUsecase) BackfillPlaceholders(batchSize int) (int, error) {
+	var (
+		afterID uint
+		updated int
+	)
+
+	for {
+		items, err := bs.photoRepository.GetItemsWithoutPlaceholder(afterID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(items) == 0 {
+			return updated, nil
+		}
+
+		for _, item := range items {
+			afterID = item.ID
+
+			data, err := bs.mediaUpload.FetchOriginal(item.URL)
+			if err != nil {
+				log.Printf("backfill placeholders: photo item %d: %v", item.ID, err)
+				continue
+			}
+
+			img, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				log.Printf("backfill placeholders: photo item %d: %v", item.ID, err)
+				continue
+			}
+
+			placeholder := helpers.NewImagePlaceholder(img)
+			item.Width = img.Bounds().Dx()
+			item.Height = img.Bounds().Dy()
+			item.BlurHash = placeholder.BlurHash
+			item.DominantColor = placeholder.DominantColor
+
+			err = bs.photoRepository.SetItemPlaceholder(item)
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// BackfillSizes looks up the size of every stored photo item and variant
+// whose size is not known, so that they count towards the storage quota of
+// their owner. It returns how many were updated. Items and variants whose
+// object is missing are logged and skipped.
+func (bs *backfillUsecase) BackfillSizes(batchSize int) (int, error) {
+	var (
+		afterID uint
+		updated int
+	)
+
+	for {
+		items, err := bs.photoRepository.GetItemsWithoutSize(afterID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for _, item := range items {
+			afterID = item.ID
+
+			object, err := bs.mediaUpload.StatMedia(item.StorageKey)
+			if err != nil {
+				log.Printf("backfill sizes: photo item %d: %v", item.ID, err)
+				continue
+			}
+
+			err = bs.photoRepository.SetItemSize(item.ID, object.Size)
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+
+	afterID = 0
+	for {
+		variants, err := bs.photoRepository.GetVariantsWithoutSize(afterID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(variants) == 0 {
+			return updated, nil
+		}
+
+		for _, variant := range variants {
+			afterID = variant.ID
+
+			object, err := bs.mediaUpload.StatMedia(variant.StorageKey)
+			if err != nil {
+				log.Printf("backfill sizes: photo variant %d: %v", variant.ID, err)
+				continue
+			}
+
+			err = bs.photoRepository.SetVariantSize(variant.ID, object.Size)
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
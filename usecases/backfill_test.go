@@ -0,0 +1,160 @@
+package usecases
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type placeholderPhotoRepository struct {
+	repositories.PhotoRepository
+	items   []models.PhotoItem
+	updated map[uint]models.PhotoItem
+}
+
+func (r *placeholderPhotoRepository) GetItemsWithoutPlaceholder(afterID uint, limit int) ([]models.PhotoItem, error) {
+	var items []models.PhotoItem
+	for _, item := range r.items {
+		if item.ID > afterID && len(items) < limit {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *placeholderPhotoRepository) SetItemPlaceholder(item models.PhotoItem) error {
+	r.updated[item.ID] = item
+	return nil
+}
+
+// originalsMediaUpload serves the originals in files by URL.
+type originalsMediaUpload struct {
+	MediaUpload
+	files map[string][]byte
+}
+
+func (m originalsMediaUpload) FetchOriginal(photoURL string) ([]byte, error) {
+	data, ok := m.files[photoURL]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func TestBackfillPlaceholders(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0, 128, 0, 255}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+
+	repository := &placeholderPhotoRepository{
+		items: []models.PhotoItem{
+			{ID: 1, PhotoID: 1, URL: "/media/a.png"},
+			{ID: 2, PhotoID: 1, URL: "/media/missing.png"},
+			{ID: 3, PhotoID: 2, URL: "/media/broken.png"},
+			{ID: 4, PhotoID: 3, URL: "/media/a.png"},
+		},
+		updated: map[uint]models.PhotoItem{},
+	}
+	mediaUpload := originalsMediaUpload{files: map[string][]byte{
+		"/media/a.png":      buf.Bytes(),
+		"/media/broken.png": []byte("not an image"),
+	}}
+
+	updated, err := NewBackfillUsecase(repository, mediaUpload).BackfillPlaceholders(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated, "items that cannot be fetched or decoded are skipped")
+
+	item := repository.updated[1]
+	assert.Equal(t, image.Pt(40, 30), image.Pt(item.Width, item.Height))
+	assert.Equal(t, "#008000", item.DominantColor)
+	assert.Len(t, item.BlurHash, 28)
+	assert.Contains(t, repository.updated, uint(4))
+}
+
+type sizePhotoRepository struct {
+	repositories.PhotoRepository
+	items        []models.PhotoItem
+	variants     []models.PhotoVariant
+	itemSizes    map[uint]int64
+	variantSizes map[uint]int64
+}
+
+func (r *sizePhotoRepository) GetItemsWithoutSize(afterID uint, limit int) ([]models.PhotoItem, error) {
+	var items []models.PhotoItem
+	for _, item := range r.items {
+		if item.ID > afterID && len(items) < limit {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *sizePhotoRepository) SetItemSize(itemID uint, size int64) error {
+	r.itemSizes[itemID] = size
+	return nil
+}
+
+func (r *sizePhotoRepository) GetVariantsWithoutSize(afterID uint, limit int) ([]models.PhotoVariant, error) {
+	var variants []models.PhotoVariant
+	for _, variant := range r.variants {
+		if variant.ID > afterID && len(variants) < limit {
+			variants = append(variants, variant)
+		}
+	}
+	return variants, nil
+}
+
+func (r *sizePhotoRepository) SetVariantSize(variantID uint, size int64) error {
+	r.variantSizes[variantID] = size
+	return nil
+}
+
+// statMediaUpload knows the size of the objects in sizes.
+type statMediaUpload struct {
+	MediaUpload
+	sizes map[string]int64
+}
+
+func (m statMediaUpload) StatMedia(key string) (models.MediaObject, error) {
+	size, ok := m.sizes[key]
+	if !ok {
+		return models.MediaObject{}, helpers.ErrMediaNotFound
+	}
+	return models.MediaObject{Key: key, Size: size}, nil
+}
+
+func TestBackfillSizes(t *testing.T) {
+	repository := &sizePhotoRepository{
+		items: []models.PhotoItem{
+			{ID: 1, StorageKey: "photos/a.jpg"},
+			{ID: 2, StorageKey: "photos/missing.jpg"},
+			{ID: 3, StorageKey: "photos/b.jpg"},
+		},
+		variants: []models.PhotoVariant{
+			{ID: 1, StorageKey: "photos/a_thumb.jpg"},
+		},
+		itemSizes:    map[uint]int64{},
+		variantSizes: map[uint]int64{},
+	}
+	mediaUpload := statMediaUpload{sizes: map[string]int64{
+		"photos/a.jpg":       2048,
+		"photos/b.jpg":       4096,
+		"photos/a_thumb.jpg": 512,
+	}}
+
+	updated, err := NewBackfillUsecase(repository, mediaUpload).BackfillSizes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, updated, "items whose object is missing are skipped")
+	assert.Equal(t, map[uint]int64{1: 2048, 3: 4096}, repository.itemSizes)
+	assert.Equal(t, map[uint]int64{1: 512}, repository.variantSizes)
+}
@@ -11,18 +11,20 @@ type CommentUsecase interface {
 	PostComment(input models.CommentInput) (models.Comment, error)
 	GetMyComment(userId int) []models.Comment
 	GetMyCommentByID(userId, ID int) models.Comment
-	GetComments() []models.Comment
+	GetComments(viewerID int) []models.Comment
 	DeleteComment(commentID, userID int) (int, error)
 	UpdateComment(input models.UpdateCommentInput, commentID, userID int) (models.Comment, int, error)
 }
 
 type commentUsecase struct {
-	repository      repositories.CommentRepository
-	photoRepository repositories.PhotoRepository
+	repository       repositories.CommentRepository
+	photoRepository  repositories.PhotoRepository
+	searchIndex      repositories.SearchIndex
+	followRepository repositories.FollowRepository
 }
 
-func NewCommentUsecase(repository repositories.CommentRepository, photoRepository repositories.PhotoRepository) *commentUsecase {
-	return &commentUsecase{repository, photoRepository}
+func NewCommentUsecase(repository repositories.CommentRepository, photoRepository repositories.PhotoRepository, searchIndex repositories.SearchIndex, followRepository repositories.FollowRepository) *commentUsecase {
+	return &commentUsecase{repository, photoRepository, searchIndex, followRepository}
 }
 
 // PostComment godoc
@@ -52,6 +54,10 @@ func (cs *commentUsecase) PostComment(input models.CommentInput) (models.Comment
 		return comment, err
 	}
 
+	if !canViewPhoto(cs.followRepository, photos, int(input.UserID)) {
+		return comment, errors.New("Photo not found")
+	}
+
 	comment.Message = input.Message
 	comment.PhotoID = int(photos.ID)
 	comment.UserID = int(input.UserID)
@@ -67,6 +73,8 @@ func (cs *commentUsecase) PostComment(input models.CommentInput) (models.Comment
 		return comment, err
 	}
 
+	logIndexError(cs.searchIndex.IndexComment(comment))
+
 	return comment, nil
 }
 
@@ -131,12 +139,12 @@ func (cs *commentUsecase) GetMyComment(userId int) []models.Comment {
 // @Failure      500
 // @Router       /comments [get]
 // @Security BearerAuth
-func (cs *commentUsecase) GetComments() []models.Comment {
+func (cs *commentUsecase) GetComments(viewerID int) []models.Comment {
 	var (
 		comments []models.Comment
 	)
 
-	comments, err := cs.repository.GetAllComments()
+	comments, err := cs.repository.GetAllComments(viewerID)
 	if err != nil {
 		return nil
 	}
@@ -166,6 +174,7 @@ func (cs *commentUsecase) DeleteComment(commentID int, userID int) (int, error)
 
 	if uint(commentID) == comment.ID && comment.UserID == userID {
 		cs.repository.DeleteCommentRepository(comment)
+		logIndexError(cs.searchIndex.Remove(models.SearchTypeComment, comment.ID))
 	} else {
 		return comment.UserID, err
 	}
@@ -201,6 +210,10 @@ func (cs *commentUsecase) UpdateComment(input models.UpdateCommentInput, comment
 		return comment, comment.UserID, err
 	}
 
+	if !canViewPhoto(cs.followRepository, comment.Photo, UserID) {
+		return comment, comment.UserID, errors.New("Photo not found")
+	}
+
 	comment.Message = input.Message
 	comment.UpdatedAt = time.Now()
 
@@ -214,5 +227,8 @@ func (cs *commentUsecase) UpdateComment(input models.UpdateCommentInput, comment
 	if err != nil {
 		return comment, comment.UserID, err
 	}
+
+	logIndexError(cs.searchIndex.IndexComment(comment))
+
 	return comment, comment.UserID, nil
 }
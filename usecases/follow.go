@@ -0,0 +1,139 @@
+package usecases
+
+import (
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+)
+
+type FollowUsecase interface {
+	FollowUser(username string, userID int) error
+	UnfollowUser(username string, userID int) error
+	BlockUser(username string, userID int) error
+	UnblockUser(username string, userID int) error
+}
+
+type followUsecase struct {
+	repository      repositories.FollowRepository
+	userRepository  repositories.UserRepository
+	blockRepository repositories.BlockRepository
+}
+
+func NewFollowUsecase(repository repositories.FollowRepository, userRepository repositories.UserRepository, blockRepository repositories.BlockRepository) *followUsecase {
+	return &followUsecase{repository, userRepository, blockRepository}
+}
+
+// canViewPhoto reports whether viewerID may see photo. Only followers-only
+// photos of other users need a look at the follow graph.
+func canViewPhoto(follows repositories.FollowRepository, photo models.Photo, viewerID int) bool {
+	if photo.Visibility != models.PhotoVisibilityFollowers || photo.UserID == viewerID {
+		return photo.VisibleTo(viewerID, false)
+	}
+
+	following, err := follows.IsFollowing(viewerID, photo.UserID)
+	return err == nil && following
+}
+
+// FollowUser godoc
+// @Summary      Follow user
+// @Description  Follow a user to see their followers-only photos
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/follow [post]
+// @Security BearerAuth
+func (fs *followUsecase) FollowUser(username string, userID int) error {
+	user, err := fs.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return errors.New("User not found")
+	}
+
+	if int(user.ID) == userID {
+		return errors.New("You cannot follow yourself")
+	}
+
+	blocked, err := fs.blockRepository.IsBlocked(userID, int(user.ID))
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errors.New("User not found")
+	}
+
+	return fs.repository.Follow(uint(userID), user.ID)
+}
+
+// UnfollowUser godoc
+// @Summary      Unfollow user
+// @Description  Stop following a user
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/follow [delete]
+// @Security BearerAuth
+func (fs *followUsecase) UnfollowUser(username string, userID int) error {
+	user, err := fs.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return errors.New("User not found")
+	}
+
+	return fs.repository.Unfollow(uint(userID), user.ID)
+}
+
+// BlockUser godoc
+// @Summary      Block user
+// @Description  Block a user. Follows and tags between the two users are removed, and neither can follow or tag the other until unblocked
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/block [post]
+// @Security BearerAuth
+func (fs *followUsecase) BlockUser(username string, userID int) error {
+	user, err := fs.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return errors.New("User not found")
+	}
+
+	if int(user.ID) == userID {
+		return errors.New("You cannot block yourself")
+	}
+
+	return fs.blockRepository.Block(uint(userID), user.ID)
+}
+
+// UnblockUser godoc
+// @Summary      Unblock user
+// @Description  Unblock a user
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/block [delete]
+// @Security BearerAuth
+func (fs *followUsecase) UnblockUser(username string, userID int) error {
+	user, err := fs.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return errors.New("User not found")
+	}
+
+	return fs.blockRepository.Unblock(uint(userID), user.ID)
+}
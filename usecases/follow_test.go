@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type followingRepository struct {
+	repositories.FollowRepository
+	following map[[2]int]bool
+}
+
+func (r followingRepository) IsFollowing(followerID, followingID int) (bool, error) {
+	return r.following[[2]int{followerID, followingID}], nil
+}
+
+func TestCanViewPhoto(t *testing.T) {
+	follows := followingRepository{following: map[[2]int]bool{{2, 1}: true}}
+
+	var testCases = []struct {
+		visibility string
+		viewerID   int
+		expect     bool
+	}{
+		{models.PhotoVisibilityPublic, 3, true},
+		{models.PhotoVisibilityFollowers, 1, true},
+		{models.PhotoVisibilityFollowers, 2, true},
+		{models.PhotoVisibilityFollowers, 3, false},
+		{models.PhotoVisibilityPrivate, 1, true},
+		{models.PhotoVisibilityPrivate, 2, false},
+	}
+
+	for _, testCase := range testCases {
+		photo := models.Photo{UserID: 1, Visibility: testCase.visibility}
+		assert.Equal(t, testCase.expect, canViewPhoto(follows, photo, testCase.viewerID), "%s viewed by %d", testCase.visibility, testCase.viewerID)
+	}
+}
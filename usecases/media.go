@@ -0,0 +1,427 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	validate = validator.New()
+
+	ErrMediaFetch = errors.New("could not fetch the photo URL")
+
+	ErrPresignUnsupported = errors.New("the storage backend does not support direct uploads")
+	ErrUploadMissing      = errors.New("the file has not been uploaded yet")
+	ErrUploadMismatch     = errors.New("the uploaded file does not match")
+)
+
+type MediaUpload interface {
+	FileUpload(file models.File) (models.MediaObject, error)
+	FileUploads(files []models.File) ([]models.MediaObject, error)
+	RemoteUpload(url models.Url) (models.MediaObject, error)
+	StoreVariants(baseKey string, data []byte) (map[string]models.MediaObject, error)
+	FetchOriginal(photoURL string) ([]byte, error)
+	PresignUpload(contentType string, expiry time.Duration) (string, models.UploadForm, error)
+	AdoptUpload(upload models.PresignedUpload, keepLocation bool) (models.MediaObject, error)
+	DeleteMedia(keys []string) error
+	StatMedia(key string) (models.MediaObject, error)
+}
+
+type media struct {
+	store   helpers.MediaStore
+	limits  helpers.ImageLimits
+	fetcher *helpers.RemoteFetcher
+}
+
+func NewMediaUpload(store helpers.MediaStore, limits helpers.ImageLimits) MediaUpload {
+	return &media{store, limits, helpers.NewRemoteFetcher(limits.MaxBytes)}
+}
+
+// readMedia reads r, failing with helpers.ErrImageTooLarge as soon as it
+// holds more than the size limit.
+func (m *media) readMedia(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, m.limits.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > m.limits.MaxBytes {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", helpers.ErrImageTooLarge, m.limits.MaxBytes)
+	}
+	return data, nil
+}
+
+// put validates data as an image and stores it under a new key. The image
+// loses its private metadata first, failing closed; JPEGs are turned
+// upright and the location is only kept when keepLocation is set.
+func (m *media) put(data []byte, keepLocation bool) (models.MediaObject, error) {
+	info, err := helpers.ValidateImage(data, m.limits)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	data, metadata, err := helpers.CleanImage(data, info.Format, keepLocation)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	info, err = helpers.ValidateImage(data, m.limits)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	key, err := helpers.NewMediaKey("photos", info.ContentType)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	object, err := m.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), info.ContentType)
+	if err != nil {
+		return object, err
+	}
+
+	object.Width = info.Width
+	object.Height = info.Height
+	if metadata != nil && !metadata.IsEmpty() {
+		object.Metadata = metadata
+	}
+
+	err = m.storeDerived(&object, strings.TrimSuffix(key, path.Ext(key)), data)
+	if err != nil {
+		logMediaError(m.DeleteMedia(object.StorageKeys()))
+		return models.MediaObject{}, err
+	}
+
+	return object, nil
+}
+
+// storeDerived decodes data once to fill in the placeholder of object and
+// store its variants under baseKey.
+func (m *media) storeDerived(object *models.MediaObject, baseKey string, data []byte) error {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", helpers.ErrImageInvalid, err)
+	}
+
+	placeholder := helpers.NewImagePlaceholder(img)
+	object.BlurHash = placeholder.BlurHash
+	object.DominantColor = placeholder.DominantColor
+
+	object.Variants, err = m.storeVariants(baseKey, img)
+	return err
+}
+
+// StoreVariants decodes data and stores a resized JPEG copy for every entry
+// of helpers.PhotoVariantSpecs, named baseKey_<variant>.jpg.
+func (m *media) StoreVariants(baseKey string, data []byte) (map[string]models.MediaObject, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", helpers.ErrImageInvalid, err)
+	}
+
+	return m.storeVariants(baseKey, img)
+}
+
+func (m *media) storeVariants(baseKey string, img image.Image) (map[string]models.MediaObject, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	variants := make(map[string]models.MediaObject, len(helpers.PhotoVariantSpecs))
+	for _, spec := range helpers.PhotoVariantSpecs {
+		resized := helpers.ResizeVariant(img, spec)
+
+		encoded, err := helpers.EncodeVariant(resized)
+		if err != nil {
+			return variants, err
+		}
+
+		key := baseKey + "_" + spec.Name + ".jpg"
+		object, err := m.store.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), "image/jpeg")
+		if err != nil {
+			return variants, err
+		}
+
+		object.Width = resized.Bounds().Dx()
+		object.Height = resized.Bounds().Dy()
+		variants[spec.Name] = object
+	}
+
+	return variants, nil
+}
+
+func (m *media) FileUpload(file models.File) (models.MediaObject, error) {
+	//validate
+	err := validate.Struct(file)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	data, err := m.readMedia(file.File)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	//upload
+	return m.put(data, file.KeepLocation)
+}
+
+// FileUploads stores every file of a carousel post. If one of them fails,
+// the ones already stored are deleted again.
+func (m *media) FileUploads(files []models.File) ([]models.MediaObject, error) {
+	objects := make([]models.MediaObject, 0, len(files))
+
+	for _, file := range files {
+		object, err := m.FileUpload(file)
+		if err != nil {
+			var keys []string
+			for _, stored := range objects {
+				keys = append(keys, stored.StorageKeys()...)
+			}
+			logMediaError(m.DeleteMedia(keys))
+
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// fetch downloads url, enforcing the size limit. It is only meant for URLs
+// of the media store; URLs given by users go through the RemoteFetcher.
+func (m *media) fetch(url string) ([]byte, error) {
+	client := http.Client{Timeout: 10 * time.Second}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMediaFetch, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrMediaFetch, res.Status)
+	}
+	if res.ContentLength > m.limits.MaxBytes {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", helpers.ErrImageTooLarge, m.limits.MaxBytes)
+	}
+
+	return m.readMedia(res.Body)
+}
+
+func (m *media) RemoteUpload(url models.Url) (models.MediaObject, error) {
+	//validate
+	err := validate.Struct(url)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, _, err := m.fetcher.Fetch(ctx, url.Url)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+	if url.MaxBytes > 0 && int64(len(data)) > url.MaxBytes {
+		return models.MediaObject{}, fmt.Errorf("%w: %d bytes left", ErrQuotaExceeded, url.MaxBytes)
+	}
+
+	//upload
+	return m.put(data, url.KeepLocation)
+}
+
+// FetchOriginal returns the stored file behind photoURL. Files of the local
+// backend are read from disk, anything else is downloaded.
+func (m *media) FetchOriginal(photoURL string) ([]byte, error) {
+	if local, ok := m.store.(*helpers.LocalMediaStore); ok && strings.HasPrefix(photoURL, local.BaseURL+"/") {
+		name, err := local.Path(strings.TrimPrefix(photoURL, local.BaseURL+"/"))
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return m.readMedia(file)
+	}
+
+	return m.fetch(photoURL)
+}
+
+// PresignUpload returns a new key and a form for uploading an image of
+// contentType straight to the media store under it.
+func (m *media) PresignUpload(contentType string, expiry time.Duration) (string, models.UploadForm, error) {
+	presigner, ok := m.store.(helpers.UploadPresigner)
+	if !ok {
+		return "", models.UploadForm{}, ErrPresignUnsupported
+	}
+	if !helpers.SupportedImageType(contentType) {
+		return "", models.UploadForm{}, helpers.ErrImageUnsupported
+	}
+
+	key, err := helpers.NewMediaKey("photos", contentType)
+	if err != nil {
+		return "", models.UploadForm{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	form, err := presigner.PresignUpload(ctx, key, contentType, m.limits.MaxBytes, expiry)
+
+	return key, form, err
+}
+
+// readObject returns the stored file of key, reading files of the local
+// backend from disk and downloading anything else through a signed URL.
+func (m *media) readObject(key string) ([]byte, error) {
+	if local, ok := m.store.(*helpers.LocalMediaStore); ok {
+		name, err := local.Path(key)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := os.Open(name)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrUploadMissing
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return m.readMedia(file)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := m.store.Stat(ctx, key)
+	if errors.Is(err, helpers.ErrMediaNotFound) {
+		return nil, ErrUploadMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := m.store.SignedURL(ctx, key, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.fetch(url)
+}
+
+// AdoptUpload checks a file uploaded straight to the media store against
+// what the client declared and validates it like any other upload. The file
+// is kept as the original unless its metadata has to be cleaned, in which
+// case the cleaned copy is stored under a new key and the upload is
+// deleted.
+func (m *media) AdoptUpload(upload models.PresignedUpload, keepLocation bool) (models.MediaObject, error) {
+	data, err := m.readObject(upload.Key)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	if int64(len(data)) != upload.Size {
+		return models.MediaObject{}, fmt.Errorf("%w: expected %d bytes, got %d", ErrUploadMismatch, upload.Size, len(data))
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != upload.Checksum {
+		return models.MediaObject{}, fmt.Errorf("%w: the SHA-256 checksum differs", ErrUploadMismatch)
+	}
+
+	info, err := helpers.ValidateImage(data, m.limits)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+	if info.ContentType != upload.ContentType {
+		return models.MediaObject{}, fmt.Errorf("%w: expected %s, got %s", ErrUploadMismatch, upload.ContentType, info.ContentType)
+	}
+
+	clean, _, err := helpers.CleanImage(data, info.Format, keepLocation)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+	if !bytes.Equal(clean, data) {
+		object, err := m.put(data, keepLocation)
+		if err == nil {
+			logMediaError(m.DeleteMedia([]string{upload.Key}))
+		}
+		return object, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	object, err := m.store.Stat(ctx, upload.Key)
+	if err != nil {
+		return object, err
+	}
+
+	object.ContentType = info.ContentType
+	object.Width = info.Width
+	object.Height = info.Height
+
+	err = m.storeDerived(&object, strings.TrimSuffix(upload.Key, path.Ext(upload.Key)), data)
+	if err != nil {
+		logMediaError(m.DeleteMedia(object.StorageKeys()))
+		return models.MediaObject{}, err
+	}
+
+	return object, nil
+}
+
+// DeleteMedia removes keys from the media store. Every key is tried; the
+// first failure is returned.
+func (m *media) DeleteMedia(keys []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var firstErr error
+	for _, key := range keys {
+		err := m.store.Delete(ctx, key)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("delete %s: %w", key, err)
+		}
+	}
+
+	return firstErr
+}
+
+// StatMedia returns the stored object of key, failing with
+// helpers.ErrMediaNotFound if there is none.
+func (m *media) StatMedia(key string) (models.MediaObject, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return m.store.Stat(ctx, key)
+}
+
+// logMediaError logs a failed media cleanup. The object is left behind for
+// the orphaned media sweeper instead of failing the request.
+func logMediaError(err error) {
+	if err != nil {
+		log.Printf("media cleanup: %v", err)
+	}
+}
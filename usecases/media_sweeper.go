@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"time"
+)
+
+// mediaSweepPrefix is the key prefix of every object Pixelfeed uploads.
+// Assets outside of it, such as uploads from before keys were tracked, are
+// never swept.
+const mediaSweepPrefix = "photos/"
+
+// MediaSweeper reconciles the media store against the photos table and
+// removes objects no photo refers to.
+type MediaSweeper interface {
+	Sweep(dryRun bool) (models.MediaSweepResult, error)
+}
+
+type mediaSweeper struct {
+	store           helpers.MediaStore
+	photoRepository repositories.PhotoRepository
+	grace           time.Duration
+}
+
+// NewMediaSweeper returns a sweeper that leaves objects younger than grace
+// alone, as their photo may not be saved yet.
+func NewMediaSweeper(store helpers.MediaStore, photoRepository repositories.PhotoRepository, grace time.Duration) *mediaSweeper {
+	return &mediaSweeper{store, photoRepository, grace}
+}
+
+// Sweep lists the stored media and deletes the orphaned objects. With dryRun
+// set the orphans are only reported.
+func (ms *mediaSweeper) Sweep(dryRun bool) (models.MediaSweepResult, error) {
+	result := models.MediaSweepResult{DryRun: dryRun, Orphaned: []string{}}
+
+	keys, err := ms.photoRepository.GetStorageKeys()
+	if err != nil {
+		return result, err
+	}
+
+	referenced := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		referenced[key] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().Add(-ms.grace)
+	err = ms.store.List(ctx, mediaSweepPrefix, func(object models.MediaObject) error {
+		result.Checked++
+		if !referenced[object.Key] && object.UpdatedAt.Before(cutoff) {
+			result.Orphaned = append(result.Orphaned, object.Key)
+		}
+		return nil
+	})
+	if err != nil || dryRun {
+		return result, err
+	}
+
+	for _, key := range result.Orphaned {
+		err := ms.store.Delete(ctx, key)
+		if err != nil {
+			log.Printf("media sweeper: delete %s: %v", key, err)
+			continue
+		}
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// StartMediaSweeper runs sweeper every interval in the background until the
+// returned function is called.
+func StartMediaSweeper(sweeper MediaSweeper, interval time.Duration, dryRun bool) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				result, err := sweeper.Sweep(dryRun)
+				if err != nil {
+					log.Printf("media sweeper: %v", err)
+					continue
+				}
+				log.Printf("media sweeper: checked %d objects, %d orphaned, %d deleted (dry run: %t)",
+					result.Checked, len(result.Orphaned), result.Deleted, result.DryRun)
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"context"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/repositories"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type storageKeysRepository struct {
+	repositories.PhotoRepository
+	keys []string
+}
+
+func (r storageKeysRepository) GetStorageKeys() ([]string, error) {
+	return r.keys, nil
+}
+
+func TestMediaSweeper(t *testing.T) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, key := range []string{"photos/kept.jpg", "photos/kept_thumb.jpg", "photos/orphan.jpg", "photos/fresh.jpg"} {
+		_, err := store.Put(ctx, key, strings.NewReader("data"), 4, "image/jpeg")
+		assert.NoError(t, err)
+		if key != "photos/fresh.jpg" {
+			name, _ := store.Path(key)
+			assert.NoError(t, os.Chtimes(name, old, old))
+		}
+	}
+
+	sweeper := NewMediaSweeper(store, storageKeysRepository{keys: []string{"photos/kept.jpg", "photos/kept_thumb.jpg"}}, 24*time.Hour)
+
+	result, err := sweeper.Sweep(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Checked)
+	assert.Equal(t, []string{"photos/orphan.jpg"}, result.Orphaned)
+	assert.Equal(t, 0, result.Deleted)
+	_, err = store.Stat(ctx, "photos/orphan.jpg")
+	assert.NoError(t, err)
+
+	result, err = sweeper.Sweep(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+	_, err = store.Stat(ctx, "photos/orphan.jpg")
+	assert.ErrorIs(t, err, helpers.ErrMediaNotFound)
+
+	for _, key := range []string{"photos/kept.jpg", "photos/kept_thumb.jpg", "photos/fresh.jpg"} {
+		_, err = store.Stat(ctx, key)
+		assert.NoError(t, err, key)
+	}
+}
@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopCloserFile struct {
+	*bytes.Reader
+}
+
+func (nopCloserFile) Close() error { return nil }
+
+func TestFileUploadStoresVariants(t *testing.T) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	mediaUpload := NewMediaUpload(store, helpers.DefaultImageLimits)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1600, 900))))
+
+	object, err := mediaUpload.FileUpload(models.File{File: nopCloserFile{bytes.NewReader(buf.Bytes())}})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", object.ContentType)
+	assert.True(t, strings.HasSuffix(object.Key, ".png"))
+	assert.Equal(t, image.Pt(1600, 900), image.Pt(object.Width, object.Height))
+	assert.Len(t, object.BlurHash, 28)
+	assert.Equal(t, "#ffffff", object.DominantColor, "transparency counts as white")
+
+	expect := map[string]image.Point{
+		"thumb": image.Pt(150, 150),
+		"small": image.Pt(640, 360),
+		"large": image.Pt(1080, 607),
+	}
+	assert.Len(t, object.Variants, len(expect))
+	for name, size := range expect {
+		variant := object.Variants[name]
+		assert.Equal(t, size, image.Pt(variant.Width, variant.Height), name)
+		assert.Equal(t, strings.TrimSuffix(object.Key, ".png")+"_"+name+".jpg", variant.Key, name)
+
+		path, _ := store.Path(variant.Key)
+		_, err := os.Stat(path)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestFileUploadRejectsNonImages(t *testing.T) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	mediaUpload := NewMediaUpload(store, helpers.DefaultImageLimits)
+
+	_, err = mediaUpload.FileUpload(models.File{File: nopCloserFile{bytes.NewReader([]byte("MZ not an image"))}})
+	assert.ErrorIs(t, err, helpers.ErrImageUnsupported)
+}
+
+func TestFileUploadsRemovesStoredFilesOnFailure(t *testing.T) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+	mediaUpload := NewMediaUpload(store, helpers.DefaultImageLimits)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 100))))
+
+	objects, err := mediaUpload.FileUploads([]models.File{
+		{File: nopCloserFile{bytes.NewReader(buf.Bytes())}},
+		{File: nopCloserFile{bytes.NewReader(buf.Bytes())}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.NotEqual(t, objects[0].Key, objects[1].Key)
+
+	_, err = mediaUpload.FileUploads([]models.File{
+		{File: nopCloserFile{bytes.NewReader(buf.Bytes())}},
+		{File: nopCloserFile{bytes.NewReader([]byte("MZ not an image"))}},
+	})
+	assert.ErrorIs(t, err, helpers.ErrImageUnsupported)
+
+	var stored int
+	assert.NoError(t, store.List(context.Background(), "photos/", func(models.MediaObject) error {
+		stored++
+		return nil
+	}))
+	assert.Equal(t, 2*(1+len(helpers.PhotoVariantSpecs)), stored)
+}
@@ -0,0 +1,396 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"strings"
+	"time"
+)
+
+const maxReportDetails = 1000
+
+var (
+	ErrNotModerator      = errors.New("Only moderators can do this")
+	ErrCaseNotFound      = errors.New("Case not found")
+	ErrCaseClaimed       = errors.New("Case is already claimed")
+	ErrReportDuplicate   = errors.New("You already reported this")
+	ErrReportTarget      = fmt.Errorf("target_type must be %s, %s, %s or %s", models.ReportTargetPhoto, models.ReportTargetComment, models.ReportTargetSocialMedia, models.ReportTargetUser)
+	ErrReportReason      = fmt.Errorf("reason must be one of %s", strings.Join(models.ReportReasons, ", "))
+	ErrModerationAction  = fmt.Errorf("action must be %s, %s, %s or %s", models.ModerationRemove, models.ModerationWarn, models.ModerationSuspend, models.ModerationDismiss)
+	ErrContentNotFound   = errors.New("Content not found")
+	ErrReportOwnContent  = errors.New("You cannot report your own content")
+	ErrCaseNotClaimed    = errors.New("Claim the case before resolving it")
+	ErrModerationNote    = errors.New("Note cannot be empty")
+	ErrRemoveUnsupported = errors.New("Users cannot be removed, suspend them instead")
+)
+
+// ModerationUsecase takes reports of abusive content and lets moderators
+// work through them. Content reported by enough users is hidden until a
+// moderator looks at it, and everything done to a case is recorded.
+type ModerationUsecase interface {
+	Report(reporterID int, input models.ReportInput) (models.ReportResponse, error)
+	GetCases(moderatorID int, status string, page, limit int) ([]models.ModerationCase, error)
+	GetCase(caseID, moderatorID int) (models.ModerationCase, error)
+	ClaimCase(caseID, moderatorID int) (models.ModerationCase, error)
+	AddNote(caseID, moderatorID int, input models.ModerationNoteInput) (models.ModerationNote, error)
+	ResolveCase(caseID, moderatorID int, input models.ModerationResolveInput) (models.ModerationCase, error)
+}
+
+type moderationUsecase struct {
+	repository            repositories.ModerationRepository
+	userRepository        repositories.UserRepository
+	photoRepository       repositories.PhotoRepository
+	commentRepository     repositories.CommentRepository
+	socialMediaRepository repositories.SocialMediaRepository
+	searchIndex           repositories.SearchIndex
+	mediaUpload           MediaUpload
+	hideThreshold         int64
+}
+
+// NewModerationUsecase returns a ModerationUsecase hiding content once it
+// has hideThreshold reports, or never if hideThreshold is 0.
+func NewModerationUsecase(repository repositories.ModerationRepository, userRepository repositories.UserRepository, photoRepository repositories.PhotoRepository,
+	commentRepository repositories.CommentRepository, socialMediaRepository repositories.SocialMediaRepository, searchIndex repositories.SearchIndex,
+	mediaUpload MediaUpload, hideThreshold int64) *moderationUsecase {
+	return &moderationUsecase{repository, userRepository, photoRepository, commentRepository, socialMediaRepository, searchIndex, mediaUpload, hideThreshold}
+}
+
+func validReportTarget(targetType string) bool {
+	switch targetType {
+	case models.ReportTargetPhoto, models.ReportTargetComment, models.ReportTargetSocialMedia, models.ReportTargetUser:
+		return true
+	}
+	return false
+}
+
+func validReportReason(reason string) bool {
+	for _, r := range models.ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms *moderationUsecase) checkModerator(userID int) error {
+	user, err := ms.userRepository.GetUserById(userID)
+	if err != nil || user.Role != models.UserRoleModerator {
+		return ErrNotModerator
+	}
+	return nil
+}
+
+// record adds action to the log of a case. A failure is logged rather than
+// failing the action that was already taken.
+func (ms *moderationUsecase) record(caseID uint, actorID *int, action, details string) {
+	err := ms.repository.CreateAction(models.ModerationAction{CaseID: caseID, ActorID: actorID, Action: action, Details: details})
+	if err != nil {
+		log.Printf("moderation log: case %d %s: %v", caseID, action, err)
+	}
+}
+
+// Report godoc
+// @Summary      Report content
+// @Description  Report an abusive photo, comment, social media link or user. target_type is photo, comment, social_media or user; reason is spam, harassment, hate_speech, violence, nudity, self_harm, impersonation, intellectual_property or other. Content is reported once per user until a moderator resolves it
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ReportInput true "Payload Body [RAW]"
+// @Success      201
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      500
+// @Router       /reports [post]
+// @Security BearerAuth
+func (ms *moderationUsecase) Report(reporterID int, input models.ReportInput) (models.ReportResponse, error) {
+	if !validReportTarget(input.TargetType) {
+		return models.ReportResponse{}, ErrReportTarget
+	}
+	if !validReportReason(input.Reason) {
+		return models.ReportResponse{}, ErrReportReason
+	}
+	input.Details = strings.TrimSpace(input.Details)
+	if len(input.Details) > maxReportDetails {
+		return models.ReportResponse{}, fmt.Errorf("details can be at most %d characters", maxReportDetails)
+	}
+
+	owner, err := ms.repository.FindTargetOwner(input.TargetType, input.TargetID)
+	if err != nil {
+		return models.ReportResponse{}, ErrContentNotFound
+	}
+	if owner == reporterID {
+		return models.ReportResponse{}, ErrReportOwnContent
+	}
+
+	moderationCase, err := ms.repository.FindOpenCase(input.TargetType, input.TargetID)
+	if err != nil {
+		moderationCase, err = ms.repository.CreateCase(models.ModerationCase{
+			TargetType:   input.TargetType,
+			TargetID:     input.TargetID,
+			TargetUserID: owner,
+			Status:       models.ModerationCaseOpen,
+		})
+		if err != nil {
+			return models.ReportResponse{}, err
+		}
+	}
+
+	report := models.Report{CaseID: moderationCase.ID, ReporterID: reporterID, Reason: input.Reason, Details: input.Details}
+	moderationCase, created, err := ms.repository.AddReport(report)
+	if err != nil {
+		return models.ReportResponse{}, err
+	}
+	if !created {
+		return models.ReportResponse{}, ErrReportDuplicate
+	}
+	ms.record(moderationCase.ID, &reporterID, models.ModerationActionReport, input.Reason)
+
+	// Reported users are not hidden: a profile shows up in too many places
+	// to hide it everywhere. They wait for a moderator, who can suspend them.
+	if ms.hideThreshold > 0 && moderationCase.ReportCount >= ms.hideThreshold && !moderationCase.Hidden && moderationCase.TargetType != models.ReportTargetUser {
+		err = ms.repository.SetHidden(moderationCase.TargetType, moderationCase.TargetID, true)
+		if err == nil {
+			moderationCase.Hidden = true
+			err = ms.repository.UpdateCase(moderationCase)
+		}
+		if err != nil {
+			log.Printf("moderation: hiding case %d: %v", moderationCase.ID, err)
+		} else {
+			ms.record(moderationCase.ID, nil, models.ModerationActionAutoHide, fmt.Sprintf("%d reports", moderationCase.ReportCount))
+		}
+	}
+
+	report.CreatedAt = time.Now()
+	return models.ParseReportToResponse(report, moderationCase), nil
+}
+
+// GetCases godoc
+// @Summary      Get moderation queue
+// @Description  Get the cases to moderate, the most reported first. status is open, claimed or resolved; without it, open and claimed cases are listed. Only for moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Param status query string false "Case status"
+// @Param page query int false "Page number"
+// @Param limit query int false "Cases per page"
+// @Router       /moderation/cases [get]
+// @Security BearerAuth
+func (ms *moderationUsecase) GetCases(moderatorID int, status string, page, limit int) ([]models.ModerationCase, error) {
+	err := ms.checkModerator(moderatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []string{models.ModerationCaseOpen, models.ModerationCaseClaimed}
+	switch status {
+	case "":
+	case models.ModerationCaseOpen, models.ModerationCaseClaimed, models.ModerationCaseResolved:
+		statuses = []string{status}
+	default:
+		return nil, errors.New("status must be open, claimed or resolved")
+	}
+
+	return ms.repository.GetCases(statuses, limit, (page-1)*limit)
+}
+
+// GetCase godoc
+// @Summary      Get moderation case
+// @Description  Get a case with its reports, notes and the log of everything done to it. Only for moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Param id path int true "Case ID"
+// @Router       /moderation/cases/{id} [get]
+// @Security BearerAuth
+func (ms *moderationUsecase) GetCase(caseID, moderatorID int) (models.ModerationCase, error) {
+	err := ms.checkModerator(moderatorID)
+	if err != nil {
+		return models.ModerationCase{}, err
+	}
+
+	moderationCase, err := ms.repository.FindCase(caseID)
+	if err != nil {
+		return moderationCase, ErrCaseNotFound
+	}
+
+	return moderationCase, nil
+}
+
+// ClaimCase godoc
+// @Summary      Claim moderation case
+// @Description  Assign an open case to yourself so other moderators do not work on it too. Only for moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      403
+// @Failure      404
+// @Failure      409
+// @Param id path int true "Case ID"
+// @Router       /moderation/cases/{id}/claim [post]
+// @Security BearerAuth
+func (ms *moderationUsecase) ClaimCase(caseID, moderatorID int) (models.ModerationCase, error) {
+	moderationCase, err := ms.GetCase(caseID, moderatorID)
+	if err != nil {
+		return moderationCase, err
+	}
+
+	claimed, err := ms.repository.ClaimCase(moderationCase.ID, moderatorID)
+	if err != nil {
+		return moderationCase, err
+	}
+	if !claimed {
+		return moderationCase, ErrCaseClaimed
+	}
+	ms.record(moderationCase.ID, &moderatorID, models.ModerationActionClaim, "")
+
+	return ms.repository.FindCase(caseID)
+}
+
+// AddNote godoc
+// @Summary      Add moderation note
+// @Description  Add a note to a case for other moderators. Only for moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ModerationNoteInput true "Payload Body [RAW]"
+// @Success      201
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Param id path int true "Case ID"
+// @Router       /moderation/cases/{id}/notes [post]
+// @Security BearerAuth
+func (ms *moderationUsecase) AddNote(caseID, moderatorID int, input models.ModerationNoteInput) (models.ModerationNote, error) {
+	input.Note = strings.TrimSpace(input.Note)
+	if input.Note == "" {
+		return models.ModerationNote{}, ErrModerationNote
+	}
+
+	moderationCase, err := ms.GetCase(caseID, moderatorID)
+	if err != nil {
+		return models.ModerationNote{}, err
+	}
+
+	note, err := ms.repository.CreateNote(models.ModerationNote{CaseID: moderationCase.ID, ModeratorID: moderatorID, Note: input.Note})
+	if err != nil {
+		return note, err
+	}
+	ms.record(moderationCase.ID, &moderatorID, models.ModerationActionNote, "")
+
+	return note, nil
+}
+
+// ResolveCase godoc
+// @Summary      Resolve moderation case
+// @Description  Close a case you claimed. action is remove to delete the content, warn to warn its owner, suspend to suspend its owner, or dismiss when nothing is wrong. Content hidden for its reports stays hidden unless the case is dismissed. Only for moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ModerationResolveInput true "Payload Body [RAW]"
+// @Success      200
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Case ID"
+// @Router       /moderation/cases/{id}/resolve [post]
+// @Security BearerAuth
+func (ms *moderationUsecase) ResolveCase(caseID, moderatorID int, input models.ModerationResolveInput) (models.ModerationCase, error) {
+	moderationCase, err := ms.GetCase(caseID, moderatorID)
+	if err != nil {
+		return moderationCase, err
+	}
+	if moderationCase.Status != models.ModerationCaseClaimed || moderationCase.AssigneeID == nil || *moderationCase.AssigneeID != moderatorID {
+		return moderationCase, ErrCaseNotClaimed
+	}
+
+	switch input.Action {
+	case models.ModerationRemove:
+		err = ms.removeContent(moderationCase)
+	case models.ModerationWarn:
+	case models.ModerationSuspend:
+		err = ms.repository.SuspendUser(moderationCase.TargetUserID, time.Now())
+	case models.ModerationDismiss:
+		if moderationCase.Hidden {
+			err = ms.repository.SetHidden(moderationCase.TargetType, moderationCase.TargetID, false)
+			moderationCase.Hidden = false
+		}
+	default:
+		return moderationCase, ErrModerationAction
+	}
+	if err != nil {
+		return moderationCase, err
+	}
+
+	now := time.Now()
+	moderationCase.Status = models.ModerationCaseResolved
+	moderationCase.Resolution = input.Action
+	moderationCase.ResolvedAt = &now
+	moderationCase.UpdatedAt = now
+
+	err = ms.repository.UpdateCase(moderationCase)
+	if err != nil {
+		return moderationCase, err
+	}
+
+	note := strings.TrimSpace(input.Note)
+	if note != "" {
+		_, err = ms.repository.CreateNote(models.ModerationNote{CaseID: moderationCase.ID, ModeratorID: moderatorID, Note: note})
+		if err != nil {
+			log.Printf("moderation: note on case %d: %v", moderationCase.ID, err)
+		}
+	}
+	ms.record(moderationCase.ID, &moderatorID, input.Action, fmt.Sprintf("%s %d", moderationCase.TargetType, moderationCase.TargetID))
+
+	return ms.repository.FindCase(caseID)
+}
+
+// removeContent deletes the content of a case the way its owner would.
+func (ms *moderationUsecase) removeContent(moderationCase models.ModerationCase) error {
+	switch moderationCase.TargetType {
+	case models.ReportTargetPhoto:
+		photo, err := ms.photoRepository.FindByID(int(moderationCase.TargetID))
+		if err != nil {
+			return ErrContentNotFound
+		}
+		err = ms.photoRepository.DeletePhotoRepository(photo)
+		if err != nil {
+			return err
+		}
+		logIndexError(ms.searchIndex.Remove(models.SearchTypePhoto, photo.ID))
+		logMediaError(ms.mediaUpload.DeleteMedia(photo.StorageKeys()))
+	case models.ReportTargetComment:
+		comment, err := ms.commentRepository.FindByID(int(moderationCase.TargetID))
+		if err != nil {
+			return ErrContentNotFound
+		}
+		err = ms.commentRepository.DeleteCommentRepository(comment)
+		if err != nil {
+			return err
+		}
+		logIndexError(ms.searchIndex.Remove(models.SearchTypeComment, comment.ID))
+	case models.ReportTargetSocialMedia:
+		socialMedia, err := ms.socialMediaRepository.GetSocialMediaByID(int(moderationCase.TargetID), moderationCase.TargetUserID)
+		if err != nil {
+			return ErrContentNotFound
+		}
+		return ms.socialMediaRepository.DeleteSocialMedia(socialMedia)
+	default:
+		return ErrRemoveUnsupported
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package usecases
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memoryModerationRepository keeps cases in memory. Every reported item
+// belongs to the user in owners.
+type memoryModerationRepository struct {
+	owners  map[uint]int
+	hidden  map[uint]bool
+	cases   map[uint]*models.ModerationCase
+	reports map[uint]map[int]bool
+	actions []models.ModerationAction
+}
+
+func newMemoryModerationRepository(owners map[uint]int) *memoryModerationRepository {
+	return &memoryModerationRepository{
+		owners:  owners,
+		hidden:  map[uint]bool{},
+		cases:   map[uint]*models.ModerationCase{},
+		reports: map[uint]map[int]bool{},
+	}
+}
+
+func (r *memoryModerationRepository) FindTargetOwner(targetType string, targetID uint) (int, error) {
+	owner, ok := r.owners[targetID]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return owner, nil
+}
+
+func (r *memoryModerationRepository) SetHidden(targetType string, targetID uint, hidden bool) error {
+	r.hidden[targetID] = hidden
+	return nil
+}
+
+func (r *memoryModerationRepository) SuspendUser(userID int, at time.Time) error {
+	return nil
+}
+
+func (r *memoryModerationRepository) FindOpenCase(targetType string, targetID uint) (models.ModerationCase, error) {
+	for _, moderationCase := range r.cases {
+		if moderationCase.TargetID == targetID && moderationCase.Status != models.ModerationCaseResolved {
+			return *moderationCase, nil
+		}
+	}
+	return models.ModerationCase{}, gorm.ErrRecordNotFound
+}
+
+func (r *memoryModerationRepository) CreateCase(moderationCase models.ModerationCase) (models.ModerationCase, error) {
+	moderationCase.ID = uint(len(r.cases) + 1)
+	r.cases[moderationCase.ID] = &moderationCase
+	r.reports[moderationCase.ID] = map[int]bool{}
+	return moderationCase, nil
+}
+
+func (r *memoryModerationRepository) AddReport(report models.Report) (models.ModerationCase, bool, error) {
+	if r.reports[report.CaseID][report.ReporterID] {
+		return *r.cases[report.CaseID], false, nil
+	}
+	r.reports[report.CaseID][report.ReporterID] = true
+	r.cases[report.CaseID].ReportCount++
+	return *r.cases[report.CaseID], true, nil
+}
+
+func (r *memoryModerationRepository) FindCase(caseID int) (models.ModerationCase, error) {
+	moderationCase, ok := r.cases[uint(caseID)]
+	if !ok {
+		return models.ModerationCase{}, gorm.ErrRecordNotFound
+	}
+	return *moderationCase, nil
+}
+
+func (r *memoryModerationRepository) GetCases(statuses []string, limit, offset int) ([]models.ModerationCase, error) {
+	return nil, nil
+}
+
+func (r *memoryModerationRepository) ClaimCase(caseID uint, moderatorID int) (bool, error) {
+	moderationCase := r.cases[caseID]
+	if moderationCase.Status != models.ModerationCaseOpen {
+		return false, nil
+	}
+	moderationCase.Status = models.ModerationCaseClaimed
+	moderationCase.AssigneeID = &moderatorID
+	return true, nil
+}
+
+func (r *memoryModerationRepository) UpdateCase(moderationCase models.ModerationCase) error {
+	r.cases[moderationCase.ID] = &moderationCase
+	return nil
+}
+
+func (r *memoryModerationRepository) CreateNote(note models.ModerationNote) (models.ModerationNote, error) {
+	return note, nil
+}
+
+func (r *memoryModerationRepository) CreateAction(action models.ModerationAction) error {
+	r.actions = append(r.actions, action)
+	return nil
+}
+
+// roleUserRepository makes the users in moderators moderators.
+type roleUserRepository struct {
+	repositories.UserRepository
+	moderators map[int]bool
+}
+
+func (r roleUserRepository) GetUserById(userId int) (models.User, error) {
+	user := models.User{Model: gorm.Model{ID: uint(userId)}, Role: models.UserRoleUser}
+	if r.moderators[userId] {
+		user.Role = models.UserRoleModerator
+	}
+	return user, nil
+}
+
+func TestReport(t *testing.T) {
+	repository := newMemoryModerationRepository(map[uint]int{1: 10})
+	moderationUsecase := NewModerationUsecase(repository, roleUserRepository{}, nil, nil, nil, repositories.NewMemorySearchIndex(), nil, 2)
+
+	input := models.ReportInput{TargetType: models.ReportTargetPhoto, TargetID: 1, Reason: "spam"}
+
+	_, err := moderationUsecase.Report(10, input)
+	assert.ErrorIs(t, err, ErrReportOwnContent)
+	_, err = moderationUsecase.Report(11, models.ReportInput{TargetType: "album", TargetID: 1, Reason: "spam"})
+	assert.ErrorIs(t, err, ErrReportTarget)
+	_, err = moderationUsecase.Report(11, models.ReportInput{TargetType: models.ReportTargetPhoto, TargetID: 1, Reason: "boring"})
+	assert.ErrorIs(t, err, ErrReportReason)
+	_, err = moderationUsecase.Report(11, models.ReportInput{TargetType: models.ReportTargetPhoto, TargetID: 2, Reason: "spam"})
+	assert.ErrorIs(t, err, ErrContentNotFound)
+
+	_, err = moderationUsecase.Report(11, input)
+	assert.NoError(t, err)
+	_, err = moderationUsecase.Report(11, input)
+	assert.ErrorIs(t, err, ErrReportDuplicate, "a user reports the same content once")
+	assert.False(t, repository.hidden[1], "one report is below the threshold")
+
+	_, err = moderationUsecase.Report(12, input)
+	assert.NoError(t, err)
+	assert.True(t, repository.hidden[1], "the content is hidden at the threshold")
+
+	moderationCase := repository.cases[1]
+	assert.Equal(t, int64(2), moderationCase.ReportCount)
+	assert.True(t, moderationCase.Hidden)
+	assert.Len(t, repository.cases, 1, "reports of the same content share a case")
+
+	last := repository.actions[len(repository.actions)-1]
+	assert.Equal(t, models.ModerationActionAutoHide, last.Action)
+	assert.Nil(t, last.ActorID)
+
+	userInput := models.ReportInput{TargetType: models.ReportTargetUser, TargetID: 20, Reason: "spam"}
+	repository.owners[20] = 20
+	_, err = moderationUsecase.Report(11, userInput)
+	assert.NoError(t, err)
+	_, err = moderationUsecase.Report(12, userInput)
+	assert.NoError(t, err)
+	assert.False(t, repository.hidden[20], "reported users are left to moderators")
+}
+
+func TestResolveCase(t *testing.T) {
+	repository := newMemoryModerationRepository(map[uint]int{1: 10})
+	users := roleUserRepository{moderators: map[int]bool{20: true, 21: true}}
+	moderationUsecase := NewModerationUsecase(repository, users, nil, nil, nil, repositories.NewMemorySearchIndex(), nil, 1)
+
+	_, err := moderationUsecase.Report(11, models.ReportInput{TargetType: models.ReportTargetPhoto, TargetID: 1, Reason: "nudity"})
+	assert.NoError(t, err)
+	assert.True(t, repository.hidden[1])
+
+	_, err = moderationUsecase.ClaimCase(1, 11)
+	assert.ErrorIs(t, err, ErrNotModerator)
+	_, err = moderationUsecase.ResolveCase(1, 20, models.ModerationResolveInput{Action: models.ModerationDismiss})
+	assert.ErrorIs(t, err, ErrCaseNotClaimed)
+
+	moderationCase, err := moderationUsecase.ClaimCase(1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ModerationCaseClaimed, moderationCase.Status)
+	_, err = moderationUsecase.ClaimCase(1, 21)
+	assert.ErrorIs(t, err, ErrCaseClaimed, "a case is claimed once")
+	_, err = moderationUsecase.ResolveCase(1, 21, models.ModerationResolveInput{Action: models.ModerationDismiss})
+	assert.ErrorIs(t, err, ErrCaseNotClaimed, "only the assignee resolves the case")
+
+	_, err = moderationUsecase.ResolveCase(1, 20, models.ModerationResolveInput{Action: "ban"})
+	assert.ErrorIs(t, err, ErrModerationAction)
+
+	moderationCase, err = moderationUsecase.ResolveCase(1, 20, models.ModerationResolveInput{Action: models.ModerationDismiss, Note: "Not nudity"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ModerationCaseResolved, moderationCase.Status)
+	assert.Equal(t, models.ModerationDismiss, moderationCase.Resolution)
+	assert.NotNil(t, moderationCase.ResolvedAt)
+	assert.False(t, repository.hidden[1], "dismissing shows the content again")
+
+	_, err = moderationUsecase.Report(12, models.ReportInput{TargetType: models.ReportTargetPhoto, TargetID: 1, Reason: "nudity"})
+	assert.NoError(t, err)
+	assert.Len(t, repository.cases, 2, "reports after a resolution open a new case")
+}
@@ -2,8 +2,11 @@ package usecases
 
 import (
 	"errors"
+	"fmt"
+	"mini-project-alterra/helpers"
 	"mini-project-alterra/models"
 	"mini-project-alterra/repositories"
+	"strings"
 	"time"
 )
 
@@ -11,31 +14,179 @@ type PhotoUsecase interface {
 	CreatePhoto(input models.PhotoInput) (models.Photo, error)
 	DeletePhoto(photoID, userID int) (int, error)
 	GetMyPhotoByID(userId, ID int) models.Photo
-	GetMyPhoto(userId int) []models.Photo
-	GetPhotos() []models.Photo
+	GetPhoto(photoID, viewerID int) (models.Photo, error)
+	GetMyPhoto(userId int, archived bool) []models.Photo
+	GetPhotos(viewerID, page, limit int) []models.Photo
+	GetDrafts(userId int) []models.Photo
+	GetNearbyPhotos(lat, lng, radiusKm float64, viewerID, page, limit int) ([]models.NearbyPhoto, error)
+	PublishDuePhotos() (int, error)
+	GetRevisions(photoID, userID int) ([]models.PhotoRevision, error)
+	RevertPhoto(photoID, revisionID, userID int) (models.Photo, error)
+	ArchivePhoto(photoID, userID int, archive bool) (models.Photo, error)
+	SetContentWarning(photoID int, user models.User, input models.ContentWarningInput) (models.Photo, error)
 	UpdatePhoto(input models.PhotoInput, PhotoID, UserID int) (models.Photo, int, error)
 }
 
 type photoUsecase struct {
-	repository repositories.PhotoRepository
+	repository    repositories.PhotoRepository
+	tagRepository repositories.TagRepository
+	searchIndex   repositories.SearchIndex
+	mediaUpload   MediaUpload
 }
 
-func NewPhotoUsecase(repository repositories.PhotoRepository) *photoUsecase {
-	return &photoUsecase{repository}
+func NewPhotoUsecase(repository repositories.PhotoRepository, tagRepository repositories.TagRepository, searchIndex repositories.SearchIndex, mediaUpload MediaUpload) *photoUsecase {
+	return &photoUsecase{repository, tagRepository, searchIndex, mediaUpload}
+}
+
+func validPhotoVisibility(visibility string) bool {
+	switch visibility {
+	case models.PhotoVisibilityPublic, models.PhotoVisibilityFollowers, models.PhotoVisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+func validSensitiveCategory(category string) bool {
+	for _, c := range models.SensitiveCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+var errSensitiveCategory = fmt.Errorf("Sensitive category must be one of %s", strings.Join(models.SensitiveCategories, ", "))
+
+// sensitiveContentSetting returns the sensitive_content setting of
+// viewerID, falling back to blurring if it cannot be read.
+func sensitiveContentSetting(userRepository repositories.UserRepository, viewerID int) string {
+	viewer, err := userRepository.GetUserById(viewerID)
+	if err != nil || viewer.SensitiveContent == "" {
+		return models.SensitiveContentBlur
+	}
+	return viewer.SensitiveContent
+}
+
+// photoSchedule works out the status and publish time of a post from the
+// requested status and publish_at. A publish_at alone schedules the post,
+// and neither publishes it right away.
+func photoSchedule(status, publishAt string, now time.Time) (string, *time.Time, error) {
+	var at *time.Time
+	if publishAt != "" {
+		t, err := time.Parse(time.RFC3339, publishAt)
+		if err != nil {
+			return "", nil, errors.New("publish_at must be an RFC 3339 time")
+		}
+		at = &t
+	}
+
+	if status == "" {
+		status = models.PhotoStatusPublished
+		if at != nil {
+			status = models.PhotoStatusScheduled
+		}
+	}
+
+	switch status {
+	case models.PhotoStatusPublished:
+		if at != nil {
+			return "", nil, errors.New("publish_at can only be set on a scheduled post")
+		}
+		return status, &now, nil
+	case models.PhotoStatusDraft:
+		return status, nil, nil
+	case models.PhotoStatusScheduled:
+		if at == nil {
+			return "", nil, errors.New("publish_at is required for a scheduled post")
+		}
+		if !at.After(now) {
+			return "", nil, errors.New("publish_at must be in the future")
+		}
+		return status, at, nil
+	}
+
+	return "", nil, errors.New("Status must be published, draft or scheduled")
+}
+
+// setPhotoLocation applies the location of input to photo. The GPS position
+// in the EXIF data is only used when the user asks for it with
+// location_from_exif, and it is only there for users who chose to keep the
+// location of their uploads.
+func setPhotoLocation(photo *models.Photo, input models.PhotoInput) error {
+	if input.RemoveLocation {
+		photo.Latitude, photo.Longitude, photo.PlaceName = nil, nil, ""
+		return nil
+	}
+
+	lat, lng := input.Latitude, input.Longitude
+	if lat == nil && lng == nil && input.LocationFromExif && input.Metadata != nil {
+		lat, lng = input.Metadata.Latitude, input.Metadata.Longitude
+	}
+
+	if (lat == nil) != (lng == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if lat != nil {
+		if !helpers.ValidCoordinates(*lat, *lng) {
+			return errors.New("latitude must be between -90 and 90 and longitude between -180 and 180")
+		}
+		photo.Latitude, photo.Longitude = lat, lng
+	}
+
+	if placeName := strings.TrimSpace(input.PlaceName); placeName != "" {
+		if len(placeName) > maxPlaceNameLength {
+			return fmt.Errorf("place_name can be at most %d bytes", maxPlaceNameLength)
+		}
+		photo.PlaceName = placeName
+	}
+
+	return nil
+}
+
+// maxPlaceNameLength is the size of the place_name column.
+const maxPlaceNameLength = 255
+
+// syncTags links photo to the hashtags in its caption, dropping links to tags
+// that are no longer mentioned.
+func (ps *photoUsecase) syncTags(photo models.Photo) (models.Photo, error) {
+	tags, err := ps.tagRepository.FirstOrCreateTags(helpers.ExtractHashtags(photo.Caption))
+	if err != nil {
+		return photo, err
+	}
+
+	err = ps.tagRepository.SetPhotoTags(photo, tags)
+	if err != nil {
+		return photo, err
+	}
+
+	return ps.repository.FindByID(int(photo.ID))
 }
 
 // CreatePhoto godoc
 // @Summary      Create photo
-// @Description  Create photo
+// @Description  Create a post from up to 10 uploaded JPEG, PNG, GIF or WebP images, from complete resumable uploads, or from a photo_url. Repeat file or upload_id, and alt_text, for every image, in carousel order
 // @Tags         Photo
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        file formData file true "Photo file"
+// @Param        file formData file false "Photo file, repeat for a carousel post"
+// @Param        upload_id formData string false "ID of a complete resumable upload, used instead of file"
+// @Param        alt_text formData string false "Alt text of the image at the same position"
 // @Param        title formData string false "Photo title"
 // @Param        caption formData string false "Photo caption"
+// @Param        visibility formData string false "public, followers or private; defaults to the user's setting"
+// @Param        sensitive_category formData string false "Flags the photo as sensitive: nudity, violence, gore, self_harm, drugs or other"
+// @Param        status formData string false "published, draft or scheduled" default(published)
+// @Param        publish_at formData string false "RFC 3339 time to publish a scheduled post at"
+// @Param        latitude formData number false "Latitude of the photo location"
+// @Param        longitude formData number false "Longitude of the photo location"
+// @Param        place_name formData string false "Name of the photo location"
+// @Param        location_from_exif formData bool false "Use the GPS position of the image, kept only with the keep_photo_location setting"
 // @Success      200
 // @Failure      400
+// @Failure      403
 // @Failure      404
+// @Failure      413
+// @Failure      415
 // @Failure      500
 // @Router       /photos [post]
 // @Security BearerAuth
@@ -44,22 +195,76 @@ func (ps *photoUsecase) CreatePhoto(input models.PhotoInput) (models.Photo, erro
 		photo models.Photo
 	)
 
-	if input.Caption == "" || input.PhotoURL == "" || input.Title == "" {
-		return photo, errors.New("Error uploading photo")
-	}
 	photo.Title = input.Title
 	photo.Caption = input.Caption
 	photo.PhotoURL = input.PhotoURL
+	photo.StorageKey = input.StorageKey
 	photo.UserID = input.UserID
+	photo.Items = input.Items
+	photo.Metadata = input.Metadata
+	photo.Visibility = input.Visibility
+	photo.SensitiveCategory = input.SensitiveCategory
+
+	if photo.Visibility == "" {
+		photo.Visibility = models.PhotoVisibilityPublic
+	}
+	photo.SetCover()
+
+	// The media is already stored; remove it again if the photo cannot be
+	// saved so it does not linger in the media store.
+	keys := photo.StorageKeys()
+
+	if input.Caption == "" || photo.PhotoURL == "" || input.Title == "" {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return models.Photo{}, errors.New("Error uploading photo")
+	}
+	if len(photo.Items) > models.MaxPhotoItems {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return models.Photo{}, fmt.Errorf("A post can hold at most %d images", models.MaxPhotoItems)
+	}
+	if !validPhotoVisibility(photo.Visibility) {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return models.Photo{}, errors.New("Visibility must be public, followers or private")
+	}
+	if photo.SensitiveCategory != "" && !validSensitiveCategory(photo.SensitiveCategory) {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return models.Photo{}, errSensitiveCategory
+	}
+
+	var err error
+	photo.Status, photo.PublishAt, err = photoSchedule(input.Status, input.PublishAt, time.Now())
+	if err != nil {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return models.Photo{}, err
+	}
+
+	err = setPhotoLocation(&photo, input)
+	if err != nil {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return models.Photo{}, err
+	}
+
+	photo, err = ps.repository.CreatePhoto(photo)
+	if err != nil {
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+		return photo, err
+	}
+
+	photo, err = ps.syncTags(photo)
+	if err != nil {
+		return photo, err
+	}
 
-	photo, err := ps.repository.CreatePhoto(photo)
+	if photo.Published() {
+		ps.published(photo)
+	}
 
-	return photo, err
+	return photo, nil
 }
 
 // DeletePhoto godoc
 // @Summary      Delete photo
-// @Description  Delete photo
+// @Description  Delete photo permanently, together with its stored image and variants
 // @Tags         Photo
 // @Accept       json
 // @Produce      json
@@ -79,7 +284,12 @@ func (ps *photoUsecase) DeletePhoto(photoID, userID int) (int, error) {
 	}
 
 	if uint(photoID) == photo.ID && photo.UserID == userID {
-		ps.repository.DeletePhotoRepository(photo)
+		err = ps.repository.DeletePhotoRepository(photo)
+		if err != nil {
+			return photo.UserID, err
+		}
+		logIndexError(ps.searchIndex.Remove(models.SearchTypePhoto, photo.ID))
+		logMediaError(ps.mediaUpload.DeleteMedia(photo.StorageKeys()))
 	} else {
 		return photo.UserID, err
 	}
@@ -87,6 +297,27 @@ func (ps *photoUsecase) DeletePhoto(photoID, userID int) (int, error) {
 	return photo.UserID, nil
 }
 
+// GetPhoto godoc
+// @Summary      Get photo
+// @Description  Get a photo you may see. Viewing a photo of someone else counts as a view in its insights
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Param id path int true "Photo ID"
+// @Router       /photos/{id} [get]
+// @Security BearerAuth
+func (ps *photoUsecase) GetPhoto(photoID, viewerID int) (models.Photo, error) {
+	photo, err := ps.repository.FindVisibleByID(photoID, viewerID)
+	if err != nil {
+		return photo, errors.New("Photo not found")
+	}
+
+	return photo, nil
+}
+
 // GetMyPhoto godoc
 // @Summary      Get my photo by id
 // @Description  Get my photo by id
@@ -115,7 +346,7 @@ func (ps *photoUsecase) GetMyPhotoByID(userId, ID int) models.Photo {
 
 // GetMyPhoto godoc
 // @Summary      Get my photo
-// @Description  Get my photo
+// @Description  Get my photo. Archived photos are left out, unless archived is true, which lists only those
 // @Tags         Photo
 // @Accept       json
 // @Produce      json
@@ -123,14 +354,15 @@ func (ps *photoUsecase) GetMyPhotoByID(userId, ID int) models.Photo {
 // @Failure      400
 // @Failure      404
 // @Failure      500
+// @Param archived query bool false "List archived photos"
 // @Router       /photo [get]
 // @Security BearerAuth
-func (ps *photoUsecase) GetMyPhoto(userId int) []models.Photo {
+func (ps *photoUsecase) GetMyPhoto(userId int, archived bool) []models.Photo {
 	var (
 		photos []models.Photo
 	)
 
-	photos, err := ps.repository.GetAllMyPhoto(userId)
+	photos, err := ps.repository.GetAllMyPhoto(userId, archived)
 	if err != nil {
 		return nil
 	}
@@ -140,7 +372,7 @@ func (ps *photoUsecase) GetMyPhoto(userId int) []models.Photo {
 
 // GetPhotos godoc
 // @Summary      Get all photos
-// @Description  Get all photos
+// @Description  Get all photos, together with your reposts and those of the users you follow, newest first. A repost shows the original photo with a repost field telling who reposted it and their caption
 // @Tags         Photo
 // @Accept       json
 // @Produce      json
@@ -148,14 +380,18 @@ func (ps *photoUsecase) GetMyPhoto(userId int) []models.Photo {
 // @Failure      400
 // @Failure      404
 // @Failure      500
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Posts per page" default(20)
 // @Router       /photos [get]
 // @Security BearerAuth
-func (ps *photoUsecase) GetPhotos() []models.Photo {
+func (ps *photoUsecase) GetPhotos(viewerID, page, limit int) []models.Photo {
 	var (
 		photos []models.Photo
 	)
 
-	photos, err := ps.repository.GetAllPhoto()
+	// The page is merged with reposts, so any of the page*limit newest
+	// photos may be on it.
+	photos, err := ps.repository.GetAllPhoto(viewerID, page*limit)
 	if err != nil {
 		return nil
 	}
@@ -163,9 +399,47 @@ func (ps *photoUsecase) GetPhotos() []models.Photo {
 	return photos
 }
 
+// reorderPhotoItems puts items in the order given by order and applies its
+// alt texts. Items missing from order are returned as removed. At least one
+// item has to remain and every entry of order must be an existing item.
+func reorderPhotoItems(items []models.PhotoItem, order []models.PhotoItemInput) ([]models.PhotoItem, []models.PhotoItem, error) {
+	if len(order) == 0 {
+		return nil, nil, errors.New("A post needs at least one image")
+	}
+
+	byID := make(map[uint]models.PhotoItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	kept := make([]models.PhotoItem, 0, len(order))
+	for i, entry := range order {
+		item, ok := byID[entry.ID]
+		if !ok {
+			return nil, nil, fmt.Errorf("Photo item %d cannot be found", entry.ID)
+		}
+		delete(byID, entry.ID)
+
+		item.Position = i
+		if entry.AltText != nil {
+			item.AltText = *entry.AltText
+		}
+		kept = append(kept, item)
+	}
+
+	removed := make([]models.PhotoItem, 0, len(byID))
+	for _, item := range items {
+		if _, ok := byID[item.ID]; ok {
+			removed = append(removed, item)
+		}
+	}
+
+	return kept, removed, nil
+}
+
 // UpdatePhoto godoc
 // @Summary      Update photo
-// @Description  Update photo
+// @Description  Update photo. items sets the order of the images of the post and their alt text; images left out are removed
 // @Tags         Photo
 // @Accept       json
 // @Produce      json
@@ -191,20 +465,355 @@ func (ps *photoUsecase) UpdatePhoto(input models.PhotoInput, PhotoID, userID int
 		return photo, photo.UserID, err
 	}
 
-	photo.Title = input.Title
-	photo.Caption = input.Caption
-	photo.PhotoURL = input.PhotoURL
+	before := models.SnapshotPhoto(photo)
+
+	// Empty fields are not saved, so they keep their current value.
+	if input.Title != "" {
+		photo.Title = input.Title
+	}
+	if input.Caption != "" {
+		photo.Caption = input.Caption
+	}
+	if input.PhotoURL != "" {
+		photo.PhotoURL = input.PhotoURL
+	}
 	photo.UpdatedAt = time.Now()
 
-	photo, err = ps.repository.UpdatePhoto(photo)
+	if input.Visibility != "" {
+		if !validPhotoVisibility(input.Visibility) {
+			return photo, photo.UserID, errors.New("Visibility must be public, followers or private")
+		}
+		photo.Visibility = input.Visibility
+	}
+
+	err = setPhotoLocation(&photo, input)
 	if err != nil {
 		return photo, photo.UserID, err
 	}
 
-	photo, err = ps.repository.FindByID(PhotoID)
+	// Drafts and scheduled posts can be rescheduled, published now or moved
+	// back to drafts until they are published.
+	schedule := input.Status != "" || input.PublishAt != ""
+	if schedule {
+		if photo.Published() {
+			return photo, photo.UserID, errors.New("A published post cannot be rescheduled")
+		}
+		photo.Status, photo.PublishAt, err = photoSchedule(input.Status, input.PublishAt, time.Now())
+		if err != nil {
+			return photo, photo.UserID, err
+		}
+	}
+
+	var removed []models.PhotoItem
+	if input.ItemOrder != nil {
+		photo.Items, removed, err = reorderPhotoItems(photo.Items, input.ItemOrder)
+		if err != nil {
+			return photo, photo.UserID, err
+		}
+		photo.SetCover()
+		photo.Variants = nil
+	}
+
+	revision := markEdited(&photo, before, userID)
+
+	photo, ok, err := ps.repository.UpdatePhoto(photo, models.PhotoEdit{Schedule: schedule, Revision: revision, Removed: removed})
+	if err != nil {
+		return photo, photo.UserID, err
+	}
+	if !ok {
+		return photo, photo.UserID, errors.New("A published post cannot be rescheduled")
+	}
+
+	if len(removed) > 0 {
+		var keys []string
+		for _, item := range removed {
+			keys = append(keys, item.StorageKeys()...)
+		}
+		logMediaError(ps.mediaUpload.DeleteMedia(keys))
+	}
+
+	photo, err = ps.syncTags(photo)
 	if err != nil {
 		return photo, photo.UserID, err
 	}
 
+	switch {
+	case schedule && photo.Published():
+		ps.published(photo)
+	case photo.Published():
+		ps.reindex(photo)
+	}
+
 	return photo, photo.UserID, nil
 }
+
+// markEdited compares photo with how it was before an edit. When the photo
+// changed, whether it is published or not, it is marked as edited and the
+// revision recording the change is returned.
+func markEdited(photo *models.Photo, before models.PhotoSnapshot, userID int) *models.PhotoRevision {
+	changes := before.Changes(models.SnapshotPhoto(*photo))
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	photo.EditedAt = &now
+
+	return &models.PhotoRevision{
+		PhotoID: photo.ID,
+		UserID:  userID,
+		Before:  before,
+		Changes: changes,
+	}
+}
+
+// GetRevisions godoc
+// @Summary      Get photo revisions
+// @Description  Get the edits of one of your photos, newest first, with the fields each edit changed. Edits made while it was a draft or scheduled are included
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Router       /photos/{id}/revisions [get]
+// @Security BearerAuth
+func (ps *photoUsecase) GetRevisions(photoID, userID int) ([]models.PhotoRevision, error) {
+	photo, err := ps.repository.FindByID(photoID)
+	if err != nil || photo.UserID != userID {
+		return nil, errors.New("Photo not found")
+	}
+
+	return ps.repository.GetRevisions(photo.ID)
+}
+
+// RevertPhoto godoc
+// @Summary      Revert photo
+// @Description  Undo an edit of one of your photos by restoring the title, caption, visibility and location from before it. Replaced images are deleted, so a revision whose image is not the current one cannot be reverted. The revert is recorded as a new revision
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Param revisionId path int true "Revision ID"
+// @Router       /photos/{id}/revisions/{revisionId}/revert [post]
+// @Security BearerAuth
+func (ps *photoUsecase) RevertPhoto(photoID, revisionID, userID int) (models.Photo, error) {
+	photo, err := ps.repository.FindByID(photoID)
+	if err != nil || photo.UserID != userID {
+		return models.Photo{}, errors.New("Photo not found")
+	}
+
+	revision, err := ps.repository.FindRevision(photo.ID, revisionID)
+	if err != nil {
+		return photo, errors.New("Revision not found")
+	}
+	// Restoring everything but the image would leave the photo in a state it
+	// has never been in.
+	if revision.Before.PhotoURL != photo.PhotoURL {
+		return photo, errors.New("The image has changed since this revision, so it cannot be reverted")
+	}
+
+	before := models.SnapshotPhoto(photo)
+	revision.Before.Restore(&photo)
+	photo.UpdatedAt = time.Now()
+
+	edit := markEdited(&photo, before, userID)
+	if edit == nil {
+		return photo, nil
+	}
+
+	photo, _, err = ps.repository.UpdatePhoto(photo, models.PhotoEdit{Revision: edit})
+	if err != nil {
+		return photo, err
+	}
+
+	photo, err = ps.syncTags(photo)
+	if err != nil {
+		return photo, err
+	}
+
+	ps.reindex(photo)
+
+	return photo, nil
+}
+
+// published runs the side effects of a post going out. Posts published on
+// creation, published now from a draft and published by the scheduler all
+// go through it, so they are handled alike. Its only side effect is adding
+// the post to search: Pixelfeed has no notifications, and feeds are read
+// from the database, where they place posts by PostedAt.
+func (ps *photoUsecase) published(photo models.Photo) {
+	photo.Status = models.PhotoStatusPublished
+	ps.reindex(photo)
+}
+
+// reindex updates the search index after a published post changed.
+func (ps *photoUsecase) reindex(photo models.Photo) {
+	logIndexError(ps.searchIndex.IndexPhoto(photo))
+}
+
+// maxNearbyRadiusKm bounds a nearby search.
+const maxNearbyRadiusKm = 50
+
+// GetNearbyPhotos godoc
+// @Summary      Get nearby photos
+// @Description  Get the photos tagged with a location within radius kilometres of a position, nearest first. Only photos the user may see are returned
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radius query number false "Radius in kilometres, at most 50" default(5)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(20)
+// @Router       /photos/nearby [get]
+// @Security BearerAuth
+func (ps *photoUsecase) GetNearbyPhotos(lat, lng, radiusKm float64, viewerID, page, limit int) ([]models.NearbyPhoto, error) {
+	if !helpers.ValidCoordinates(lat, lng) {
+		return nil, errors.New("lat must be between -90 and 90 and lng between -180 and 180")
+	}
+	if radiusKm <= 0 || radiusKm > maxNearbyRadiusKm {
+		return nil, fmt.Errorf("radius must be more than 0 and at most %d kilometres", maxNearbyRadiusKm)
+	}
+
+	photos, err := ps.repository.GetNearbyPhotos(viewerID, lat, lng, radiusKm, helpers.BoundingBox(lat, lng, radiusKm), limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby := make([]models.NearbyPhoto, 0, len(photos))
+	for _, photo := range photos {
+		distance := helpers.HaversineKm(lat, lng, *photo.Latitude, *photo.Longitude)
+		nearby = append(nearby, models.NearbyPhoto{Photo: photo, DistanceKm: distance})
+	}
+
+	return nearby, nil
+}
+
+// GetDrafts godoc
+// @Summary      Get my drafts
+// @Description  Get my drafts and scheduled posts, the next to be published first. They are not shown anywhere else until published
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /photos/drafts [get]
+// @Security BearerAuth
+func (ps *photoUsecase) GetDrafts(userId int) []models.Photo {
+	photos, err := ps.repository.GetDrafts(userId)
+	if err != nil {
+		return nil
+	}
+
+	return photos
+}
+
+// publishBatchSize is how many due posts the scheduler publishes per run.
+const publishBatchSize = 100
+
+// PublishDuePhotos publishes the scheduled posts whose time has come and
+// returns how many were published. Posts left over from a full batch are
+// picked up on the next run.
+func (ps *photoUsecase) PublishDuePhotos() (int, error) {
+	photos, err := ps.repository.GetDuePhotos(time.Now(), publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var published int
+	for _, photo := range photos {
+		ok, err := ps.repository.PublishScheduledPhoto(photo)
+		if err != nil {
+			return published, err
+		}
+		if !ok {
+			continue
+		}
+
+		ps.published(photo)
+		published++
+	}
+
+	return published, nil
+}
+
+// ArchivePhoto godoc
+// @Summary      Archive photo
+// @Description  Archive one of your published photos to hide it from your profile, feeds, search and albums without deleting it, or unarchive it again. Comments, tags and insights are kept. Archived photos are listed by GET /photo?archived=true
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Router       /photos/{id}/archive [post]
+// @Router       /photos/{id}/unarchive [post]
+// @Security BearerAuth
+func (ps *photoUsecase) ArchivePhoto(photoID, userID int, archive bool) (models.Photo, error) {
+	photo, err := ps.repository.FindByID(photoID)
+	if err != nil || photo.UserID != userID {
+		return models.Photo{}, errors.New("Photo not found")
+	}
+	if !photo.Published() {
+		return photo, errors.New("Only published photos can be archived")
+	}
+	if (photo.ArchivedAt != nil) == archive {
+		return photo, nil
+	}
+
+	photo.ArchivedAt = nil
+	if archive {
+		now := time.Now()
+		photo.ArchivedAt = &now
+	}
+
+	return ps.repository.SetArchived(photo)
+}
+
+// SetContentWarning godoc
+// @Summary      Set content warning
+// @Description  Flag one of your photos as sensitive content with a category (nudity, violence, gore, self_harm, drugs or other), or clear the flag with an empty category. Moderators can flag any photo, and only they can change a flag they set. Viewers see a content_warning on sensitive photos, which are left out of listings for those whose sensitive_content setting is hide
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ContentWarningInput true "Payload Body [RAW]"
+// @Success      200
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Router       /photos/{id}/content-warning [put]
+// @Security BearerAuth
+func (ps *photoUsecase) SetContentWarning(photoID int, user models.User, input models.ContentWarningInput) (models.Photo, error) {
+	if input.Category != "" && !validSensitiveCategory(input.Category) {
+		return models.Photo{}, errSensitiveCategory
+	}
+
+	moderator := user.Role == models.UserRoleModerator
+	photo, err := ps.repository.FindByID(photoID)
+	if err != nil || (photo.UserID != int(user.ID) && !moderator) {
+		return models.Photo{}, errors.New("Photo not found")
+	}
+	if photo.SensitiveByModerator && !moderator {
+		return photo, ErrNotModerator
+	}
+
+	photo.SensitiveCategory = input.Category
+	photo.SensitiveByModerator = moderator && input.Category != ""
+
+	return ps.repository.SetContentWarning(photo)
+}
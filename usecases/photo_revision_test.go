@@ -0,0 +1,156 @@
+package usecases
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type revisionRepository struct {
+	repositories.PhotoRepository
+	photo     models.Photo
+	revisions []models.PhotoRevision
+	published bool
+}
+
+func (r *revisionRepository) FindByID(photoID int) (models.Photo, error) {
+	if uint(photoID) != r.photo.ID {
+		return models.Photo{}, gorm.ErrRecordNotFound
+	}
+	return r.photo, nil
+}
+
+// UpdatePhoto saves nothing when a reschedule comes after the post was
+// published, which published simulates.
+func (r *revisionRepository) UpdatePhoto(photo models.Photo, edit models.PhotoEdit) (models.Photo, bool, error) {
+	if edit.Schedule && r.published {
+		return photo, false, nil
+	}
+
+	r.photo = photo
+	if edit.Revision != nil {
+		revision := *edit.Revision
+		revision.ID = uint(len(r.revisions) + 1)
+		r.revisions = append(r.revisions, revision)
+	}
+	return photo, true, nil
+}
+
+func (r *revisionRepository) FindRevision(photoID uint, revisionID int) (models.PhotoRevision, error) {
+	if revisionID < 1 || revisionID > len(r.revisions) || r.revisions[revisionID-1].PhotoID != photoID {
+		return models.PhotoRevision{}, gorm.ErrRecordNotFound
+	}
+	return r.revisions[revisionID-1], nil
+}
+
+type noTagsRepository struct {
+	repositories.TagRepository
+}
+
+func (noTagsRepository) FirstOrCreateTags(names []string) ([]models.Tag, error) {
+	return nil, nil
+}
+
+func (noTagsRepository) SetPhotoTags(photo models.Photo, tags []models.Tag) error {
+	return nil
+}
+
+func TestUpdatePhotoRecordsRevisions(t *testing.T) {
+	repository := &revisionRepository{photo: models.Photo{
+		Model:      gorm.Model{ID: 1},
+		Title:      "Bali",
+		Caption:    "Liburan ke bali",
+		Visibility: models.PhotoVisibilityPublic,
+		Status:     models.PhotoStatusPublished,
+		UserID:     1,
+	}}
+	photoUsecase := NewPhotoUsecase(repository, noTagsRepository{}, repositories.NewMemorySearchIndex(), nil)
+
+	photo, _, err := photoUsecase.UpdatePhoto(models.PhotoInput{Caption: "Liburan ke bali"}, 1, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, photo.EditedAt, "an edit without changes is not recorded")
+	assert.Empty(t, repository.revisions)
+
+	photo, _, err = photoUsecase.UpdatePhoto(models.PhotoInput{Caption: "Sunset di Kuta", Visibility: models.PhotoVisibilityFollowers}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bali", photo.Title)
+	assert.True(t, models.ParsePhotoToResponse(photo).Edited)
+	if assert.Len(t, repository.revisions, 1) {
+		assert.Equal(t, map[string]models.PhotoFieldChange{
+			"caption":    {Old: "Liburan ke bali", New: "Sunset di Kuta"},
+			"visibility": {Old: models.PhotoVisibilityPublic, New: models.PhotoVisibilityFollowers},
+		}, repository.revisions[0].Changes)
+	}
+
+	photo, err = photoUsecase.RevertPhoto(1, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Liburan ke bali", photo.Caption)
+	assert.Equal(t, models.PhotoVisibilityPublic, photo.Visibility)
+	assert.Len(t, repository.revisions, 2)
+
+	// Replacing the image makes the earlier revisions impossible to revert.
+	photo, _, err = photoUsecase.UpdatePhoto(models.PhotoInput{Caption: "Pantai Kuta", PhotoURL: "/media/kuta.jpg"}, 1, 1)
+	assert.NoError(t, err)
+	photo, err = photoUsecase.RevertPhoto(1, 1, 1)
+	assert.EqualError(t, err, "The image has changed since this revision, so it cannot be reverted")
+	assert.Equal(t, "Pantai Kuta", photo.Caption)
+	assert.Len(t, repository.revisions, 3)
+
+	_, err = photoUsecase.RevertPhoto(1, 1, 2)
+	assert.EqualError(t, err, "Photo not found")
+	_, err = photoUsecase.RevertPhoto(1, 9, 1)
+	assert.EqualError(t, err, "Revision not found")
+}
+
+func TestRescheduleAfterPublishingSavesNothing(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+	repository := &revisionRepository{photo: models.Photo{
+		Model:     gorm.Model{ID: 1},
+		Title:     "Bali",
+		Caption:   "Liburan ke bali",
+		Status:    models.PhotoStatusScheduled,
+		PublishAt: &publishAt,
+		UserID:    1,
+	}}
+	// The scheduler publishes the post after it was loaded for the edit.
+	repository.published = true
+	photoUsecase := NewPhotoUsecase(repository, noTagsRepository{}, repositories.NewMemorySearchIndex(), nil)
+
+	_, _, err := photoUsecase.UpdatePhoto(models.PhotoInput{Title: "Bali 2023", Status: models.PhotoStatusDraft}, 1, 1)
+	assert.EqualError(t, err, "A published post cannot be rescheduled")
+	assert.Equal(t, "Bali", repository.photo.Title)
+	assert.Empty(t, repository.revisions)
+}
+
+func TestDraftEditsAreRecorded(t *testing.T) {
+	for _, status := range []string{models.PhotoStatusDraft, models.PhotoStatusScheduled} {
+		photo := models.Photo{Model: gorm.Model{ID: 1}, Title: "Bali", Status: status}
+		before := models.SnapshotPhoto(photo)
+		photo.Title = "Bali 2023"
+
+		revision := markEdited(&photo, before, 1)
+		if assert.NotNil(t, revision, status) {
+			assert.Equal(t, map[string]models.PhotoFieldChange{"title": {Old: "Bali", New: "Bali 2023"}}, revision.Changes)
+		}
+		assert.NotNil(t, photo.EditedAt, status)
+	}
+}
+
+func TestRevisionResponsesKeepStoredURLs(t *testing.T) {
+	models.SetMediaURLSigner(func(url string) string { return url + "?sig=1" })
+	defer models.SetMediaURLSigner(nil)
+
+	revisions := []models.PhotoRevision{{ID: 1, Changes: map[string]models.PhotoFieldChange{
+		"photo_url": {Old: "/media/a.jpg", New: "/media/b.jpg"},
+		"title":     {Old: "Bali", New: "Kuta"},
+	}}}
+
+	responses := models.ParsePhotoRevisionsToResponse(revisions)
+	assert.Equal(t, models.PhotoFieldChange{Old: "/media/a.jpg?sig=1", New: "/media/b.jpg?sig=1"}, responses[0].Changes["photo_url"])
+	assert.Equal(t, models.PhotoFieldChange{Old: "Bali", New: "Kuta"}, responses[0].Changes["title"])
+	assert.Equal(t, models.PhotoFieldChange{Old: "/media/a.jpg", New: "/media/b.jpg"}, revisions[0].Changes["photo_url"])
+}
@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"log"
+	"time"
+)
+
+// StartPhotoScheduler publishes due scheduled posts every interval in the
+// background until the returned function is called.
+func StartPhotoScheduler(photoUsecase PhotoUsecase, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				published, err := photoUsecase.PublishDuePhotos()
+				if published > 0 {
+					log.Printf("photo scheduler: published %d posts", published)
+				}
+				if err != nil {
+					log.Printf("photo scheduler: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,284 @@
+package usecases
+
+import (
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReorderPhotoItems(t *testing.T) {
+	items := []models.PhotoItem{
+		{ID: 1, Position: 0, AltText: "first"},
+		{ID: 2, Position: 1, AltText: "second"},
+		{ID: 3, Position: 2, AltText: "third"},
+	}
+	altText := "now the cover"
+
+	kept, removed, err := reorderPhotoItems(items, []models.PhotoItemInput{{ID: 3, AltText: &altText}, {ID: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PhotoItem{
+		{ID: 3, Position: 0, AltText: "now the cover"},
+		{ID: 1, Position: 1, AltText: "first"},
+	}, kept)
+	assert.Equal(t, []models.PhotoItem{{ID: 2, Position: 1, AltText: "second"}}, removed)
+
+	_, _, err = reorderPhotoItems(items, []models.PhotoItemInput{})
+	assert.Error(t, err)
+
+	_, _, err = reorderPhotoItems(items, []models.PhotoItemInput{{ID: 4}})
+	assert.Error(t, err)
+
+	_, _, err = reorderPhotoItems(items, []models.PhotoItemInput{{ID: 1}, {ID: 1}})
+	assert.Error(t, err)
+}
+
+func TestPhotoSchedule(t *testing.T) {
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	var testCases = []struct {
+		status    string
+		publishAt string
+		expect    string
+		expectAt  *time.Time
+		expectErr bool
+	}{
+		{"", "", models.PhotoStatusPublished, &now, false},
+		{"", later.Format(time.RFC3339), models.PhotoStatusScheduled, &later, false},
+		{models.PhotoStatusDraft, "", models.PhotoStatusDraft, nil, false},
+		{models.PhotoStatusScheduled, later.Format(time.RFC3339), models.PhotoStatusScheduled, &later, false},
+		{models.PhotoStatusScheduled, "", "", nil, true},
+		{models.PhotoStatusScheduled, now.Add(-time.Minute).Format(time.RFC3339), "", nil, true},
+		{models.PhotoStatusPublished, later.Format(time.RFC3339), "", nil, true},
+		{"", "tomorrow", "", nil, true},
+		{"archived", "", "", nil, true},
+	}
+
+	for _, testCase := range testCases {
+		status, at, err := photoSchedule(testCase.status, testCase.publishAt, now)
+		if testCase.expectErr {
+			assert.Error(t, err, "%q %q", testCase.status, testCase.publishAt)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expect, status)
+		assert.Equal(t, testCase.expectAt, at)
+	}
+}
+
+type duePhotosRepository struct {
+	repositories.PhotoRepository
+	due       []models.Photo
+	published map[uint]bool
+}
+
+func (r *duePhotosRepository) GetDuePhotos(now time.Time, limit int) ([]models.Photo, error) {
+	return r.due, nil
+}
+
+// PublishScheduledPhoto treats photo 2 as cancelled after it was loaded.
+func (r *duePhotosRepository) PublishScheduledPhoto(photo models.Photo) (bool, error) {
+	if photo.ID == 2 {
+		return false, nil
+	}
+	r.published[photo.ID] = true
+	return true, nil
+}
+
+func TestPublishDuePhotos(t *testing.T) {
+	publishAt := time.Now().Add(-time.Minute)
+	repository := &duePhotosRepository{
+		due: []models.Photo{
+			{Model: gorm.Model{ID: 1}, Title: "Sunrise in Bali", Status: models.PhotoStatusScheduled, PublishAt: &publishAt},
+			{Model: gorm.Model{ID: 2}, Title: "Sunset in Bali", Status: models.PhotoStatusScheduled, PublishAt: &publishAt},
+		},
+		published: map[uint]bool{},
+	}
+	searchIndex := repositories.NewMemorySearchIndex()
+	photoUsecase := NewPhotoUsecase(repository, nil, searchIndex, nil)
+
+	published, err := photoUsecase.PublishDuePhotos()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, map[uint]bool{1: true}, repository.published)
+
+	hits, err := searchIndex.Search("bali", []string{models.SearchTypePhoto}, 0, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, uint(1), hits[0].ID)
+}
+
+func TestSetPhotoLocation(t *testing.T) {
+	lat, lng := -8.4095, 115.1889
+	exifLat, exifLng := -6.175, 106.8272
+	metadata := &models.PhotoMetadata{Latitude: &exifLat, Longitude: &exifLng}
+
+	var photo models.Photo
+	assert.NoError(t, setPhotoLocation(&photo, models.PhotoInput{Metadata: metadata}))
+	assert.Nil(t, photo.Latitude, "EXIF location used without consent")
+
+	assert.NoError(t, setPhotoLocation(&photo, models.PhotoInput{Metadata: metadata, LocationFromExif: true}))
+	assert.Equal(t, exifLat, *photo.Latitude)
+	assert.Equal(t, exifLng, *photo.Longitude)
+
+	assert.NoError(t, setPhotoLocation(&photo, models.PhotoInput{Latitude: &lat, Longitude: &lng, PlaceName: " Bali "}))
+	assert.Equal(t, lat, *photo.Latitude)
+	assert.Equal(t, "Bali", photo.PlaceName)
+
+	assert.Error(t, setPhotoLocation(&photo, models.PhotoInput{Latitude: &lat}))
+	outside := 200.0
+	assert.Error(t, setPhotoLocation(&photo, models.PhotoInput{Latitude: &lat, Longitude: &outside}))
+
+	assert.NoError(t, setPhotoLocation(&photo, models.PhotoInput{RemoveLocation: true}))
+	assert.Nil(t, photo.Latitude)
+	assert.Nil(t, photo.Longitude)
+	assert.Empty(t, photo.PlaceName)
+}
+
+type boxPhotosRepository struct {
+	repositories.PhotoRepository
+	photos []models.Photo
+}
+
+// GetNearbyPhotos does in Go what the repository does in SQL.
+func (r boxPhotosRepository) GetNearbyPhotos(viewerID int, lat, lng, radiusKm float64, box helpers.GeoBox, limit, offset int) ([]models.Photo, error) {
+	distance := func(photo models.Photo) float64 {
+		return helpers.HaversineKm(lat, lng, *photo.Latitude, *photo.Longitude)
+	}
+
+	var photos []models.Photo
+	for _, photo := range r.photos {
+		if box.Contains(*photo.Latitude, *photo.Longitude) && distance(photo) <= radiusKm {
+			photos = append(photos, photo)
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool {
+		return distance(photos[i]) < distance(photos[j])
+	})
+
+	if offset >= len(photos) {
+		return nil, nil
+	}
+	if offset+limit < len(photos) {
+		photos = photos[:offset+limit]
+	}
+	return photos[offset:], nil
+}
+
+func TestGetNearbyPhotos(t *testing.T) {
+	at := func(id uint, lat, lng float64) models.Photo {
+		return models.Photo{Model: gorm.Model{ID: id}, Latitude: &lat, Longitude: &lng}
+	}
+	repository := boxPhotosRepository{photos: []models.Photo{
+		at(1, -6.2000, 106.8500),
+		at(2, -6.1754, 106.8272),
+		at(3, -6.9175, 107.6191),
+		// Inside the bounding box but outside the radius.
+		at(4, -6.2088+0.04, 106.8456+0.04),
+	}}
+	photoUsecase := NewPhotoUsecase(repository, nil, nil, nil)
+
+	nearby, err := photoUsecase.GetNearbyPhotos(-6.2088, 106.8456, 5, 1, 1, 20)
+	assert.NoError(t, err)
+	if assert.Len(t, nearby, 2) {
+		assert.Equal(t, uint(1), nearby[0].Photo.ID)
+		assert.Equal(t, uint(2), nearby[1].Photo.ID)
+		assert.Less(t, nearby[0].DistanceKm, nearby[1].DistanceKm)
+	}
+
+	page, err := photoUsecase.GetNearbyPhotos(-6.2088, 106.8456, 5, 1, 2, 1)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, uint(2), page[0].Photo.ID)
+
+	_, err = photoUsecase.GetNearbyPhotos(-6.2088, 106.8456, 500, 1, 1, 20)
+	assert.Error(t, err)
+	_, err = photoUsecase.GetNearbyPhotos(-100, 106.8456, 5, 1, 1, 20)
+	assert.Error(t, err)
+}
+
+type archivePhotosRepository struct {
+	repositories.PhotoRepository
+	photos map[int]models.Photo
+}
+
+func (r archivePhotosRepository) FindByID(photoID int) (models.Photo, error) {
+	photo, ok := r.photos[photoID]
+	if !ok {
+		return photo, gorm.ErrRecordNotFound
+	}
+	return photo, nil
+}
+
+func (r archivePhotosRepository) SetArchived(photo models.Photo) (models.Photo, error) {
+	r.photos[int(photo.ID)] = photo
+	return photo, nil
+}
+
+func TestArchivePhoto(t *testing.T) {
+	repository := archivePhotosRepository{photos: map[int]models.Photo{
+		1: {Model: gorm.Model{ID: 1}, UserID: 1, Status: models.PhotoStatusPublished, Visibility: models.PhotoVisibilityPublic},
+		2: {Model: gorm.Model{ID: 2}, UserID: 1, Status: models.PhotoStatusDraft},
+	}}
+	photoUsecase := NewPhotoUsecase(repository, nil, nil, nil)
+
+	_, err := photoUsecase.ArchivePhoto(1, 2, true)
+	assert.Error(t, err, "only the owner can archive a photo")
+	_, err = photoUsecase.ArchivePhoto(2, 1, true)
+	assert.Error(t, err, "drafts cannot be archived")
+
+	photo, err := photoUsecase.ArchivePhoto(1, 1, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, repository.photos[1].ArchivedAt)
+	assert.True(t, photo.VisibleTo(1, false), "the owner still sees an archived photo")
+	assert.False(t, photo.VisibleTo(2, true))
+
+	photo, err = photoUsecase.ArchivePhoto(1, 1, false)
+	assert.NoError(t, err)
+	assert.Nil(t, repository.photos[1].ArchivedAt)
+	assert.True(t, photo.VisibleTo(2, false))
+}
+
+func (r archivePhotosRepository) SetContentWarning(photo models.Photo) (models.Photo, error) {
+	r.photos[int(photo.ID)] = photo
+	return photo, nil
+}
+
+func TestSetContentWarning(t *testing.T) {
+	repository := archivePhotosRepository{photos: map[int]models.Photo{
+		1: {Model: gorm.Model{ID: 1}, UserID: 1, Status: models.PhotoStatusPublished},
+	}}
+	photoUsecase := NewPhotoUsecase(repository, nil, nil, nil)
+	owner := models.User{Model: gorm.Model{ID: 1}, Role: models.UserRoleUser}
+	other := models.User{Model: gorm.Model{ID: 2}, Role: models.UserRoleUser}
+	moderator := models.User{Model: gorm.Model{ID: 3}, Role: models.UserRoleModerator}
+
+	_, err := photoUsecase.SetContentWarning(1, owner, models.ContentWarningInput{Category: "spooky"})
+	assert.Error(t, err)
+	_, err = photoUsecase.SetContentWarning(1, other, models.ContentWarningInput{Category: "violence"})
+	assert.Error(t, err, "only the owner and moderators can flag a photo")
+
+	photo, err := photoUsecase.SetContentWarning(1, owner, models.ContentWarningInput{Category: "violence"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ContentWarningResponse{Category: "violence", FlaggedBy: models.ContentWarningByUploader}, models.ParseContentWarningToResponse(photo))
+	assert.True(t, photo.HiddenBySetting(2, models.SensitiveContentHide))
+	assert.False(t, photo.HiddenBySetting(2, models.SensitiveContentBlur))
+	assert.False(t, photo.HiddenBySetting(1, models.SensitiveContentHide), "owners see their own photos")
+
+	_, err = photoUsecase.SetContentWarning(1, moderator, models.ContentWarningInput{Category: "gore"})
+	assert.NoError(t, err)
+	_, err = photoUsecase.SetContentWarning(1, owner, models.ContentWarningInput{})
+	assert.ErrorIs(t, err, ErrNotModerator, "the owner cannot clear a moderator's flag")
+	assert.Equal(t, models.ContentWarningByModerator, models.ParseContentWarningToResponse(repository.photos[1]).FlaggedBy)
+
+	photo, err = photoUsecase.SetContentWarning(1, moderator, models.ContentWarningInput{})
+	assert.NoError(t, err)
+	assert.Nil(t, models.ParseContentWarningToResponse(photo))
+	assert.False(t, photo.SensitiveByModerator)
+}
@@ -0,0 +1,237 @@
+package usecases
+
+import (
+	"errors"
+	"log"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInsightDays = 30
+	maxInsightDays     = 90
+)
+
+// PhotoViewUsecase counts views of photo details. Views are deduplicated
+// and added up in memory and written by FlushViews, so a popular photo
+// costs one update per flush instead of one per view.
+type PhotoViewUsecase interface {
+	RecordView(photo models.Photo, viewerID int)
+	FlushViews() (int, error)
+	GetInsights(photoID, userID, days int) (models.PhotoInsightsResponse, error)
+}
+
+type photoViewKey struct {
+	photoID  uint
+	viewerID uint
+}
+
+type photoViewDate struct {
+	photoID uint
+	date    string
+}
+
+type pendingViews struct {
+	views   int64
+	viewers map[uint]bool
+}
+
+type photoViewUsecase struct {
+	repository      repositories.PhotoViewRepository
+	photoRepository repositories.PhotoRepository
+	window          time.Duration
+	now             func() time.Time
+
+	mu      sync.Mutex
+	seen    map[photoViewKey]time.Time
+	pending map[photoViewDate]*pendingViews
+}
+
+// NewPhotoViewUsecase returns a PhotoViewUsecase counting a viewer once per
+// window for the same photo.
+func NewPhotoViewUsecase(repository repositories.PhotoViewRepository, photoRepository repositories.PhotoRepository, window time.Duration) *photoViewUsecase {
+	return &photoViewUsecase{
+		repository:      repository,
+		photoRepository: photoRepository,
+		window:          window,
+		now:             time.Now,
+		seen:            make(map[photoViewKey]time.Time),
+		pending:         make(map[photoViewDate]*pendingViews),
+	}
+}
+
+// RecordView counts a view of photo by viewerID, unless it is their own
+// photo or they already viewed it within the window.
+func (vs *photoViewUsecase) RecordView(photo models.Photo, viewerID int) {
+	if photo.UserID == viewerID {
+		return
+	}
+
+	now := vs.now()
+	key := photoViewKey{photo.ID, uint(viewerID)}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if last, ok := vs.seen[key]; ok && now.Sub(last) < vs.window {
+		return
+	}
+	vs.seen[key] = now
+
+	date := photoViewDate{photo.ID, now.UTC().Format(models.PhotoViewDateLayout)}
+	pending, ok := vs.pending[date]
+	if !ok {
+		pending = &pendingViews{viewers: make(map[uint]bool)}
+		vs.pending[date] = pending
+	}
+	pending.views++
+	pending.viewers[key.viewerID] = true
+}
+
+// FlushViews writes the views counted since the last flush and returns for
+// how many photos. Views that cannot be written are kept for the next
+// flush.
+func (vs *photoViewUsecase) FlushViews() (int, error) {
+	now := vs.now()
+
+	vs.mu.Lock()
+	pending := vs.pending
+	vs.pending = make(map[photoViewDate]*pendingViews)
+	for key, last := range vs.seen {
+		if now.Sub(last) >= vs.window {
+			delete(vs.seen, key)
+		}
+	}
+	vs.mu.Unlock()
+
+	var (
+		flushed  int
+		firstErr error
+	)
+	for date, views := range pending {
+		viewerIDs := make([]uint, 0, len(views.viewers))
+		for viewerID := range views.viewers {
+			viewerIDs = append(viewerIDs, viewerID)
+		}
+
+		err := vs.repository.AddViews(date.photoID, date.date, views.views, viewerIDs)
+		if err != nil {
+			vs.requeue(date, views)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		flushed++
+	}
+
+	// Views of yesterday may still be flushed shortly after midnight, so
+	// only days before that are final.
+	yesterday := now.UTC().AddDate(0, 0, -1).Format(models.PhotoViewDateLayout)
+	err := vs.repository.DeleteViewersBefore(yesterday)
+	if err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return flushed, firstErr
+}
+
+func (vs *photoViewUsecase) requeue(date photoViewDate, views *pendingViews) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	pending, ok := vs.pending[date]
+	if !ok {
+		vs.pending[date] = views
+		return
+	}
+	pending.views += views.views
+	for viewerID := range views.viewers {
+		pending.viewers[viewerID] = true
+	}
+}
+
+// GetInsights godoc
+// @Summary      Get photo insights
+// @Description  Get the views, unique viewers, comments and reposts of one of your photos per day, for the last days (30 by default, at most 90). Views are counted once per viewer within a time window and may take a minute to show up
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Param days query int false "Number of days"
+// @Router       /photos/{id}/insights [get]
+// @Security BearerAuth
+func (vs *photoViewUsecase) GetInsights(photoID, userID, days int) (models.PhotoInsightsResponse, error) {
+	photo, err := vs.photoRepository.FindByID(photoID)
+	if err != nil || photo.UserID != userID {
+		return models.PhotoInsightsResponse{}, errors.New("Photo not found")
+	}
+
+	if days < 1 {
+		days = defaultInsightDays
+	}
+	if days > maxInsightDays {
+		days = maxInsightDays
+	}
+
+	today := vs.now().UTC()
+	dates := make([]string, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		dates = append(dates, today.AddDate(0, 0, -i).Format(models.PhotoViewDateLayout))
+	}
+
+	views, err := vs.repository.GetViewCounts(photo.ID, dates[0])
+	if err != nil {
+		return models.PhotoInsightsResponse{}, err
+	}
+
+	comments, err := vs.repository.GetCommentCounts(photo.ID, dates[0])
+	if err != nil {
+		return models.PhotoInsightsResponse{}, err
+	}
+
+	reposts, err := vs.repository.GetRepostCounts(photo.ID, dates[0])
+	if err != nil {
+		return models.PhotoInsightsResponse{}, err
+	}
+
+	return models.ParsePhotoInsightsToResponse(photo.ID, dates, views, comments, reposts), nil
+}
+
+// StartPhotoViewFlusher writes counted views every interval in the
+// background until the returned function is called, which flushes the
+// views counted last.
+func StartPhotoViewFlusher(photoViewUsecase PhotoViewUsecase, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	flush := func() {
+		_, err := photoViewUsecase.FlushViews()
+		if err != nil {
+			log.Printf("photo view flusher: %v", err)
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		flush()
+	}
+}
@@ -0,0 +1,148 @@
+package usecases
+
+import (
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type memoryPhotoViewRepository struct {
+	repositories.PhotoViewRepository
+	counts  map[photoViewDate]models.PhotoViewCount
+	viewers map[photoViewDate]map[uint]bool
+	fail    bool
+}
+
+func (r *memoryPhotoViewRepository) AddViews(photoID uint, date string, views int64, viewerIDs []uint) error {
+	if r.fail {
+		return errors.New("database is down")
+	}
+
+	key := photoViewDate{photoID, date}
+	if r.viewers[key] == nil {
+		r.viewers[key] = map[uint]bool{}
+	}
+
+	count := r.counts[key]
+	count.PhotoID, count.Date = photoID, date
+	count.Views += views
+	for _, viewerID := range viewerIDs {
+		if !r.viewers[key][viewerID] {
+			r.viewers[key][viewerID] = true
+			count.UniqueViewers++
+		}
+	}
+	r.counts[key] = count
+
+	return nil
+}
+
+func (r *memoryPhotoViewRepository) GetViewCounts(photoID uint, from string) ([]models.PhotoViewCount, error) {
+	var counts []models.PhotoViewCount
+	for key, count := range r.counts {
+		if key.photoID == photoID && key.date >= from {
+			counts = append(counts, count)
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Date < counts[j].Date })
+	return counts, nil
+}
+
+func (r *memoryPhotoViewRepository) GetCommentCounts(photoID uint, from string) ([]models.DailyCount, error) {
+	return []models.DailyCount{{Date: "2026-10-18", Count: 2}}, nil
+}
+
+func (r *memoryPhotoViewRepository) GetRepostCounts(photoID uint, from string) ([]models.DailyCount, error) {
+	return []models.DailyCount{{Date: "2026-10-19", Count: 1}}, nil
+}
+
+func (r *memoryPhotoViewRepository) DeleteViewersBefore(date string) error {
+	for key := range r.viewers {
+		if key.date < date {
+			delete(r.viewers, key)
+		}
+	}
+	return nil
+}
+
+func newTestPhotoViewUsecase(now *time.Time) (*photoViewUsecase, *memoryPhotoViewRepository) {
+	repository := &memoryPhotoViewRepository{counts: map[photoViewDate]models.PhotoViewCount{}, viewers: map[photoViewDate]map[uint]bool{}}
+	photos := photoByIDRepository{photos: map[int]models.Photo{
+		1: {Model: gorm.Model{ID: 1}, UserID: 10},
+	}}
+
+	viewUsecase := NewPhotoViewUsecase(repository, photos, 30*time.Minute)
+	viewUsecase.now = func() time.Time { return *now }
+
+	return viewUsecase, repository
+}
+
+func TestRecordView(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	viewUsecase, repository := newTestPhotoViewUsecase(&now)
+	photo := models.Photo{Model: gorm.Model{ID: 1}, UserID: 10}
+	today := photoViewDate{1, "2026-10-19"}
+
+	viewUsecase.RecordView(photo, 10)
+	viewUsecase.RecordView(photo, 20)
+	viewUsecase.RecordView(photo, 20)
+	viewUsecase.RecordView(photo, 30)
+	assert.Empty(t, repository.counts, "views are only written when flushed")
+
+	flushed, err := viewUsecase.FlushViews()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+	assert.Equal(t, int64(2), repository.counts[today].Views, "the owner and repeated views are not counted")
+	assert.Equal(t, int64(2), repository.counts[today].UniqueViewers)
+
+	now = now.Add(time.Hour)
+	viewUsecase.RecordView(photo, 20)
+
+	repository.fail = true
+	_, err = viewUsecase.FlushViews()
+	assert.Error(t, err)
+
+	repository.fail = false
+	_, err = viewUsecase.FlushViews()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), repository.counts[today].Views, "a view after the window counts again and survives a failed flush")
+	assert.Equal(t, int64(2), repository.counts[today].UniqueViewers)
+
+	now = now.AddDate(0, 0, 2)
+	_, err = viewUsecase.FlushViews()
+	assert.NoError(t, err)
+	assert.Empty(t, repository.viewers, "viewers of final days are pruned")
+	assert.Empty(t, viewUsecase.seen)
+}
+
+func TestGetInsights(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	viewUsecase, repository := newTestPhotoViewUsecase(&now)
+	repository.counts[photoViewDate{1, "2026-10-19"}] = models.PhotoViewCount{PhotoID: 1, Date: "2026-10-19", Views: 5, UniqueViewers: 3}
+	repository.counts[photoViewDate{1, "2026-10-01"}] = models.PhotoViewCount{PhotoID: 1, Date: "2026-10-01", Views: 9, UniqueViewers: 9}
+
+	_, err := viewUsecase.GetInsights(1, 20, 7)
+	assert.Error(t, err, "only the owner sees insights")
+
+	insights, err := viewUsecase.GetInsights(1, 10, 7)
+	assert.NoError(t, err)
+	if assert.Len(t, insights.Days, 7) {
+		assert.Equal(t, "2026-10-13", insights.Days[0].Date)
+		assert.Equal(t, models.PhotoInsightsDay{Date: "2026-10-18", Comments: 2}, insights.Days[5])
+		assert.Equal(t, models.PhotoInsightsDay{Date: "2026-10-19", Views: 5, UniqueViewers: 3, Reposts: 1}, insights.Days[6])
+	}
+	assert.Equal(t, int64(5), insights.Views)
+	assert.Equal(t, int64(2), insights.Comments)
+	assert.Equal(t, int64(1), insights.Reposts)
+
+	insights, err = viewUsecase.GetInsights(1, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, insights.Days, defaultInsightDays)
+	assert.Equal(t, int64(14), insights.Views)
+}
@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// QuotaUsecase keeps users within the storage quota of their tier. Usage is
+// summed up from the stored photos and stories, so deleting them releases
+// quota.
+type QuotaUsecase interface {
+	GetUsage(userID int) (models.StorageUsageResponse, error)
+	CheckQuota(userID int, bytes, photos int64) error
+	CheckStored(userID int, objects []models.MediaObject, photos int64) error
+	RemainingBytes(userID int) (int64, error)
+}
+
+type quotaUsecase struct {
+	photoRepository repositories.PhotoRepository
+	userRepository  repositories.UserRepository
+	quotas          map[string]models.StorageQuota
+}
+
+// NewQuotaUsecase returns a QuotaUsecase applying quotas by tier. Users of
+// an unknown tier get the standard quota.
+func NewQuotaUsecase(photoRepository repositories.PhotoRepository, userRepository repositories.UserRepository, quotas map[string]models.StorageQuota) *quotaUsecase {
+	return &quotaUsecase{photoRepository, userRepository, quotas}
+}
+
+func (qs *quotaUsecase) usage(userID int) (string, models.StorageUsage, models.StorageQuota, error) {
+	user, err := qs.userRepository.GetUserById(userID)
+	if err != nil {
+		return "", models.StorageUsage{}, models.StorageQuota{}, err
+	}
+
+	tier := user.QuotaTier
+	quota, ok := qs.quotas[tier]
+	if !ok {
+		tier = models.UserQuotaStandard
+		quota = qs.quotas[tier]
+	}
+
+	usage, err := qs.photoRepository.GetStorageUsage(userID)
+
+	return tier, usage, quota, err
+}
+
+// GetUsage godoc
+// @Summary      Get storage usage
+// @Description  Get the bytes and number of photos you store and the limits of your quota tier. A limit of 0 means unlimited
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      401
+// @Failure      500
+// @Router       /users/usage [get]
+// @Security BearerAuth
+func (qs *quotaUsecase) GetUsage(userID int) (models.StorageUsageResponse, error) {
+	tier, usage, quota, err := qs.usage(userID)
+	if err != nil {
+		return models.StorageUsageResponse{}, err
+	}
+
+	return models.ParseStorageUsageToResponse(tier, usage, quota), nil
+}
+
+// CheckQuota reports with ErrQuotaExceeded whether storing bytes more in
+// photos more photos would take userID over their quota.
+func (qs *quotaUsecase) CheckQuota(userID int, bytes, photos int64) error {
+	_, usage, quota, err := qs.usage(userID)
+	if err != nil {
+		return err
+	}
+
+	if quota.MaxPhotos > 0 && usage.Photos+photos > quota.MaxPhotos {
+		return fmt.Errorf("%w: you can keep at most %d photos", ErrQuotaExceeded, quota.MaxPhotos)
+	}
+	if quota.MaxBytes > 0 && usage.Bytes+bytes > quota.MaxBytes {
+		return fmt.Errorf("%w: %d of %d bytes used", ErrQuotaExceeded, usage.Bytes, quota.MaxBytes)
+	}
+
+	return nil
+}
+
+// CheckStored is CheckQuota for media that is already stored but not saved
+// yet. The checks before an upload only know the size of the originals, so
+// this one adds up the variants as well.
+func (qs *quotaUsecase) CheckStored(userID int, objects []models.MediaObject, photos int64) error {
+	var bytes int64
+	for _, object := range objects {
+		bytes += object.Size
+		for _, v := range object.Variants {
+			bytes += v.Size
+		}
+	}
+
+	return qs.CheckQuota(userID, bytes, photos)
+}
+
+// RemainingBytes returns how many bytes userID may still store, or
+// math.MaxInt64 without a limit.
+func (qs *quotaUsecase) RemainingBytes(userID int) (int64, error) {
+	_, usage, quota, err := qs.usage(userID)
+	if err != nil || quota.MaxBytes == 0 {
+		return math.MaxInt64, err
+	}
+	if usage.Bytes >= quota.MaxBytes {
+		return 0, nil
+	}
+
+	return quota.MaxBytes - usage.Bytes, nil
+}
@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"math"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type storageUsageRepository struct {
+	repositories.PhotoRepository
+	usage models.StorageUsage
+}
+
+func (r storageUsageRepository) GetStorageUsage(userID int) (models.StorageUsage, error) {
+	return r.usage, nil
+}
+
+type quotaUserRepository struct {
+	repositories.UserRepository
+	tier string
+}
+
+func (r quotaUserRepository) GetUserById(userId int) (models.User, error) {
+	return models.User{Model: gorm.Model{ID: uint(userId)}, QuotaTier: r.tier}, nil
+}
+
+func TestCheckQuota(t *testing.T) {
+	quotas := map[string]models.StorageQuota{
+		models.UserQuotaStandard: {MaxBytes: 1000, MaxPhotos: 2},
+		models.UserQuotaExtended: {MaxBytes: 5000},
+	}
+	usage := models.StorageUsage{Bytes: 900, Photos: 2}
+
+	quotaUsecase := NewQuotaUsecase(storageUsageRepository{usage: usage}, quotaUserRepository{tier: models.UserQuotaStandard}, quotas)
+
+	assert.ErrorIs(t, quotaUsecase.CheckQuota(1, 10, 1), ErrQuotaExceeded, "the photo limit is reached")
+	assert.NoError(t, quotaUsecase.CheckQuota(1, 100, 0))
+	assert.ErrorIs(t, quotaUsecase.CheckQuota(1, 101, 0), ErrQuotaExceeded)
+
+	stored := []models.MediaObject{{Size: 80, Variants: map[string]models.MediaObject{"thumb": {Size: 30}}}}
+	assert.ErrorIs(t, quotaUsecase.CheckStored(1, stored, 0), ErrQuotaExceeded, "variants count towards the quota")
+	stored[0].Variants["thumb"] = models.MediaObject{Size: 20}
+	assert.NoError(t, quotaUsecase.CheckStored(1, stored, 0))
+
+	remaining, err := quotaUsecase.RemainingBytes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), remaining)
+
+	response, err := quotaUsecase.GetUsage(1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserQuotaStandard, response.Tier)
+	assert.Equal(t, int64(1000), response.BytesLimit)
+
+	quotaUsecase = NewQuotaUsecase(storageUsageRepository{usage: usage}, quotaUserRepository{tier: models.UserQuotaExtended}, quotas)
+
+	assert.NoError(t, quotaUsecase.CheckQuota(1, 4000, 50), "the extended tier has no photo limit")
+	remaining, err = quotaUsecase.RemainingBytes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4100), remaining)
+
+	quotaUsecase = NewQuotaUsecase(storageUsageRepository{usage: usage}, quotaUserRepository{tier: "unknown"}, quotas)
+
+	assert.ErrorIs(t, quotaUsecase.CheckQuota(1, 0, 1), ErrQuotaExceeded, "unknown tiers get the standard quota")
+}
+
+func TestUploadQuota(t *testing.T) {
+	uploadUsecase, _ := newTestUploadUsecase(t)
+	uploadUsecase.quotaUsecase = NewQuotaUsecase(storageUsageRepository{usage: models.StorageUsage{Bytes: 900}}, quotaUserRepository{},
+		map[string]models.StorageQuota{models.UserQuotaStandard: {MaxBytes: 1000}})
+
+	_, err := uploadUsecase.CreateUpload(1, 101, "")
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	_, err = uploadUsecase.CreateUpload(1, 100, "")
+	assert.NoError(t, err)
+
+	_, _, err = uploadUsecase.PresignUpload(1, models.PresignUploadInput{ContentType: "image/png", Size: 101, Checksum: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	unlimited := NewQuotaUsecase(storageUsageRepository{}, quotaUserRepository{}, nil)
+	remaining, err := unlimited.RemainingBytes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64), remaining)
+}
@@ -0,0 +1,132 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrRepostNotAllowed = errors.New("The author does not allow reposts of this photo")
+	ErrRepostDuplicate  = errors.New("You have already reposted this photo")
+	ErrRepostNotFound   = errors.New("Repost not found")
+
+	// ErrRepostPhotoNotFound is returned for photos the user may not see,
+	// including those of users they blocked or who blocked them.
+	ErrRepostPhotoNotFound = errors.New("Photo not found")
+)
+
+type RepostUsecase interface {
+	CreateRepost(photoID, userID int, input models.RepostInput) (models.Repost, error)
+	DeleteRepost(repostID, userID int) error
+	GetPhotoReposts(photoID, viewerID int) ([]models.Repost, error)
+	GetFeedReposts(viewerID, page, limit int) ([]models.Repost, error)
+}
+
+type repostUsecase struct {
+	repository      repositories.RepostRepository
+	photoRepository repositories.PhotoRepository
+	blockRepository repositories.BlockRepository
+}
+
+func NewRepostUsecase(repository repositories.RepostRepository, photoRepository repositories.PhotoRepository, blockRepository repositories.BlockRepository) *repostUsecase {
+	return &repostUsecase{repository, photoRepository, blockRepository}
+}
+
+// CreateRepost godoc
+// @Summary      Repost photo
+// @Description  Share the public photo of another user with your followers. Add a caption to quote-share it. The repost is shown in feeds while the photo stays public and its author allows reposts, and is removed with the photo
+// @Tags         Repost
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RepostInput true "Payload Body [RAW]"
+// @Success      201
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      409
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Router       /photos/{id}/reposts [post]
+// @Security BearerAuth
+func (rs *repostUsecase) CreateRepost(photoID, userID int, input models.RepostInput) (models.Repost, error) {
+	photo, err := rs.photoRepository.FindVisibleByID(photoID, userID)
+	if err != nil {
+		return models.Repost{}, ErrRepostPhotoNotFound
+	}
+	if photo.UserID == userID {
+		return models.Repost{}, errors.New("You cannot repost your own photo")
+	}
+
+	blocked, err := rs.blockRepository.IsBlocked(userID, photo.UserID)
+	if err != nil || blocked {
+		return models.Repost{}, ErrRepostPhotoNotFound
+	}
+	if photo.Visibility != models.PhotoVisibilityPublic || !photo.User.AllowReposts {
+		return models.Repost{}, ErrRepostNotAllowed
+	}
+
+	input.Caption = strings.TrimSpace(input.Caption)
+	if utf8.RuneCountInString(input.Caption) > models.MaxRepostCaption {
+		return models.Repost{}, fmt.Errorf("Caption must be at most %d characters", models.MaxRepostCaption)
+	}
+
+	_, err = rs.repository.FindRepost(userID, photo.ID)
+	if err == nil {
+		return models.Repost{}, ErrRepostDuplicate
+	}
+
+	return rs.repository.CreateRepost(models.Repost{UserID: userID, PhotoID: photo.ID, Caption: input.Caption})
+}
+
+// DeleteRepost godoc
+// @Summary      Delete repost
+// @Description  Delete one of your reposts
+// @Tags         Repost
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Repost ID"
+// @Router       /reposts/{id} [delete]
+// @Security BearerAuth
+func (rs *repostUsecase) DeleteRepost(repostID, userID int) error {
+	repost, err := rs.repository.FindByID(repostID)
+	if err != nil || repost.UserID != userID {
+		return ErrRepostNotFound
+	}
+
+	return rs.repository.DeleteRepost(repost)
+}
+
+// GetPhotoReposts godoc
+// @Summary      Get photo reposts
+// @Description  Get who reposted a photo you may see, most recent first, with the captions of quote-shares
+// @Tags         Repost
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Photo ID"
+// @Router       /photos/{id}/reposts [get]
+// @Security BearerAuth
+func (rs *repostUsecase) GetPhotoReposts(photoID, viewerID int) ([]models.Repost, error) {
+	photo, err := rs.photoRepository.FindVisibleByID(photoID, viewerID)
+	if err != nil {
+		return nil, ErrRepostPhotoNotFound
+	}
+
+	return rs.repository.GetPhotoReposts(photo.ID)
+}
+
+// GetFeedReposts returns the reposts shown in the feed of viewerID, their
+// own and those of the users they follow, up to the given feed page. Any of
+// them may be on that page, so all page*limit most recent ones are loaded.
+func (rs *repostUsecase) GetFeedReposts(viewerID, page, limit int) ([]models.Repost, error) {
+	return rs.repository.GetFeedReposts(viewerID, page*limit)
+}
@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// visiblePhotoRepository finds the photos a viewer who follows nobody may
+// see.
+type visiblePhotoRepository struct {
+	repositories.PhotoRepository
+	photos map[int]models.Photo
+}
+
+func (r visiblePhotoRepository) FindVisibleByID(photoID, viewerID int) (models.Photo, error) {
+	photo, ok := r.photos[photoID]
+	if !ok || !photo.VisibleTo(viewerID, false) {
+		return photo, gorm.ErrRecordNotFound
+	}
+	return photo, nil
+}
+
+type memoryRepostRepository struct {
+	repositories.RepostRepository
+	reposts []models.Repost
+}
+
+func (r *memoryRepostRepository) CreateRepost(repost models.Repost) (models.Repost, error) {
+	repost.ID = uint(len(r.reposts) + 1)
+	r.reposts = append(r.reposts, repost)
+	return repost, nil
+}
+
+func (r *memoryRepostRepository) FindRepost(userID int, photoID uint) (models.Repost, error) {
+	for _, repost := range r.reposts {
+		if repost.UserID == userID && repost.PhotoID == photoID {
+			return repost, nil
+		}
+	}
+	return models.Repost{}, gorm.ErrRecordNotFound
+}
+
+func TestCreateRepost(t *testing.T) {
+	photo := func(id uint, userID int, visibility string, allowReposts bool) models.Photo {
+		return models.Photo{Model: gorm.Model{ID: id}, UserID: userID, Visibility: visibility, Status: models.PhotoStatusPublished,
+			User: models.User{AllowReposts: allowReposts}}
+	}
+	photos := visiblePhotoRepository{photos: map[int]models.Photo{
+		1: photo(1, 10, models.PhotoVisibilityPublic, true),
+		2: photo(2, 10, models.PhotoVisibilityPublic, false),
+		3: photo(3, 10, models.PhotoVisibilityPrivate, true),
+		4: photo(4, 30, models.PhotoVisibilityPublic, true),
+	}}
+	repository := &memoryRepostRepository{}
+	blocks := blockedRepository{blocked: map[[2]int]bool{{30, 20}: true}}
+	repostUsecase := NewRepostUsecase(repository, photos, blocks)
+
+	repost, err := repostUsecase.CreateRepost(1, 20, models.RepostInput{Caption: "  Pengen ke sini juga "})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), repost.PhotoID)
+	assert.Equal(t, "Pengen ke sini juga", repost.Caption)
+
+	_, err = repostUsecase.CreateRepost(1, 20, models.RepostInput{})
+	assert.ErrorIs(t, err, ErrRepostDuplicate)
+
+	_, err = repostUsecase.CreateRepost(2, 20, models.RepostInput{})
+	assert.ErrorIs(t, err, ErrRepostNotAllowed, "the author disallows reposts")
+
+	_, err = repostUsecase.CreateRepost(3, 20, models.RepostInput{})
+	assert.ErrorIs(t, err, ErrRepostPhotoNotFound, "private photos cannot be seen")
+
+	_, err = repostUsecase.CreateRepost(4, 20, models.RepostInput{})
+	assert.ErrorIs(t, err, ErrRepostPhotoNotFound, "blocked users cannot repost")
+
+	_, err = repostUsecase.CreateRepost(1, 10, models.RepostInput{})
+	assert.Error(t, err, "authors cannot repost their own photos")
+
+	_, err = repostUsecase.CreateRepost(1, 40, models.RepostInput{Caption: strings.Repeat("a", models.MaxRepostCaption+1)})
+	assert.Error(t, err)
+	assert.Len(t, repository.reposts, 1)
+}
+
+func TestFeedIsNewestFirstAndPaged(t *testing.T) {
+	at := func(minutes int) time.Time {
+		return time.Date(2023, 5, 1, 9, minutes, 0, 0, time.UTC)
+	}
+	scheduled := at(30)
+
+	photos := []models.Photo{
+		{Model: gorm.Model{ID: 2, CreatedAt: at(0)}, Title: "Scheduled", PublishAt: &scheduled},
+		{Model: gorm.Model{ID: 1, CreatedAt: at(10)}, Title: "Posted"},
+	}
+	reposts := []models.Repost{
+		{ID: 1, Photo: models.Photo{Title: "Reposted"}, CreatedAt: at(20)},
+	}
+
+	titles := func(feed []models.PhotoResponse) []string {
+		var titles []string
+		for _, response := range feed {
+			titles = append(titles, response.Title)
+		}
+		return titles
+	}
+
+	assert.Equal(t, []string{"Scheduled", "Reposted", "Posted"}, titles(models.ParseFeedToResponse(photos, reposts, 1, 3)))
+	assert.Equal(t, []string{"Scheduled", "Reposted"}, titles(models.ParseFeedToResponse(photos, reposts, 1, 2)))
+	assert.Equal(t, []string{"Posted"}, titles(models.ParseFeedToResponse(photos, reposts, 2, 2)))
+	assert.Empty(t, models.ParseFeedToResponse(photos, reposts, 3, 2))
+}
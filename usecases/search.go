@@ -0,0 +1,108 @@
+package usecases
+
+import (
+	"errors"
+	"log"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"strings"
+)
+
+type SearchUsecase interface {
+	Search(query string, types []string, viewerID, page, limit int) ([]models.SearchResult, error)
+}
+
+type searchUsecase struct {
+	index             repositories.SearchIndex
+	photoRepository   repositories.PhotoRepository
+	userRepository    repositories.UserRepository
+	commentRepository repositories.CommentRepository
+	followRepository  repositories.FollowRepository
+}
+
+func NewSearchUsecase(index repositories.SearchIndex, photoRepository repositories.PhotoRepository, userRepository repositories.UserRepository, commentRepository repositories.CommentRepository, followRepository repositories.FollowRepository) *searchUsecase {
+	return &searchUsecase{index, photoRepository, userRepository, commentRepository, followRepository}
+}
+
+// logIndexError reports a failed search index update. The write that caused
+// it has already been committed, so the error is not returned to the user.
+func logIndexError(err error) {
+	if err != nil {
+		log.Printf("search index: %v", err)
+	}
+}
+
+// Search godoc
+// @Summary      Search
+// @Description  Search photo titles and captions, usernames and full names, and comment messages. Results are ranked by relevance and photos the user may not see are left out
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param q query string true "Search query"
+// @Param type query string false "Comma separated result types: photo, user, comment"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(20)
+// @Router       /search [get]
+// @Security BearerAuth
+func (ss *searchUsecase) Search(query string, types []string, viewerID, page, limit int) ([]models.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("Search query is required")
+	}
+
+	if len(types) == 0 {
+		types = models.SearchTypes
+	}
+	for _, docType := range types {
+		valid := false
+		for _, searchType := range models.SearchTypes {
+			valid = valid || docType == searchType
+		}
+		if !valid {
+			return nil, errors.New("Type must be photo, user or comment")
+		}
+	}
+
+	hits, err := ss.index.Search(query, types, viewerID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The MySQL index already leaves out what the viewer may not see. These
+	// checks cover indexes that cannot, and records changed since matching.
+	setting := sensitiveContentSetting(ss.userRepository, viewerID)
+
+	results := make([]models.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		result := models.SearchResult{Type: hit.Type, Score: hit.Score}
+
+		switch hit.Type {
+		case models.SearchTypePhoto:
+			photo, err := ss.photoRepository.FindByID(int(hit.ID))
+			if err != nil || photo.User.ID == 0 || !canViewPhoto(ss.followRepository, photo, viewerID) || photo.HiddenBySetting(viewerID, setting) {
+				continue
+			}
+			result.Photo = &photo
+		case models.SearchTypeUser:
+			user, err := ss.userRepository.GetUserById(int(hit.ID))
+			if err != nil {
+				continue
+			}
+			result.User = &user
+		case models.SearchTypeComment:
+			comment, err := ss.commentRepository.FindByID(int(hit.ID))
+			if err != nil || comment.HiddenAt != nil || comment.User.ID == 0 || comment.Photo.ID == 0 || !canViewPhoto(ss.followRepository, comment.Photo, viewerID) {
+				continue
+			}
+			result.Comment = &comment
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,469 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"strings"
+	"time"
+)
+
+const expireStoriesBatch = 100
+
+var (
+	ErrStoryNotFound     = errors.New("Story not found")
+	ErrHighlightNotFound = errors.New("Highlight not found")
+	ErrStoriesFollowOnly = errors.New("Follow this user to see their stories")
+)
+
+// StoryUsecase manages stories, which followers see in their story tray for
+// models.StoryLifetime, and the highlights that keep them on the profile of
+// their author afterwards.
+type StoryUsecase interface {
+	CreateStory(userID int, object models.MediaObject) (models.Story, error)
+	GetTray(viewerID int) ([]models.StoryTrayEntry, error)
+	GetUserStories(username string, viewerID int) ([]models.StoryResponse, error)
+	MarkSeen(storyID, viewerID int) error
+	GetViewers(storyID, userID int) ([]models.StoryView, error)
+	GetArchive(userID int) ([]models.Story, error)
+	DeleteStory(storyID, userID int) error
+	CreateHighlight(userID int, input models.HighlightInput) (models.StoryHighlight, error)
+	AddHighlightStory(highlightID, userID int, input models.HighlightStoryInput) (models.StoryHighlight, error)
+	GetHighlights(username string, viewerID int) ([]models.StoryHighlight, error)
+	DeleteHighlight(highlightID, userID int) error
+	ExpireStories() (int, error)
+}
+
+type storyUsecase struct {
+	repository       repositories.StoryRepository
+	userRepository   repositories.UserRepository
+	followRepository repositories.FollowRepository
+	blockRepository  repositories.BlockRepository
+	mediaUpload      MediaUpload
+	retention        time.Duration
+	now              func() time.Time
+}
+
+// NewStoryUsecase returns a StoryUsecase keeping expired stories for
+// retention before their media is removed.
+func NewStoryUsecase(repository repositories.StoryRepository, userRepository repositories.UserRepository, followRepository repositories.FollowRepository,
+	blockRepository repositories.BlockRepository, mediaUpload MediaUpload, retention time.Duration) *storyUsecase {
+	return &storyUsecase{repository, userRepository, followRepository, blockRepository, mediaUpload, retention, time.Now}
+}
+
+// canViewStories reports whether viewerID may see the active stories of
+// authorID, which are shown to the author's followers.
+func (ss *storyUsecase) canViewStories(authorID, viewerID int) bool {
+	if authorID == viewerID {
+		return true
+	}
+	following, err := ss.followRepository.IsFollowing(viewerID, authorID)
+	return err == nil && following
+}
+
+// findAuthor returns the user with username unless either of them blocked
+// the other.
+func (ss *storyUsecase) findAuthor(username string, viewerID int) (models.User, error) {
+	author, err := ss.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return author, errors.New("User not found")
+	}
+
+	blocked, err := ss.blockRepository.IsBlocked(int(author.ID), viewerID)
+	if err != nil || blocked {
+		return models.User{}, errors.New("User not found")
+	}
+
+	return author, nil
+}
+
+func (ss *storyUsecase) seenStories(viewerID int, stories []models.Story) (map[uint]bool, error) {
+	ids := make([]uint, 0, len(stories))
+	for _, story := range stories {
+		ids = append(ids, story.ID)
+	}
+
+	seenIDs, err := ss.repository.GetSeenStoryIDs(viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool, len(seenIDs))
+	for _, id := range seenIDs {
+		seen[id] = true
+	}
+	return seen, nil
+}
+
+// CreateStory godoc
+// @Summary      Post story
+// @Description  Post an image as a story. Followers see it in their story tray for 24 hours; after that it stays in your archive for a while, where it can be saved into a highlight
+// @Tags         Story
+// @Accept       mpfd
+// @Produce      json
+// @Param        file formData file true "Story image"
+// @Success      201
+// @Failure      400
+// @Failure      403
+// @Failure      413
+// @Failure      415
+// @Failure      500
+// @Router       /stories [post]
+// @Security BearerAuth
+func (ss *storyUsecase) CreateStory(userID int, object models.MediaObject) (models.Story, error) {
+	story, err := ss.repository.CreateStory(models.MediaStory(userID, object, ss.now()))
+	if err != nil {
+		logMediaError(ss.mediaUpload.DeleteMedia(object.StorageKeys()))
+		return story, err
+	}
+
+	return story, nil
+}
+
+// GetTray godoc
+// @Summary      Get story tray
+// @Description  Get the active stories of the users you follow, grouped by user. Users with stories you have not seen come first, then the most recently active ones
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      500
+// @Router       /stories/tray [get]
+// @Security BearerAuth
+func (ss *storyUsecase) GetTray(viewerID int) ([]models.StoryTrayEntry, error) {
+	stories, err := ss.repository.GetTrayStories(viewerID, ss.now())
+	if err != nil {
+		return nil, err
+	}
+
+	seen, err := ss.seenStories(viewerID, stories)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ParseStoryTray(stories, seen), nil
+}
+
+// GetUserStories godoc
+// @Summary      Get user stories
+// @Description  Get the active stories of a user you follow, oldest first, telling which ones you have seen
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/stories [get]
+// @Security BearerAuth
+func (ss *storyUsecase) GetUserStories(username string, viewerID int) ([]models.StoryResponse, error) {
+	author, err := ss.findAuthor(username, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !ss.canViewStories(int(author.ID), viewerID) {
+		return nil, ErrStoriesFollowOnly
+	}
+
+	stories, err := ss.repository.GetActiveStories(int(author.ID), ss.now())
+	if err != nil {
+		return nil, err
+	}
+
+	seen, err := ss.seenStories(viewerID, stories)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ParseStoriesToResponse(stories, seen), nil
+}
+
+// MarkSeen godoc
+// @Summary      Mark story seen
+// @Description  Record that you have seen an active story, which moves its author down your story tray once all of their stories are seen. Seeing your own stories is not recorded
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Story ID"
+// @Router       /stories/{id}/seen [post]
+// @Security BearerAuth
+func (ss *storyUsecase) MarkSeen(storyID, viewerID int) error {
+	story, err := ss.repository.FindByID(storyID)
+	if err != nil || story.Expired(ss.now()) || !ss.canViewStories(story.UserID, viewerID) {
+		return ErrStoryNotFound
+	}
+	if story.UserID == viewerID {
+		return nil
+	}
+
+	return ss.repository.AddView(models.StoryView{StoryID: story.ID, ViewerID: viewerID})
+}
+
+// GetViewers godoc
+// @Summary      Get story viewers
+// @Description  Get who has seen one of your stories, most recent first
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Story ID"
+// @Router       /stories/{id}/viewers [get]
+// @Security BearerAuth
+func (ss *storyUsecase) GetViewers(storyID, userID int) ([]models.StoryView, error) {
+	story, err := ss.repository.FindByID(storyID)
+	if err != nil || story.UserID != userID {
+		return nil, ErrStoryNotFound
+	}
+
+	return ss.repository.GetViewers(story.ID)
+}
+
+// GetArchive godoc
+// @Summary      Get story archive
+// @Description  Get your expired stories that are still kept, newest first. Save them into a highlight to keep them on your profile; the others are removed after a while
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      500
+// @Router       /stories/archive [get]
+// @Security BearerAuth
+func (ss *storyUsecase) GetArchive(userID int) ([]models.Story, error) {
+	return ss.repository.GetArchivedStories(userID, ss.now())
+}
+
+// DeleteStory godoc
+// @Summary      Delete story
+// @Description  Delete one of your stories together with its image, also from the highlights holding it
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Story ID"
+// @Router       /stories/{id} [delete]
+// @Security BearerAuth
+func (ss *storyUsecase) DeleteStory(storyID, userID int) error {
+	story, err := ss.repository.FindByID(storyID)
+	if err != nil || story.UserID != userID {
+		return ErrStoryNotFound
+	}
+
+	err = ss.repository.DeleteStory(story)
+	if err != nil {
+		return err
+	}
+
+	logMediaError(ss.mediaUpload.DeleteMedia(story.StorageKeys))
+
+	return nil
+}
+
+// ownStories loads the stories of storyIDs, which have to belong to userID.
+func (ss *storyUsecase) ownStories(userID int, storyIDs []uint) ([]models.Story, error) {
+	stories := make([]models.Story, 0, len(storyIDs))
+	seen := make(map[uint]bool, len(storyIDs))
+
+	for _, id := range storyIDs {
+		if seen[id] {
+			return nil, errors.New("Story is listed more than once")
+		}
+		seen[id] = true
+
+		story, err := ss.repository.FindByID(int(id))
+		if err != nil || story.UserID != userID {
+			return nil, ErrStoryNotFound
+		}
+		stories = append(stories, story)
+	}
+
+	return stories, nil
+}
+
+// CreateHighlight godoc
+// @Summary      Create highlight
+// @Description  Create a highlight on your profile from your stories, active or archived. Stories in a highlight are kept after they expire
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Param        request body models.HighlightInput true "Payload Body [RAW]"
+// @Success      201
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /highlights [post]
+// @Security BearerAuth
+func (ss *storyUsecase) CreateHighlight(userID int, input models.HighlightInput) (models.StoryHighlight, error) {
+	input.Title = strings.TrimSpace(input.Title)
+	if input.Title == "" || len(input.Title) > 64 {
+		return models.StoryHighlight{}, errors.New("Title must be between 1 and 64 characters")
+	}
+	if len(input.StoryIDs) == 0 {
+		return models.StoryHighlight{}, errors.New("A highlight needs at least one story")
+	}
+	if len(input.StoryIDs) > models.MaxHighlightStories {
+		return models.StoryHighlight{}, fmt.Errorf("A highlight can hold at most %d stories", models.MaxHighlightStories)
+	}
+
+	stories, err := ss.ownStories(userID, input.StoryIDs)
+	if err != nil {
+		return models.StoryHighlight{}, err
+	}
+
+	highlight, err := ss.repository.CreateHighlight(models.StoryHighlight{UserID: userID, Title: input.Title, Stories: stories})
+	if err != nil {
+		return highlight, err
+	}
+
+	return ss.repository.FindHighlight(int(highlight.ID))
+}
+
+// AddHighlightStory godoc
+// @Summary      Add story to highlight
+// @Description  Save one of your stories, active or archived, into one of your highlights
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Param        request body models.HighlightStoryInput true "Payload Body [RAW]"
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Highlight ID"
+// @Router       /highlights/{id}/stories [post]
+// @Security BearerAuth
+func (ss *storyUsecase) AddHighlightStory(highlightID, userID int, input models.HighlightStoryInput) (models.StoryHighlight, error) {
+	highlight, err := ss.repository.FindHighlight(highlightID)
+	if err != nil || highlight.UserID != userID {
+		return models.StoryHighlight{}, ErrHighlightNotFound
+	}
+
+	for _, story := range highlight.Stories {
+		if story.ID == input.StoryID {
+			return highlight, nil
+		}
+	}
+	if len(highlight.Stories) >= models.MaxHighlightStories {
+		return highlight, fmt.Errorf("A highlight can hold at most %d stories", models.MaxHighlightStories)
+	}
+
+	stories, err := ss.ownStories(userID, []uint{input.StoryID})
+	if err != nil {
+		return highlight, err
+	}
+
+	err = ss.repository.AddHighlightStories(highlight, stories)
+	if err != nil {
+		return highlight, err
+	}
+
+	return ss.repository.FindHighlight(highlightID)
+}
+
+// GetHighlights godoc
+// @Summary      Get user highlights
+// @Description  Get the highlights on the profile of a user
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Router       /users/{username}/highlights [get]
+// @Security BearerAuth
+func (ss *storyUsecase) GetHighlights(username string, viewerID int) ([]models.StoryHighlight, error) {
+	author, err := ss.findAuthor(username, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.repository.GetHighlights(int(author.ID))
+}
+
+// DeleteHighlight godoc
+// @Summary      Delete highlight
+// @Description  Delete one of your highlights. Its expired stories go back to the archive and are removed after a while
+// @Tags         Story
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      404
+// @Failure      500
+// @Param id path int true "Highlight ID"
+// @Router       /highlights/{id} [delete]
+// @Security BearerAuth
+func (ss *storyUsecase) DeleteHighlight(highlightID, userID int) error {
+	highlight, err := ss.repository.FindHighlight(highlightID)
+	if err != nil || highlight.UserID != userID {
+		return ErrHighlightNotFound
+	}
+
+	return ss.repository.DeleteHighlight(highlight)
+}
+
+// ExpireStories removes the stories that expired more than the retention
+// ago and are in no highlight, with their media, and returns how many were
+// removed.
+func (ss *storyUsecase) ExpireStories() (int, error) {
+	before := ss.now().Add(-ss.retention)
+	var expired int
+
+	for {
+		stories, err := ss.repository.GetExpiredStories(before, expireStoriesBatch)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, story := range stories {
+			err := ss.repository.DeleteStory(story)
+			if err != nil {
+				return expired, err
+			}
+			logMediaError(ss.mediaUpload.DeleteMedia(story.StorageKeys))
+			expired++
+		}
+
+		if len(stories) < expireStoriesBatch {
+			return expired, nil
+		}
+	}
+}
+
+// StartStoryExpirer removes expired stories every interval in the
+// background until the returned function is called.
+func StartStoryExpirer(storyUsecase StoryUsecase, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				expired, err := storyUsecase.ExpireStories()
+				if expired > 0 {
+					log.Printf("story expirer: removed %d stories", expired)
+				}
+				if err != nil {
+					log.Printf("story expirer: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
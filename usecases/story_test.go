@@ -0,0 +1,210 @@
+package usecases
+
+import (
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStoryRepository keeps stories, views and highlights in memory. The
+// tray holds the stories of every user but the viewer.
+type memoryStoryRepository struct {
+	repositories.StoryRepository
+	stories    map[uint]models.Story
+	views      map[uint]map[int]bool
+	highlights map[uint]models.StoryHighlight
+}
+
+func newMemoryStoryRepository(stories ...models.Story) *memoryStoryRepository {
+	repository := &memoryStoryRepository{
+		stories:    map[uint]models.Story{},
+		views:      map[uint]map[int]bool{},
+		highlights: map[uint]models.StoryHighlight{},
+	}
+	for _, story := range stories {
+		repository.stories[story.ID] = story
+	}
+	return repository
+}
+
+func (r *memoryStoryRepository) FindByID(storyID int) (models.Story, error) {
+	story, ok := r.stories[uint(storyID)]
+	if !ok {
+		return story, errors.New("record not found")
+	}
+	return story, nil
+}
+
+func (r *memoryStoryRepository) DeleteStory(story models.Story) error {
+	delete(r.stories, story.ID)
+	delete(r.views, story.ID)
+	return nil
+}
+
+func (r *memoryStoryRepository) GetTrayStories(viewerID int, now time.Time) ([]models.Story, error) {
+	var stories []models.Story
+	for id := uint(1); id <= uint(len(r.stories)); id++ {
+		story, ok := r.stories[id]
+		if ok && story.UserID != viewerID && !story.Expired(now) {
+			stories = append(stories, story)
+		}
+	}
+	return stories, nil
+}
+
+func (r *memoryStoryRepository) GetExpiredStories(before time.Time, limit int) ([]models.Story, error) {
+	var stories []models.Story
+	for _, story := range r.stories {
+		if story.ExpiresAt.After(before) || r.inHighlight(story.ID) {
+			continue
+		}
+		stories = append(stories, story)
+	}
+	return stories, nil
+}
+
+func (r *memoryStoryRepository) inHighlight(storyID uint) bool {
+	for _, highlight := range r.highlights {
+		for _, story := range highlight.Stories {
+			if story.ID == storyID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *memoryStoryRepository) AddView(view models.StoryView) error {
+	if r.views[view.StoryID] == nil {
+		r.views[view.StoryID] = map[int]bool{}
+	}
+	r.views[view.StoryID][view.ViewerID] = true
+	return nil
+}
+
+func (r *memoryStoryRepository) GetSeenStoryIDs(viewerID int, storyIDs []uint) ([]uint, error) {
+	var ids []uint
+	for _, id := range storyIDs {
+		if r.views[id][viewerID] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryStoryRepository) GetViewers(storyID uint) ([]models.StoryView, error) {
+	var views []models.StoryView
+	for viewerID := range r.views[storyID] {
+		views = append(views, models.StoryView{StoryID: storyID, ViewerID: viewerID})
+	}
+	return views, nil
+}
+
+func (r *memoryStoryRepository) CreateHighlight(highlight models.StoryHighlight) (models.StoryHighlight, error) {
+	highlight.ID = uint(len(r.highlights) + 1)
+	r.highlights[highlight.ID] = highlight
+	return highlight, nil
+}
+
+func (r *memoryStoryRepository) FindHighlight(highlightID int) (models.StoryHighlight, error) {
+	highlight, ok := r.highlights[uint(highlightID)]
+	if !ok {
+		return highlight, errors.New("record not found")
+	}
+	return highlight, nil
+}
+
+// deletedMediaUpload records the keys of the media it is asked to delete.
+type deletedMediaUpload struct {
+	MediaUpload
+	deleted []string
+}
+
+func (m *deletedMediaUpload) DeleteMedia(keys []string) error {
+	m.deleted = append(m.deleted, keys...)
+	return nil
+}
+
+func newTestStoryUsecase(repository *memoryStoryRepository, now time.Time) (*storyUsecase, *deletedMediaUpload) {
+	follows := followingRepository{following: map[[2]int]bool{{2, 1}: true, {2, 3}: true}}
+	mediaUpload := &deletedMediaUpload{}
+
+	storyUsecase := NewStoryUsecase(repository, nil, follows, nil, mediaUpload, 48*time.Hour)
+	storyUsecase.now = func() time.Time { return now }
+
+	return storyUsecase, mediaUpload
+}
+
+func TestStoryTray(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	story := func(id uint, userID int, age time.Duration) models.Story {
+		createdAt := now.Add(-age)
+		return models.Story{ID: id, UserID: userID, User: models.User{Username: map[int]string{1: "hanif", 3: "budi"}[userID]},
+			CreatedAt: createdAt, ExpiresAt: createdAt.Add(models.StoryLifetime)}
+	}
+	repository := newMemoryStoryRepository(
+		story(1, 1, 3*time.Hour),
+		story(2, 3, 2*time.Hour),
+		story(3, 1, time.Hour),
+		story(4, 3, 30*time.Hour),
+	)
+	storyUsecase, _ := newTestStoryUsecase(repository, now)
+
+	tray, err := storyUsecase.GetTray(2)
+	assert.NoError(t, err)
+	if assert.Len(t, tray, 2, "expired stories leave the tray") {
+		assert.Equal(t, "hanif", tray[0].User.Username, "the most recently active user comes first")
+		assert.Equal(t, []uint{1, 3}, []uint{tray[0].Stories[0].ID, tray[0].Stories[1].ID})
+	}
+
+	assert.NoError(t, storyUsecase.MarkSeen(1, 2))
+	assert.NoError(t, storyUsecase.MarkSeen(3, 2))
+	assert.ErrorIs(t, storyUsecase.MarkSeen(4, 2), ErrStoryNotFound, "expired stories cannot be seen")
+	assert.ErrorIs(t, storyUsecase.MarkSeen(2, 4), ErrStoryNotFound, "only followers see stories")
+	assert.NoError(t, storyUsecase.MarkSeen(1, 1))
+
+	tray, err = storyUsecase.GetTray(2)
+	assert.NoError(t, err)
+	if assert.Len(t, tray, 2) {
+		assert.Equal(t, "budi", tray[0].User.Username, "users with unseen stories come first")
+		assert.True(t, tray[0].HasUnseen)
+		assert.False(t, tray[1].HasUnseen)
+		assert.True(t, tray[1].Stories[0].Seen)
+	}
+
+	viewers, err := storyUsecase.GetViewers(1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, viewers, 1, "the author's own view is not recorded")
+
+	_, err = storyUsecase.GetViewers(1, 2)
+	assert.ErrorIs(t, err, ErrStoryNotFound, "only the author sees the viewers")
+}
+
+func TestExpireStories(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	repository := newMemoryStoryRepository(
+		models.Story{ID: 1, UserID: 1, StorageKeys: []string{"stories/a.jpg", "stories/a_thumb.jpg"}, ExpiresAt: now.Add(-72 * time.Hour)},
+		models.Story{ID: 2, UserID: 1, StorageKeys: []string{"stories/b.jpg"}, ExpiresAt: now.Add(-72 * time.Hour)},
+		models.Story{ID: 3, UserID: 1, StorageKeys: []string{"stories/c.jpg"}, ExpiresAt: now.Add(-time.Hour)},
+		models.Story{ID: 4, UserID: 2, StorageKeys: []string{"stories/d.jpg"}, ExpiresAt: now.Add(-72 * time.Hour)},
+	)
+	storyUsecase, mediaUpload := newTestStoryUsecase(repository, now)
+
+	_, err := storyUsecase.CreateHighlight(1, models.HighlightInput{Title: "Bali", StoryIDs: []uint{2, 4}})
+	assert.ErrorIs(t, err, ErrStoryNotFound, "only the author's stories can be highlighted")
+
+	highlight, err := storyUsecase.CreateHighlight(1, models.HighlightInput{Title: " Bali ", StoryIDs: []uint{2}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bali", highlight.Title)
+
+	expired, err := storyUsecase.ExpireStories()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, expired)
+	assert.ElementsMatch(t, []string{"stories/a.jpg", "stories/a_thumb.jpg", "stories/d.jpg"}, mediaUpload.deleted)
+	assert.Contains(t, repository.stories, uint(2), "highlighted stories are kept")
+	assert.Contains(t, repository.stories, uint(3), "archived stories are kept for the retention")
+}
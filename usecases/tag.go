@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"errors"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+)
+
+type TagUsecase interface {
+	GetTag(name string, viewerID int) (models.TagResponse, error)
+	GetTagPhotos(name string, viewerID, page, limit int) ([]models.Photo, error)
+}
+
+type tagUsecase struct {
+	repository repositories.TagRepository
+}
+
+func NewTagUsecase(repository repositories.TagRepository) *tagUsecase {
+	return &tagUsecase{repository}
+}
+
+// GetTag godoc
+// @Summary      Get tag
+// @Description  Get a hashtag with the number of posts using it that the user can see
+// @Tags         Tag
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param tag path string true "Tag name, with or without #"
+// @Router       /tags/{tag} [get]
+// @Security BearerAuth
+func (ts *tagUsecase) GetTag(name string, viewerID int) (models.TagResponse, error) {
+	var response models.TagResponse
+
+	tag, err := ts.repository.GetTagByName(helpers.NormalizeHashtag(name))
+	if err != nil {
+		return response, errors.New("Tag not found")
+	}
+
+	count, err := ts.repository.CountPhotosByTag(tag.ID, viewerID)
+	if err != nil {
+		return response, err
+	}
+
+	response.Name = tag.Name
+	response.PostCount = count
+
+	return response, nil
+}
+
+// GetTagPhotos godoc
+// @Summary      Get tag photos
+// @Description  Get the photos using a hashtag, newest first
+// @Tags         Tag
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param tag path string true "Tag name, with or without #"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Photos per page" default(20)
+// @Router       /tags/{tag}/photos [get]
+// @Security BearerAuth
+func (ts *tagUsecase) GetTagPhotos(name string, viewerID, page, limit int) ([]models.Photo, error) {
+	tag, err := ts.repository.GetTagByName(helpers.NormalizeHashtag(name))
+	if err != nil {
+		return nil, errors.New("Tag not found")
+	}
+
+	return ts.repository.GetPhotosByTag(tag.ID, viewerID, limit, (page-1)*limit)
+}
@@ -0,0 +1,591 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expireBatchSize is how many expired uploads are removed per query.
+const expireBatchSize = 100
+
+var (
+	ErrUploadNotFound   = errors.New("Upload not found")
+	ErrUploadExpired    = errors.New("Upload has expired")
+	ErrUploadLength     = errors.New("Upload-Length must be a positive number")
+	ErrUploadOffset     = errors.New("Upload-Offset does not match the upload")
+	ErrUploadLocked     = errors.New("Upload is being written by another request")
+	ErrUploadIncomplete = errors.New("Upload is not complete")
+	ErrUploadLimit      = fmt.Errorf("You can have at most %d unfinished uploads", models.MaxActiveUploads)
+	ErrPresignSize      = errors.New("Size must be a positive number")
+	ErrPresignChecksum  = errors.New("checksum_sha256 must be a hex encoded SHA-256 digest")
+)
+
+// UploadUsecase implements uploads that do not fit a single request:
+// resumable uploads, sent in chunks that are staged on the local disk until
+// they are complete and attached to a photo, and direct uploads to the media
+// store, which are checked when they are finalized.
+type UploadUsecase interface {
+	CreateUpload(userID int, length int64, metadata string) (models.Upload, error)
+	GetUpload(id string, userID int) (models.Upload, error)
+	WriteChunk(id string, userID int, offset int64, body io.Reader) (models.Upload, error)
+	StoreUploads(ids []string, userID int, keepLocation bool) ([]models.MediaObject, error)
+	FinishUploads(ids []string)
+	PresignUpload(userID int, input models.PresignUploadInput) (models.PresignedUpload, models.UploadForm, error)
+	FinalizeUpload(id string, userID int, keepLocation bool) (models.MediaObject, error)
+	ExpireUploads() (int, error)
+	MaxSize() int64
+}
+
+type uploadUsecase struct {
+	repository    repositories.UploadRepository
+	mediaUpload   MediaUpload
+	quotaUsecase  QuotaUsecase
+	dir           string
+	maxSize       int64
+	expiry        time.Duration
+	presignExpiry time.Duration
+
+	mu      sync.Mutex
+	writing map[string]bool
+}
+
+// NewUploadUsecase returns an UploadUsecase staging files of up to maxSize
+// bytes in dir. Uploads expire when they have not been written to for
+// expiry. Direct upload forms are valid for presignExpiry, and the uploads
+// can be finalized until expiry. Uploads must fit in the storage quota of
+// the user.
+func NewUploadUsecase(repository repositories.UploadRepository, mediaUpload MediaUpload, quotaUsecase QuotaUsecase, dir string, maxSize int64, expiry, presignExpiry time.Duration) *uploadUsecase {
+	return &uploadUsecase{
+		repository:    repository,
+		mediaUpload:   mediaUpload,
+		quotaUsecase:  quotaUsecase,
+		dir:           dir,
+		maxSize:       maxSize,
+		expiry:        expiry,
+		presignExpiry: presignExpiry,
+		writing:       make(map[string]bool),
+	}
+}
+
+func (us *uploadUsecase) MaxSize() int64 {
+	return us.maxSize
+}
+
+// validUploadID reports whether id looks like an ID from newUploadID, so it
+// is safe to use as a file name.
+func validUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func newUploadID() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+func (us *uploadUsecase) path(id string) string {
+	return filepath.Join(us.dir, id)
+}
+
+// lock marks id as being written, reporting false when another request
+// already is.
+func (us *uploadUsecase) lock(id string) bool {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if us.writing[id] {
+		return false
+	}
+	us.writing[id] = true
+	return true
+}
+
+func (us *uploadUsecase) unlock(id string) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	delete(us.writing, id)
+}
+
+// CreateUpload godoc
+// @Summary      Create upload
+// @Description  Start a tus 1.0 resumable upload of a single image. Send the size in Upload-Length and optionally tus Upload-Metadata; the upload URL is returned in Location
+// @Tags         Upload
+// @Produce      json
+// @Param        Tus-Resumable header string true "1.0.0"
+// @Param        Upload-Length header int true "Size of the file in bytes"
+// @Param        Upload-Metadata header string false "tus metadata, such as filename"
+// @Success      201
+// @Failure      400
+// @Failure      403
+// @Failure      412
+// @Failure      413
+// @Failure      500
+// @Router       /uploads [post]
+// @Security BearerAuth
+func (us *uploadUsecase) CreateUpload(userID int, length int64, metadata string) (models.Upload, error) {
+	if length < 1 {
+		return models.Upload{}, ErrUploadLength
+	}
+	if length > us.maxSize {
+		return models.Upload{}, fmt.Errorf("%w: the limit is %d bytes", helpers.ErrImageTooLarge, us.maxSize)
+	}
+
+	err := us.quotaUsecase.CheckQuota(userID, length, 0)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	now := time.Now()
+	active, err := us.repository.CountActiveUploads(userID, now)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	if active >= models.MaxActiveUploads {
+		return models.Upload{}, ErrUploadLimit
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	err = os.MkdirAll(us.dir, 0o755)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	file, err := os.OpenFile(us.path(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	err = file.Close()
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	upload, err := us.repository.CreateUpload(models.Upload{
+		ID:        id,
+		UserID:    userID,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: now.Add(us.expiry),
+	})
+	if err != nil {
+		logUploadError(os.Remove(us.path(id)))
+	}
+
+	return upload, err
+}
+
+// GetUpload godoc
+// @Summary      Get upload offset
+// @Description  Get how many bytes of a resumable upload have been received, in Upload-Offset, to resume it after a broken connection
+// @Tags         Upload
+// @Param        Tus-Resumable header string true "1.0.0"
+// @Param        id path string true "Upload ID"
+// @Success      200
+// @Failure      404
+// @Failure      410
+// @Failure      412
+// @Router       /uploads/{id} [head]
+// @Security BearerAuth
+func (us *uploadUsecase) GetUpload(id string, userID int) (models.Upload, error) {
+	if !validUploadID(id) {
+		return models.Upload{}, ErrUploadNotFound
+	}
+
+	upload, err := us.repository.FindUpload(id)
+	if err != nil || upload.UserID != userID {
+		return models.Upload{}, ErrUploadNotFound
+	}
+	if !time.Now().Before(upload.ExpiresAt) {
+		return upload, ErrUploadExpired
+	}
+
+	return upload, nil
+}
+
+// WriteChunk godoc
+// @Summary      Upload chunk
+// @Description  Append the request body to a resumable upload at Upload-Offset, which must match the bytes received so far. Every chunk extends the expiry time
+// @Tags         Upload
+// @Accept       application/offset+octet-stream
+// @Param        Tus-Resumable header string true "1.0.0"
+// @Param        Upload-Offset header int true "Offset the chunk starts at"
+// @Param        id path string true "Upload ID"
+// @Success      204
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      410
+// @Failure      412
+// @Failure      413
+// @Failure      415
+// @Failure      423
+// @Router       /uploads/{id} [patch]
+// @Security BearerAuth
+func (us *uploadUsecase) WriteChunk(id string, userID int, offset int64, body io.Reader) (models.Upload, error) {
+	upload, err := us.GetUpload(id, userID)
+	if err != nil {
+		return upload, err
+	}
+
+	if !us.lock(id) {
+		return upload, ErrUploadLocked
+	}
+	defer us.unlock(id)
+
+	// Read the upload again now that it is locked, in case a request that
+	// just finished moved the offset.
+	upload, err = us.GetUpload(id, userID)
+	if err != nil {
+		return upload, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrUploadOffset
+	}
+
+	written, err := us.appendChunk(upload, body)
+	if written == 0 {
+		return upload, err
+	}
+
+	// Record what arrived even if the connection broke off, so the client
+	// can resume from there.
+	from := upload.Offset
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().Add(us.expiry)
+
+	ok, updateErr := us.repository.UpdateOffset(upload, from)
+	if updateErr != nil {
+		return upload, updateErr
+	}
+	if !ok {
+		return upload, ErrUploadOffset
+	}
+
+	return upload, err
+}
+
+// appendChunk writes body to the staged file of upload at its offset. A
+// chunk that would make the file longer than the upload is rejected as a
+// whole.
+func (us *uploadUsecase) appendChunk(upload models.Upload, body io.Reader) (int64, error) {
+	file, err := os.OpenFile(us.path(upload.ID), os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// Drop bytes of an earlier request that were written but not recorded.
+	err = file.Truncate(upload.Offset)
+	if err != nil {
+		return 0, err
+	}
+	_, err = file.Seek(upload.Offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	remaining := upload.Length - upload.Offset
+	written, err := io.Copy(file, io.LimitReader(body, remaining+1))
+	if written > remaining {
+		logUploadError(file.Truncate(upload.Offset))
+		return 0, fmt.Errorf("%w: the upload is %d bytes long", helpers.ErrImageTooLarge, upload.Length)
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return written, err
+}
+
+// StoreUploads validates and stores the complete uploads ids of userID like
+// files of a multipart form. The uploads are kept until FinishUploads is
+// called, so they can be attached again if saving the photo fails.
+func (us *uploadUsecase) StoreUploads(ids []string, userID int, keepLocation bool) ([]models.MediaObject, error) {
+	files := make([]models.File, 0, len(ids))
+	var total int64
+	defer func() {
+		for _, file := range files {
+			file.File.Close()
+		}
+	}()
+
+	for _, id := range ids {
+		upload, err := us.GetUpload(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !upload.Complete() {
+			return nil, ErrUploadIncomplete
+		}
+		total += upload.Length
+
+		file, err := os.Open(us.path(id))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, models.File{File: file, KeepLocation: keepLocation})
+	}
+
+	err := us.quotaUsecase.CheckQuota(userID, total, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return us.mediaUpload.FileUploads(files)
+}
+
+// FinishUploads removes uploads that have been attached to a photo.
+func (us *uploadUsecase) FinishUploads(ids []string) {
+	for _, id := range ids {
+		us.remove(models.Upload{ID: id})
+	}
+}
+
+func (us *uploadUsecase) remove(upload models.Upload) error {
+	err := us.repository.DeleteUpload(upload)
+	if err != nil {
+		logUploadError(err)
+		return err
+	}
+
+	err = os.Remove(us.path(upload.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	logUploadError(err)
+
+	return nil
+}
+
+// PresignUpload godoc
+// @Summary      Create direct upload
+// @Description  Get a short-lived form for uploading an image straight to the storage backend instead of through the API. Send method to url with headers, or as a multipart form with fields followed by the file as "file". Declare the exact size, type and SHA-256 of the file; they are checked when the upload is finalized
+// @Tags         Upload
+// @Accept       json
+// @Produce      json
+// @Param        request body models.PresignUploadInput true "Payload Body [RAW]"
+// @Success      201
+// @Failure      400
+// @Failure      403
+// @Failure      413
+// @Failure      415
+// @Failure      500
+// @Failure      501
+// @Router       /uploads/presign [post]
+// @Security BearerAuth
+func (us *uploadUsecase) PresignUpload(userID int, input models.PresignUploadInput) (models.PresignedUpload, models.UploadForm, error) {
+	if input.Size < 1 {
+		return models.PresignedUpload{}, models.UploadForm{}, ErrPresignSize
+	}
+	if input.Size > us.maxSize {
+		return models.PresignedUpload{}, models.UploadForm{}, fmt.Errorf("%w: the limit is %d bytes", helpers.ErrImageTooLarge, us.maxSize)
+	}
+	if checksum, err := hex.DecodeString(input.Checksum); err != nil || len(checksum) != 32 {
+		return models.PresignedUpload{}, models.UploadForm{}, ErrPresignChecksum
+	}
+
+	err := us.quotaUsecase.CheckQuota(userID, input.Size, 0)
+	if err != nil {
+		return models.PresignedUpload{}, models.UploadForm{}, err
+	}
+
+	now := time.Now()
+	active, err := us.repository.CountActivePresignedUploads(userID, now)
+	if err != nil {
+		return models.PresignedUpload{}, models.UploadForm{}, err
+	}
+	if active >= models.MaxActiveUploads {
+		return models.PresignedUpload{}, models.UploadForm{}, ErrUploadLimit
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return models.PresignedUpload{}, models.UploadForm{}, err
+	}
+
+	key, form, err := us.mediaUpload.PresignUpload(input.ContentType, us.presignExpiry)
+	if err != nil {
+		return models.PresignedUpload{}, form, err
+	}
+
+	expiresAt := now.Add(us.expiry)
+	if expiresAt.Before(form.ExpiresAt) {
+		expiresAt = form.ExpiresAt
+	}
+
+	upload, err := us.repository.CreatePresignedUpload(models.PresignedUpload{
+		ID:          id,
+		UserID:      userID,
+		Key:         key,
+		ContentType: input.ContentType,
+		Size:        input.Size,
+		Checksum:    strings.ToLower(input.Checksum),
+		ExpiresAt:   expiresAt,
+	})
+
+	return upload, form, err
+}
+
+// FinalizeUpload godoc
+// @Summary      Finalize direct upload
+// @Description  Check a direct upload against its declared size, type and checksum and create a post from it. Takes the same fields as creating a photo, without the file
+// @Tags         Upload
+// @Accept       json
+// @Produce      json
+// @Param        request body models.PhotoResponseWithoutPhotoURL true "Payload Body [RAW]"
+// @Param        id path string true "Upload ID"
+// @Success      201
+// @Failure      400
+// @Failure      403
+// @Failure      413
+// @Failure      415
+// @Failure      500
+// @Router       /uploads/presign/{id}/finalize [post]
+// @Security BearerAuth
+func (us *uploadUsecase) FinalizeUpload(id string, userID int, keepLocation bool) (models.MediaObject, error) {
+	if !validUploadID(id) {
+		return models.MediaObject{}, ErrUploadNotFound
+	}
+
+	if !us.lock(id) {
+		return models.MediaObject{}, ErrUploadLocked
+	}
+	defer us.unlock(id)
+
+	upload, err := us.repository.FindPresignedUpload(id)
+	if err != nil || upload.UserID != userID {
+		return models.MediaObject{}, ErrUploadNotFound
+	}
+	if !time.Now().Before(upload.ExpiresAt) {
+		return models.MediaObject{}, ErrUploadExpired
+	}
+
+	err = us.quotaUsecase.CheckQuota(userID, upload.Size, 1)
+	if err != nil {
+		return models.MediaObject{}, err
+	}
+
+	object, err := us.mediaUpload.AdoptUpload(upload, keepLocation)
+	if err != nil {
+		return object, err
+	}
+
+	logUploadError(us.repository.DeletePresignedUpload(upload))
+
+	return object, nil
+}
+
+// ExpireUploads removes the uploads that have expired along with their
+// staged files or, for direct uploads that were never finalized, their
+// stored objects. It returns how many were removed.
+func (us *uploadUsecase) ExpireUploads() (int, error) {
+	expired, err := us.expirePresignedUploads()
+	if err != nil {
+		return expired, err
+	}
+
+	for {
+		uploads, err := us.repository.GetExpiredUploads(time.Now(), expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, upload := range uploads {
+			err := us.remove(upload)
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(uploads) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+func (us *uploadUsecase) expirePresignedUploads() (int, error) {
+	var expired int
+	for {
+		uploads, err := us.repository.GetExpiredPresignedUploads(time.Now(), expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, upload := range uploads {
+			logMediaError(us.mediaUpload.DeleteMedia([]string{upload.Key}))
+
+			err := us.repository.DeletePresignedUpload(upload)
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(uploads) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// StartUploadExpirer removes expired uploads every interval in the
+// background until the returned function is called.
+func StartUploadExpirer(uploadUsecase UploadUsecase, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				expired, err := uploadUsecase.ExpireUploads()
+				if expired > 0 {
+					log.Printf("upload expirer: removed %d uploads", expired)
+				}
+				if err != nil {
+					log.Printf("upload expirer: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// logUploadError logs a failed cleanup of a staged upload.
+func logUploadError(err error) {
+	if err != nil {
+		log.Printf("upload cleanup: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"mini-project-alterra/helpers"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type memoryUploadRepository struct {
+	repositories.UploadRepository
+	uploads   map[string]models.Upload
+	presigned map[string]models.PresignedUpload
+}
+
+func (r *memoryUploadRepository) CreateUpload(upload models.Upload) (models.Upload, error) {
+	r.uploads[upload.ID] = upload
+	return upload, nil
+}
+
+func (r *memoryUploadRepository) FindUpload(id string) (models.Upload, error) {
+	upload, ok := r.uploads[id]
+	if !ok {
+		return upload, gorm.ErrRecordNotFound
+	}
+	return upload, nil
+}
+
+func (r *memoryUploadRepository) CountActiveUploads(userID int, now time.Time) (int64, error) {
+	var count int64
+	for _, upload := range r.uploads {
+		if upload.UserID == userID && !upload.Complete() && upload.ExpiresAt.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUploadRepository) UpdateOffset(upload models.Upload, from int64) (bool, error) {
+	if r.uploads[upload.ID].Offset != from {
+		return false, nil
+	}
+	r.uploads[upload.ID] = upload
+	return true, nil
+}
+
+func (r *memoryUploadRepository) DeleteUpload(upload models.Upload) error {
+	delete(r.uploads, upload.ID)
+	return nil
+}
+
+func (r *memoryUploadRepository) GetExpiredUploads(now time.Time, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	for _, upload := range r.uploads {
+		if !upload.ExpiresAt.After(now) && len(uploads) < limit {
+			uploads = append(uploads, upload)
+		}
+	}
+	return uploads, nil
+}
+
+func (r *memoryUploadRepository) CreatePresignedUpload(upload models.PresignedUpload) (models.PresignedUpload, error) {
+	r.presigned[upload.ID] = upload
+	return upload, nil
+}
+
+func (r *memoryUploadRepository) FindPresignedUpload(id string) (models.PresignedUpload, error) {
+	upload, ok := r.presigned[id]
+	if !ok {
+		return upload, gorm.ErrRecordNotFound
+	}
+	return upload, nil
+}
+
+func (r *memoryUploadRepository) CountActivePresignedUploads(userID int, now time.Time) (int64, error) {
+	return int64(len(r.presigned)), nil
+}
+
+func (r *memoryUploadRepository) DeletePresignedUpload(upload models.PresignedUpload) error {
+	delete(r.presigned, upload.ID)
+	return nil
+}
+
+func (r *memoryUploadRepository) GetExpiredPresignedUploads(now time.Time, limit int) ([]models.PresignedUpload, error) {
+	var uploads []models.PresignedUpload
+	for _, upload := range r.presigned {
+		if !upload.ExpiresAt.After(now) && len(uploads) < limit {
+			uploads = append(uploads, upload)
+		}
+	}
+	return uploads, nil
+}
+
+func newTestUploadUsecase(t *testing.T) (*uploadUsecase, *memoryUploadRepository) {
+	uploadUsecase, repository, _ := newTestUploadUsecaseWithStore(t)
+	return uploadUsecase, repository
+}
+
+func newTestUploadUsecaseWithStore(t *testing.T) (*uploadUsecase, *memoryUploadRepository, *helpers.LocalMediaStore) {
+	store, err := helpers.NewLocalMediaStore(t.TempDir(), "/media", "secret")
+	assert.NoError(t, err)
+
+	repository := &memoryUploadRepository{uploads: map[string]models.Upload{}, presigned: map[string]models.PresignedUpload{}}
+	quotaUsecase := NewQuotaUsecase(storageUsageRepository{}, quotaUserRepository{}, nil)
+	uploadUsecase := NewUploadUsecase(repository, NewMediaUpload(store, helpers.DefaultImageLimits), quotaUsecase, t.TempDir(), 1<<20, time.Hour, 15*time.Minute)
+
+	return uploadUsecase, repository, store
+}
+
+func TestResumableUpload(t *testing.T) {
+	uploadUsecase, repository := newTestUploadUsecase(t)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 320, 240))))
+	data := buf.Bytes()
+	half := int64(len(data) / 2)
+
+	upload, err := uploadUsecase.CreateUpload(1, int64(len(data)), "filename YmFsaS5wbmc=")
+	assert.NoError(t, err)
+	assert.Len(t, upload.ID, 32)
+	assert.Equal(t, int64(0), upload.Offset)
+
+	_, err = uploadUsecase.GetUpload(upload.ID, 2)
+	assert.ErrorIs(t, err, ErrUploadNotFound, "other users cannot see the upload")
+
+	_, err = uploadUsecase.StoreUploads([]string{upload.ID}, 1, false)
+	assert.ErrorIs(t, err, ErrUploadIncomplete)
+
+	upload, err = uploadUsecase.WriteChunk(upload.ID, 1, 0, bytes.NewReader(data[:half]))
+	assert.NoError(t, err)
+	assert.Equal(t, half, upload.Offset)
+
+	_, err = uploadUsecase.WriteChunk(upload.ID, 1, 0, bytes.NewReader(data[:half]))
+	assert.ErrorIs(t, err, ErrUploadOffset, "the chunk was already received")
+
+	_, err = uploadUsecase.WriteChunk(upload.ID, 1, half, bytes.NewReader(append(data[half:], 0)))
+	assert.ErrorIs(t, err, helpers.ErrImageTooLarge, "the chunk goes past Upload-Length")
+
+	upload, err = uploadUsecase.GetUpload(upload.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, half, upload.Offset, "a rejected chunk is not recorded")
+
+	upload, err = uploadUsecase.WriteChunk(upload.ID, 1, half, bytes.NewReader(data[half:]))
+	assert.NoError(t, err)
+	assert.True(t, upload.Complete())
+
+	objects, err := uploadUsecase.StoreUploads([]string{upload.ID}, 1, false)
+	assert.NoError(t, err)
+	if assert.Len(t, objects, 1) {
+		assert.Equal(t, "image/png", objects[0].ContentType)
+		assert.Equal(t, 320, objects[0].Width)
+	}
+
+	uploadUsecase.FinishUploads([]string{upload.ID})
+	assert.Empty(t, repository.uploads)
+	_, err = os.Stat(uploadUsecase.path(upload.ID))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCreateUploadLimits(t *testing.T) {
+	uploadUsecase, _ := newTestUploadUsecase(t)
+
+	_, err := uploadUsecase.CreateUpload(1, 0, "")
+	assert.ErrorIs(t, err, ErrUploadLength)
+
+	_, err = uploadUsecase.CreateUpload(1, 1<<20+1, "")
+	assert.ErrorIs(t, err, helpers.ErrImageTooLarge)
+
+	for i := 0; i < models.MaxActiveUploads; i++ {
+		_, err = uploadUsecase.CreateUpload(1, 100, "")
+		assert.NoError(t, err)
+	}
+	_, err = uploadUsecase.CreateUpload(1, 100, "")
+	assert.ErrorIs(t, err, ErrUploadLimit)
+}
+
+func TestExpireUploads(t *testing.T) {
+	uploadUsecase, repository := newTestUploadUsecase(t)
+
+	expired, err := uploadUsecase.CreateUpload(1, 100, "")
+	assert.NoError(t, err)
+	active, err := uploadUsecase.CreateUpload(1, 100, "")
+	assert.NoError(t, err)
+
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	repository.uploads[expired.ID] = expired
+
+	_, err = uploadUsecase.WriteChunk(expired.ID, 1, 0, bytes.NewReader([]byte("chunk")))
+	assert.ErrorIs(t, err, ErrUploadExpired)
+
+	count, err := uploadUsecase.ExpireUploads()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = os.Stat(uploadUsecase.path(expired.ID))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = uploadUsecase.GetUpload(active.ID, 1)
+	assert.NoError(t, err)
+}
+
+func TestDirectUpload(t *testing.T) {
+	uploadUsecase, repository, store := newTestUploadUsecaseWithStore(t)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 320, 240))))
+	data := buf.Bytes()
+	sum := sha256.Sum256(data)
+	input := models.PresignUploadInput{ContentType: "image/png", Size: int64(len(data)), Checksum: hex.EncodeToString(sum[:])}
+
+	_, _, err := uploadUsecase.PresignUpload(1, models.PresignUploadInput{ContentType: "image/png", Size: 10, Checksum: "abc"})
+	assert.ErrorIs(t, err, ErrPresignChecksum)
+	_, _, err = uploadUsecase.PresignUpload(1, models.PresignUploadInput{ContentType: "application/pdf", Size: 10, Checksum: input.Checksum})
+	assert.ErrorIs(t, err, helpers.ErrImageUnsupported)
+
+	upload, form, err := uploadUsecase.PresignUpload(1, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", form.Method)
+	assert.True(t, strings.HasPrefix(form.URL, "/media/"+upload.Key+"?"))
+
+	_, err = uploadUsecase.FinalizeUpload(upload.ID, 1, false)
+	assert.ErrorIs(t, err, ErrUploadMissing)
+
+	ctx := context.Background()
+	_, err = store.Put(ctx, upload.Key, bytes.NewReader(data[1:]), -1, "image/png")
+	assert.NoError(t, err)
+	_, err = uploadUsecase.FinalizeUpload(upload.ID, 1, false)
+	assert.ErrorIs(t, err, ErrUploadMismatch)
+
+	_, err = store.Put(ctx, upload.Key, bytes.NewReader(data), -1, "image/png")
+	assert.NoError(t, err)
+	_, err = uploadUsecase.FinalizeUpload(upload.ID, 2, false)
+	assert.ErrorIs(t, err, ErrUploadNotFound, "other users cannot finalize the upload")
+
+	object, err := uploadUsecase.FinalizeUpload(upload.ID, 1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, upload.Key, object.Key, "PNG uploads are kept as they are")
+	assert.Equal(t, 320, object.Width)
+	assert.Len(t, object.Variants, len(helpers.PhotoVariantSpecs))
+	assert.Empty(t, repository.presigned)
+
+	_, err = uploadUsecase.FinalizeUpload(upload.ID, 1, false)
+	assert.ErrorIs(t, err, ErrUploadNotFound, "an upload is finalized once")
+}
@@ -13,15 +13,20 @@ type UserUsecase interface {
 	Register(input models.RegisterInput) (models.User, error)
 	GetCredential(userId int) (models.User, error)
 	UpdateUser(userId int, input models.User) (models.User, error)
+	UpdateSettings(userId int, input models.UserSettingsInput) (models.User, error)
 	DeleteUser(userId int) error
 }
 
+// ErrAccountSuspended is returned for users suspended by a moderator.
+var ErrAccountSuspended = errors.New("Account is suspended")
+
 type userUsecase struct {
-	repository repositories.UserRepository
+	repository  repositories.UserRepository
+	searchIndex repositories.SearchIndex
 }
 
-func NewUserUsecase(repository repositories.UserRepository) *userUsecase {
-	return &userUsecase{repository}
+func NewUserUsecase(repository repositories.UserRepository, searchIndex repositories.SearchIndex) *userUsecase {
+	return &userUsecase{repository, searchIndex}
 }
 
 // Login godoc
@@ -49,6 +54,10 @@ func (s *userUsecase) Login(input models.LoginInput) (string, error) {
 		return accessToken, errors.New("email/password is wrong")
 	}
 
+	if user.SuspendedAt != nil {
+		return accessToken, ErrAccountSuspended
+	}
+
 	accessToken, err := middlewares.CreateToken(int(user.ID))
 	if err != nil {
 		return accessToken, err
@@ -101,6 +110,8 @@ func (s *userUsecase) Register(input models.RegisterInput) (models.User, error)
 		return user, err
 	}
 
+	logIndexError(s.searchIndex.IndexUser(user))
+
 	return user, nil
 }
 
@@ -121,6 +132,9 @@ func (s *userUsecase) GetCredential(userId int) (models.User, error) {
 	if err != nil {
 		return user, err
 	}
+	if user.SuspendedAt != nil {
+		return models.User{}, ErrAccountSuspended
+	}
 
 	return user, nil
 }
@@ -178,12 +192,61 @@ func (s *userUsecase) UpdateUser(userId int, input models.User) (models.User, er
 		user.Password = password
 	}
 	user, err = s.repository.UpdateUser(user)
-	return user, err
+	if err != nil {
+		return user, err
+	}
+
+	logIndexError(s.searchIndex.IndexUser(user))
+
+	return user, nil
+}
+
+// UpdateSettings godoc
+// @Summary      Update user settings
+// @Description  Update account settings. Set keep_photo_location to keep the GPS position of uploaded photos, which is removed by default. default_visibility is used for new photos that do not set one. sensitive_content is show, blur or hide for photos other users flagged as sensitive; hidden ones are left out of listings. Set allow_reposts to false to stop other users from reposting your photos, which also hides existing reposts
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        request body models.UserSettingsInput true "Payload Body [RAW]"
+// @Success      200
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /users/settings [patch]
+// @Security BearerAuth
+func (s *userUsecase) UpdateSettings(userId int, input models.UserSettingsInput) (models.User, error) {
+	user, err := s.repository.GetUserById(userId)
+	if err != nil {
+		return user, err
+	}
+
+	if input.KeepPhotoLocation != nil {
+		user.KeepPhotoLocation = *input.KeepPhotoLocation
+	}
+	if input.DefaultVisibility != nil {
+		if !validPhotoVisibility(*input.DefaultVisibility) {
+			return user, errors.New("Visibility must be public, followers or private")
+		}
+		user.DefaultVisibility = *input.DefaultVisibility
+	}
+	if input.SensitiveContent != nil {
+		switch *input.SensitiveContent {
+		case models.SensitiveContentShow, models.SensitiveContentBlur, models.SensitiveContentHide:
+			user.SensitiveContent = *input.SensitiveContent
+		default:
+			return user, errors.New("Sensitive content must be show, blur or hide")
+		}
+	}
+	if input.AllowReposts != nil {
+		user.AllowReposts = *input.AllowReposts
+	}
+
+	return s.repository.UpdateUser(user)
 }
 
 // DeleteUser godoc
 // @Summary      Delete user
-// @Description  Delete user
+// @Description  Delete your account. Your photos and stories are removed with their media shortly after, which releases their storage
 // @Tags         User
 // @Accept       json
 // @Produce      json
@@ -200,5 +263,11 @@ func (s *userUsecase) DeleteUser(userId int) error {
 	}
 
 	err = s.repository.DeleteUser(user)
-	return err
+	if err != nil {
+		return err
+	}
+
+	logIndexError(s.searchIndex.Remove(models.SearchTypeUser, user.ID))
+
+	return nil
 }
@@ -0,0 +1,211 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+)
+
+type UserTagUsecase interface {
+	TagUser(input models.UserTagInput, photoID, userID int) (models.UserTag, int, error)
+	GetPendingTags(userID int) []models.UserTag
+	ApproveTag(tagID, userID int) (models.UserTag, error)
+	RemoveTag(tagID, userID int) error
+	GetTaggedPhotos(username string, viewerID, page, limit int) ([]models.Photo, error)
+}
+
+type userTagUsecase struct {
+	repository       repositories.UserTagRepository
+	photoRepository  repositories.PhotoRepository
+	userRepository   repositories.UserRepository
+	followRepository repositories.FollowRepository
+	blockRepository  repositories.BlockRepository
+}
+
+func NewUserTagUsecase(repository repositories.UserTagRepository, photoRepository repositories.PhotoRepository, userRepository repositories.UserRepository, followRepository repositories.FollowRepository, blockRepository repositories.BlockRepository) *userTagUsecase {
+	return &userTagUsecase{repository, photoRepository, userRepository, followRepository, blockRepository}
+}
+
+// TagUser godoc
+// @Summary      Tag user in photo
+// @Description  Tag a user at a position on one of your photos. x and y are fractions of the image width and height from the top left corner. The tag is shown once the tagged user approves it
+// @Tags         Photo
+// @Accept       json
+// @Produce      json
+// @Param        request body models.UserTagInput true "Payload Body [RAW]"
+// @Param id path int true "Photo ID"
+// @Success      201
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /photos/{id}/user-tags [post]
+// @Security BearerAuth
+func (ts *userTagUsecase) TagUser(input models.UserTagInput, photoID, userID int) (models.UserTag, int, error) {
+	var tag models.UserTag
+
+	photo, err := ts.photoRepository.FindByID(photoID)
+	if err != nil {
+		return tag, photo.UserID, err
+	}
+
+	if photo.UserID != userID {
+		return tag, photo.UserID, nil
+	}
+
+	if input.X < 0 || input.X > 1 || input.Y < 0 || input.Y > 1 {
+		return tag, photo.UserID, errors.New("x and y must be between 0 and 1")
+	}
+
+	user, err := ts.userRepository.GetUserByUsername(input.Username)
+	if err != nil {
+		return tag, photo.UserID, errors.New("User not found")
+	}
+
+	blocked, err := ts.blockRepository.IsBlocked(userID, int(user.ID))
+	if err != nil {
+		return tag, photo.UserID, err
+	}
+	// A blocked user cannot be told apart from one that does not exist.
+	if blocked {
+		return tag, photo.UserID, errors.New("User not found")
+	}
+
+	// The tagged user has to be able to see the photo, once it is published,
+	// to approve the tag.
+	if !canViewPhoto(ts.followRepository, models.Photo{UserID: photo.UserID, Visibility: photo.Visibility}, int(user.ID)) {
+		return tag, photo.UserID, errors.New("This user cannot see the photo")
+	}
+
+	tagged, err := ts.repository.IsTagged(photo.ID, user.ID)
+	if err != nil {
+		return tag, photo.UserID, err
+	}
+	if tagged {
+		return tag, photo.UserID, errors.New("User is already tagged in this photo")
+	}
+
+	count, err := ts.repository.CountPhotoUserTags(photo.ID)
+	if err != nil {
+		return tag, photo.UserID, err
+	}
+	if count >= models.MaxUserTags {
+		return tag, photo.UserID, fmt.Errorf("A photo can have at most %d people tagged", models.MaxUserTags)
+	}
+
+	tag.PhotoID = photo.ID
+	tag.UserID = user.ID
+	tag.X = input.X
+	tag.Y = input.Y
+	tag.Status = models.UserTagPending
+	// Tagging yourself needs no approval.
+	if int(user.ID) == userID {
+		tag.Status = models.UserTagApproved
+	}
+
+	tag, err = ts.repository.CreateUserTag(tag)
+	return tag, photo.UserID, err
+}
+
+// GetPendingTags godoc
+// @Summary      Get my pending tags
+// @Description  Get the tags of the user waiting for approval, newest first
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /user-tags/pending [get]
+// @Security BearerAuth
+func (ts *userTagUsecase) GetPendingTags(userID int) []models.UserTag {
+	tags, err := ts.repository.GetPendingUserTags(userID)
+	if err != nil {
+		return nil
+	}
+
+	return tags
+}
+
+// ApproveTag godoc
+// @Summary      Approve tag
+// @Description  Approve a tag of the user so it is shown on the photo and their profile
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "User tag ID"
+// @Router       /user-tags/{id}/approve [post]
+// @Security BearerAuth
+func (ts *userTagUsecase) ApproveTag(tagID, userID int) (models.UserTag, error) {
+	tag, err := ts.repository.FindByID(tagID)
+	if err != nil || int(tag.UserID) != userID {
+		return models.UserTag{}, errors.New("Tag not found")
+	}
+
+	err = ts.repository.ApproveUserTag(tag)
+	if err != nil {
+		return tag, err
+	}
+
+	tag.Status = models.UserTagApproved
+	return tag, nil
+}
+
+// RemoveTag godoc
+// @Summary      Remove tag
+// @Description  Remove a tag. The tagged user and the owner of the photo can remove it
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param id path int true "User tag ID"
+// @Router       /user-tags/{id} [delete]
+// @Security BearerAuth
+func (ts *userTagUsecase) RemoveTag(tagID, userID int) error {
+	tag, err := ts.repository.FindByID(tagID)
+	if err != nil || (int(tag.UserID) != userID && tag.Photo.UserID != userID) {
+		return errors.New("Tag not found")
+	}
+
+	return ts.repository.DeleteUserTag(tag)
+}
+
+// GetTaggedPhotos godoc
+// @Summary      Get tagged photos
+// @Description  Get the photos a user approved a tag in, most recently tagged first. Only photos the viewer may see are listed
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Param username path string true "Username"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(20)
+// @Router       /users/{username}/tagged [get]
+// @Security BearerAuth
+func (ts *userTagUsecase) GetTaggedPhotos(username string, viewerID, page, limit int) ([]models.Photo, error) {
+	user, err := ts.userRepository.GetUserByUsername(username)
+	if err != nil {
+		return nil, errors.New("User not found")
+	}
+
+	blocked, err := ts.blockRepository.IsBlocked(viewerID, int(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errors.New("User not found")
+	}
+
+	return ts.repository.GetTaggedPhotos(user.ID, viewerID, limit, (page-1)*limit)
+}
@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"errors"
+	"mini-project-alterra/models"
+	"mini-project-alterra/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type photoByIDRepository struct {
+	repositories.PhotoRepository
+	photos map[int]models.Photo
+}
+
+func (r photoByIDRepository) FindByID(photoID int) (models.Photo, error) {
+	photo, ok := r.photos[photoID]
+	if !ok {
+		return photo, gorm.ErrRecordNotFound
+	}
+	return photo, nil
+}
+
+type usernameRepository struct {
+	repositories.UserRepository
+	users map[string]models.User
+}
+
+func (r usernameRepository) GetUserByUsername(username string) (models.User, error) {
+	user, ok := r.users[username]
+	if !ok {
+		return user, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+type blockedRepository struct {
+	repositories.BlockRepository
+	blocked map[[2]int]bool
+}
+
+func (r blockedRepository) IsBlocked(userID, otherID int) (bool, error) {
+	return r.blocked[[2]int{userID, otherID}] || r.blocked[[2]int{otherID, userID}], nil
+}
+
+type memoryUserTagRepository struct {
+	repositories.UserTagRepository
+	tags []models.UserTag
+}
+
+func (r *memoryUserTagRepository) CreateUserTag(tag models.UserTag) (models.UserTag, error) {
+	tag.ID = uint(len(r.tags) + 1)
+	r.tags = append(r.tags, tag)
+	return tag, nil
+}
+
+func (r *memoryUserTagRepository) IsTagged(photoID, userID uint) (bool, error) {
+	for _, tag := range r.tags {
+		if tag.PhotoID == photoID && tag.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryUserTagRepository) CountPhotoUserTags(photoID uint) (int64, error) {
+	var count int64
+	for _, tag := range r.tags {
+		if tag.PhotoID == photoID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUserTagRepository) FindByID(tagID int) (models.UserTag, error) {
+	if tagID < 1 || tagID > len(r.tags) {
+		return models.UserTag{}, errors.New("record not found")
+	}
+	return r.tags[tagID-1], nil
+}
+
+func TestTagUser(t *testing.T) {
+	photos := photoByIDRepository{photos: map[int]models.Photo{
+		1: {Model: gorm.Model{ID: 1}, UserID: 1, Visibility: models.PhotoVisibilityPublic},
+		2: {Model: gorm.Model{ID: 2}, UserID: 1, Visibility: models.PhotoVisibilityPrivate},
+	}}
+	users := usernameRepository{users: map[string]models.User{
+		"hanif":  {Model: gorm.Model{ID: 1}, Username: "hanif"},
+		"budi":   {Model: gorm.Model{ID: 2}, Username: "budi"},
+		"blocky": {Model: gorm.Model{ID: 3}, Username: "blocky"},
+	}}
+	blocks := blockedRepository{blocked: map[[2]int]bool{{3, 1}: true}}
+	tags := &memoryUserTagRepository{}
+	userTagUsecase := NewUserTagUsecase(tags, photos, users, followingRepository{}, blocks)
+
+	tag, ownerID, err := userTagUsecase.TagUser(models.UserTagInput{Username: "budi", X: 0.5, Y: 0.25}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ownerID)
+	assert.Equal(t, models.UserTagPending, tag.Status)
+
+	_, _, err = userTagUsecase.TagUser(models.UserTagInput{Username: "budi", X: 0.1, Y: 0.1}, 1, 1)
+	assert.EqualError(t, err, "User is already tagged in this photo")
+
+	tag, _, err = userTagUsecase.TagUser(models.UserTagInput{Username: "hanif", X: 0.1, Y: 0.1}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserTagApproved, tag.Status)
+
+	_, ownerID, err = userTagUsecase.TagUser(models.UserTagInput{Username: "hanif", X: 0.1, Y: 0.1}, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ownerID, "only the owner can tag")
+
+	_, _, err = userTagUsecase.TagUser(models.UserTagInput{Username: "blocky", X: 0.1, Y: 0.1}, 1, 1)
+	assert.EqualError(t, err, "User not found")
+
+	_, _, err = userTagUsecase.TagUser(models.UserTagInput{Username: "budi", X: 1.5, Y: 0.1}, 1, 1)
+	assert.Error(t, err)
+
+	_, _, err = userTagUsecase.TagUser(models.UserTagInput{Username: "budi", X: 0.1, Y: 0.1}, 2, 1)
+	assert.EqualError(t, err, "This user cannot see the photo")
+
+	assert.Len(t, tags.tags, 2)
+}
+
+func TestApproveTagOnlyByTaggedUser(t *testing.T) {
+	tags := &memoryUserTagRepository{tags: []models.UserTag{
+		{ID: 1, PhotoID: 1, UserID: 2, Status: models.UserTagPending, Photo: models.Photo{UserID: 1}},
+	}}
+	userTagUsecase := NewUserTagUsecase(tags, nil, nil, nil, nil)
+
+	_, err := userTagUsecase.ApproveTag(1, 1)
+	assert.EqualError(t, err, "Tag not found")
+
+	assert.EqualError(t, userTagUsecase.RemoveTag(1, 3), "Tag not found")
+}